@@ -0,0 +1,151 @@
+package minectl
+
+import (
+	"fmt"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/internal/manifest"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	applyCmd.Flags().StringP("filename", "f", "", "Location of the manifest file or directory, applies every manifest")
+	_ = applyCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+	applyCmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key (required to update a server)")
+	applyCmd.Flags().BoolP("wait", "w", true, "Wait for Minecraft Server is started")
+	applyCmd.Flags().Duration("wait-timeout", provisioner.DefaultWaitTimeout, "Maximum time to wait for Minecraft Server to answer the ping")
+	applyCmd.Flags().Bool("dry-run", false, "Only print the plan, without changing anything")
+	addBackupFlag(applyCmd, "updated")
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create or update a Minecraft Server to match the manifest.",
+	Long: `Apply looks up the server of the manifest and reconciles it:
+the server is created when it is missing, updated when the manifest changed
+and left untouched when it is already in sync.`,
+	Example: `mincetl apply  \
+    --filename server-do.yaml \
+    --ssh-key ~/.ssh/minectl \
+    --dry-run`,
+	RunE:          RunFunc(runApply),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func runApply(cmd *cobra.Command, _ []string) error {
+	filename, err := cmd.Flags().GetString("filename")
+	if err != nil {
+		return errors.Wrap(err, "failed to get 'filename' value")
+	}
+	if filename == "" {
+		return errors.New("Please provide a valid manifest file via -f|--filename flag")
+	}
+	sshKey, err := cmd.Flags().GetString("ssh-key")
+	if err != nil {
+		return err
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+	documents, err := manifest.Load(filename)
+	if err != nil {
+		return err
+	}
+	waitTimeout, err := cmd.Flags().GetDuration("wait-timeout")
+	if err != nil {
+		return err
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return err
+	}
+
+	// plan all manifests first, so nothing is changed when one of them fails
+	provisioners := make([]*provisioner.MinectlProvisioner, 0, len(documents))
+	plans := make([]*provisioner.Plan, 0, len(documents))
+	for _, document := range documents {
+		p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+			Document:          document,
+			SSHPrivateKeyPath: sshKey,
+			Store:             stateStore(),
+			Profile:           profile,
+			WaitTimeout:       waitTimeout,
+		}, minectlUI)
+		if err != nil {
+			return err
+		}
+		plan, err := p.Plan(cmd.Context())
+		if err != nil {
+			return err
+		}
+		minectlUI.Info("Plan: " + plan.String())
+		if plan.Action == provisioner.ActionUpdate && sshKey == "" && !dryRun {
+			return errors.New("Please provide a valid ssh key path via -k|--ssh-key flag to update the server")
+		}
+		provisioners = append(provisioners, p)
+		plans = append(plans, plan)
+	}
+	if dryRun {
+		return nil
+	}
+	if err := checkNetworkSSHKey(provisioners, sshKey); err != nil {
+		return err
+	}
+	if err := checkPluginsSSHKey(documents, provisioners, sshKey); err != nil {
+		return err
+	}
+	backend, retention, err := backupBefore(cmd)
+	if err != nil {
+		return err
+	}
+	// all updated servers are backed up first, so a failed backup changes nothing
+	if backend != nil {
+		for i, p := range provisioners {
+			if plans[i].Action != provisioner.ActionUpdate {
+				continue
+			}
+			if err := snapshot(cmd.Context(), p, backend, retention, backup.ReasonPreUpdate); err != nil {
+				return err
+			}
+		}
+	}
+
+	wait := true
+	if cmd.Flags().Changed("wait") {
+		wait, _ = cmd.Flags().GetBool("wait")
+	}
+	table := ui.NewTable(minectlUI, "ID", "NAME", "REGION", "TAGS", "IP")
+	servers := make([]*automation.ResourceResults, 0, len(provisioners))
+	for i, p := range provisioners {
+		res, err := p.Apply(cmd.Context(), plans[i], wait)
+		if err != nil {
+			offerRollback(cmd, p, err)
+			return err
+		}
+		if plans[i].Action == provisioner.ActionNone {
+			minectlUI.Success(fmt.Sprintf("Server (%s) is already in sync.", res.Name))
+		}
+		servers = append(servers, res)
+		table.Append([]string{res.ID, res.Name, res.Region, res.Tags, res.PublicIP})
+	}
+	err = configureNetworks(cmd.Context(), documents, provisioners, servers, func(i int) bool {
+		return plans[i].Action != provisioner.ActionNone
+	})
+	if err != nil {
+		return err
+	}
+	if err := syncManifestPlugins(cmd.Context(), documents, provisioners); err != nil {
+		return err
+	}
+	if !headless {
+		fmt.Println("")
+		table.Render()
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package minectl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/dirien/minectl/internal/config"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	authAddCmd.Flags().StringP("provider", "p", "", "The cloud provider - "+strings.Join(provider.Names(), "|"))
+	authAddCmd.Flags().StringArray("set", nil, "Set a credential as KEY=VALUE, prompts for the credentials when omitted")
+	authAddCmd.Flags().Bool("current", false, "Make the profile the current profile")
+	authRemoveCmd.Flags().StringP("provider", "p", "", "The cloud provider, removes the whole profile when omitted")
+	authRemoveCmd.Flags().BoolP("yes", "y", false, "Automatically remove the credentials")
+	authCheckCmd.Flags().StringP("provider", "p", "", "Only check the credentials of this cloud provider")
+	authCheckCmd.Flags().StringP("region", "r", "", "The region (gce: zone) used for the check")
+
+	authCmd.AddCommand(authListCmd)
+	authCmd.AddCommand(authAddCmd)
+	authCmd.AddCommand(authRemoveCmd)
+	authCmd.AddCommand(authCheckCmd)
+}
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage the credential profiles of your cloud provider.",
+	Long: `Credentials are stored as named profiles in ~/.minectl/config.yaml.
+Select a profile with the global --profile flag, otherwise the current profile
+or the profile named default is used. Environment variables always take
+precedence over the credentials of a profile.`,
+}
+
+var authListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "List all credential profiles.",
+	Example:       `mincetl auth list`,
+	RunE:          RunFunc(runAuthList),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var authAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add the credentials of a cloud provider to a profile.",
+	Example: `mincetl auth add  \
+    --profile work \
+    --provider hetzner \
+    --set HCLOUD_TOKEN=xxx`,
+	RunE:          RunFunc(runAuthAdd),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var authRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove the credentials of a cloud provider or a whole profile.",
+	Example: `mincetl auth remove  \
+    --profile work \
+    --provider hetzner`,
+	RunE:          RunFunc(runAuthRemove),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var authCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Verify the credentials of a profile with the cloud provider.",
+	Long: `Check performs a cheap authenticated call (listing the servers) for every
+provider of the profile, using the credentials minectl would use for the
+provider, including environment variables.`,
+	Example: `mincetl auth check  \
+    --profile work`,
+	RunE:          RunFunc(runAuthCheck),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func runAuthList(_ *cobra.Command, _ []string) error {
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return err
+	}
+	names := cfg.ProfileNames()
+	if len(names) == 0 {
+		minectlUI.Info("No profiles found, add one with 'minectl auth add'")
+		return nil
+	}
+	current := cfg.ProfileName("")
+	fmt.Println("")
+	table := ui.NewTable(minectlUI, "PROFILE", "CURRENT", "PROVIDER", "KEYS")
+	for _, name := range names {
+		marker := ""
+		if name == current {
+			marker = "*"
+		}
+		profile := cfg.Profiles[name]
+		for _, providerName := range profile.Providers() {
+			keys := make([]string, 0, len(profile[providerName]))
+			for key := range profile[providerName] {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			table.Append([]string{name, marker, providerName, strings.Join(keys, ", ")})
+		}
+	}
+	table.Render()
+	return nil
+}
+
+func runAuthAdd(cmd *cobra.Command, _ []string) error {
+	providerName, err := cmd.Flags().GetString("provider")
+	if err != nil {
+		return errors.Wrap(err, "failed to get 'provider' value")
+	}
+	p, err := provider.Get(providerName)
+	if err != nil {
+		return err
+	}
+	values, err := cmd.Flags().GetStringArray("set")
+	if err != nil {
+		return err
+	}
+	var creds provider.Credentials
+	if len(values) > 0 {
+		creds, err = parseCredentials(p, values)
+	} else {
+		creds, err = promptCredentials(p)
+	}
+	if err != nil {
+		return err
+	}
+	if err := p.Validate(creds); err != nil {
+		minectlUI.Warn(err.Error() + " (they have to be set as environment variables)")
+	}
+
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return err
+	}
+	name := cfg.ProfileName(profileName)
+	cfg.SetCredentials(name, p.Name, creds)
+	if current, _ := cmd.Flags().GetBool("current"); current {
+		cfg.CurrentProfile = name
+	}
+	if err := cfg.Save(configPath()); err != nil {
+		return err
+	}
+	minectlUI.Success(fmt.Sprintf("Credentials for %s added to profile %s.", p.FullName, name))
+	return nil
+}
+
+// parseCredentials parses KEY=VALUE pairs and rejects keys the provider does not know.
+func parseCredentials(p *provider.Provider, values []string) (provider.Credentials, error) {
+	known := slices.Concat(p.CredentialKeys, p.OptionalCredentialKeys)
+	creds := provider.Credentials{}
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, errors.Errorf("invalid credential %q, please use KEY=VALUE", value)
+		}
+		if !slices.Contains(known, key) {
+			return nil, errors.Errorf("unknown credential %s for %s, valid keys are %s", key, p.FullName, strings.Join(known, ", "))
+		}
+		creds[key] = val
+	}
+	return creds, nil
+}
+
+func promptCredentials(p *provider.Provider) (provider.Credentials, error) {
+	keys := slices.Concat(p.CredentialKeys, p.OptionalCredentialKeys)
+	if len(keys) == 0 {
+		return nil, errors.Errorf("%s does not need any credentials", p.FullName)
+	}
+	values := make([]string, len(keys))
+	fields := make([]huh.Field, len(keys))
+	for i, key := range keys {
+		input := huh.NewInput().
+			Title(key).
+			EchoMode(huh.EchoModePassword).
+			Value(&values[i])
+		if !slices.Contains(p.CredentialKeys, key) {
+			input = input.Description("optional")
+		}
+		fields[i] = input
+	}
+	if err := ui.RunForm(huh.NewForm(huh.NewGroup(fields...)), headless); err != nil {
+		return nil, err
+	}
+	creds := provider.Credentials{}
+	for i, key := range keys {
+		if values[i] != "" {
+			creds[key] = values[i]
+		}
+	}
+	return creds, nil
+}
+
+func runAuthRemove(cmd *cobra.Command, _ []string) error {
+	providerName, err := cmd.Flags().GetString("provider")
+	if err != nil {
+		return errors.Wrap(err, "failed to get 'provider' value")
+	}
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return err
+	}
+	name := cfg.ProfileName(profileName)
+
+	what := "profile " + name
+	if providerName != "" {
+		what = fmt.Sprintf("credentials for %s from profile %s", providerName, name)
+	}
+	if !cmd.Flag("yes").Changed {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Do you want to remove the %s?", what))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			minectlUI.Warn("Remove canceled.")
+			return nil
+		}
+	}
+	if err := cfg.Remove(name, providerName); err != nil {
+		return err
+	}
+	if err := cfg.Save(configPath()); err != nil {
+		return err
+	}
+	minectlUI.Success(fmt.Sprintf("Removed the %s.", what))
+	return nil
+}
+
+func runAuthCheck(cmd *cobra.Command, _ []string) error {
+	providerName, err := cmd.Flags().GetString("provider")
+	if err != nil {
+		return errors.Wrap(err, "failed to get 'provider' value")
+	}
+	region, err := cmd.Flags().GetString("region")
+	if err != nil {
+		return errors.Wrap(err, "failed to get 'region' value")
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return err
+	}
+	providerNames := profile.Providers()
+	if providerName != "" {
+		providerNames = []string{providerName}
+	}
+	if len(providerNames) == 0 {
+		return errors.New("No credentials found in the profile, add them with 'minectl auth add'")
+	}
+
+	failed := 0
+	fmt.Println("")
+	table := ui.NewTable(minectlUI, "PROVIDER", "STATUS", "MESSAGE")
+	for _, name := range providerNames {
+		if err := checkCredentials(name, region, profile.For(name)); err != nil {
+			failed++
+			table.Append([]string{name, "FAILED", err.Error()})
+			continue
+		}
+		table.Append([]string{name, "OK", ""})
+	}
+	table.Render()
+	if failed > 0 {
+		return errors.Errorf("%d of %d credential checks failed", failed, len(providerNames))
+	}
+	return nil
+}
+
+func checkCredentials(name, region string, creds provider.Credentials) error {
+	auto, err := provider.New(name, region, creds)
+	if err != nil {
+		return err
+	}
+	_, err = auto.ListServer()
+	return err
+}
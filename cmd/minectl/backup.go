@@ -0,0 +1,238 @@
+package minectl
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/internal/config"
+	"github.com/dirien/minectl/internal/output"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{backupCreateCmd, backupRestoreCmd} {
+		cmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+		_ = cmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+		cmd.Flags().String("id", "", "Contains the server id")
+		cmd.Flags().String("name", "", "Name of the server in the local state (used when --id is omitted)")
+		cmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key")
+	}
+	backupCreateCmd.Flags().StringP("output", "o", "", output.FlagUsage)
+	backupListCmd.Flags().StringP("output", "o", "", output.FlagUsage)
+	backupRestoreCmd.Flags().String("backup", backup.Latest, "ID of the backup to restore")
+	backupRestoreCmd.Flags().BoolP("yes", "y", false, "Automatically replace the worlds of the server")
+	backupRestoreCmd.Flags().BoolP("wait", "w", true, "Wait for Minecraft Server is started")
+	backupRestoreCmd.Flags().Duration("wait-timeout", provisioner.DefaultWaitTimeout, "Maximum time to wait for Minecraft Server to answer the ping")
+
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up and restore the worlds of your Minecraft Server.",
+	Long: `Backups are tar archives of the worlds, compressed with zstd. They are stored
+in the backend of the backup section in ~/.minectl/config.yaml, a local
+directory or an S3-compatible bucket, by default in ~/.minectl/backups.`,
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Back up the worlds of a Minecraft Server.",
+	Example: `mincetl backup create  \
+    --name minecraft-server \
+    --ssh-key ~/.ssh/minectl`,
+	RunE:          RunFunc(runBackupCreate),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Annotations:   map[string]string{outputAnnotation: ""},
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list [name]",
+	Short: "List the backups of all or one Minecraft Server.",
+	Example: `mincetl backup list
+
+mincetl backup list minecraft-server --output json`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          RunFunc(runBackupList),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Annotations:   map[string]string{outputAnnotation: ""},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Replace the worlds of a Minecraft Server with a backup.",
+	Example: `mincetl backup restore  \
+    --name minecraft-server \
+    --ssh-key ~/.ssh/minectl
+
+mincetl backup restore  \
+    --name minecraft-server \
+    --backup 20240101T120000Z \
+    --ssh-key ~/.ssh/minectl`,
+	RunE:          RunFunc(runBackupRestore),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// backupSettings returns the backend and the retention of the configuration file.
+func backupSettings(ctx context.Context) (backup.Backend, backup.Retention, error) {
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return nil, backup.Retention{}, err
+	}
+	backend, err := backup.NewBackend(ctx, cfg.Backup, filepath.Join(GetHomeFolder(), "backups"))
+	if err != nil {
+		return nil, backup.Retention{}, err
+	}
+	var retention backup.Retention
+	if cfg.Backup != nil {
+		retention = cfg.Backup.Retention
+	}
+	return backend, retention, nil
+}
+
+// addBackupFlag adds --backup to a command which deletes or changes the worlds.
+func addBackupFlag(cmd *cobra.Command, action string) {
+	cmd.Flags().Bool("backup", false, fmt.Sprintf("Back up the worlds before the server is %s, aborting if the backup fails (default: true when a backup backend is configured in ~/.minectl/config.yaml)", action))
+}
+
+// backupBefore returns the backend and retention when the worlds have to be
+// backed up before the command changes them, otherwise a nil backend.
+func backupBefore(cmd *cobra.Command) (backup.Backend, backup.Retention, error) {
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return nil, backup.Retention{}, err
+	}
+	enabled := cfg.Backup.HasBackend()
+	if cmd.Flags().Changed("backup") {
+		if enabled, err = cmd.Flags().GetBool("backup"); err != nil {
+			return nil, backup.Retention{}, err
+		}
+	}
+	if !enabled {
+		return nil, backup.Retention{}, nil
+	}
+	return backupSettings(cmd.Context())
+}
+
+// snapshot backs up the worlds of the server before the operation given by reason.
+func snapshot(ctx context.Context, p provisioner.Provisioner, backend backup.Backend, retention backup.Retention, reason string) error {
+	info, err := p.Backup(ctx, backend, retention, reason)
+	if err != nil {
+		return errors.Wrap(err, "the backup failed, nothing was changed (use --backup=false to skip the backup)")
+	}
+	minectlUI.Info(fmt.Sprintf("Backup %s of %s stored at %s", info.ID, info.Server, output.NewBackup(info, backend).Location))
+	return nil
+}
+
+func runBackupCreate(cmd *cobra.Command, _ []string) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	p, err := sshProvisioner(cmd, 0)
+	if err != nil {
+		return err
+	}
+	backend, retention, err := backupSettings(cmd.Context())
+	if err != nil {
+		return err
+	}
+	info, err := p.Backup(cmd.Context(), backend, retention, backup.ReasonManual)
+	if err != nil {
+		return err
+	}
+	if format.IsMachineReadable() {
+		return output.Write(cmd.OutOrStdout(), format, output.NewBackup(info, backend))
+	}
+	return nil
+}
+
+func runBackupList(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	backend, _, err := backupSettings(cmd.Context())
+	if err != nil {
+		return err
+	}
+	var server string
+	if len(args) > 0 {
+		server = args[0]
+	}
+	backups, err := backup.List(cmd.Context(), backend, server)
+	if err != nil {
+		return err
+	}
+	list := output.BackupList{Backups: make([]output.Backup, 0, len(backups))}
+	for i := range backups {
+		list.Backups = append(list.Backups, output.NewBackup(&backups[i], backend))
+	}
+	if format.IsMachineReadable() {
+		return output.Write(cmd.OutOrStdout(), format, list)
+	}
+	if len(list.Backups) == 0 {
+		minectlUI.Info("No backups found in " + backend.String())
+		return nil
+	}
+	fmt.Println("")
+	table := ui.NewTable(minectlUI, "SERVER", "ID", "CREATED", "REASON", "VERSION", "WORLDS", "SIZE", "SHA256")
+	for _, b := range list.Backups {
+		table.Append([]string{b.Server, b.ID, b.Created.Local().Format(time.DateTime), b.Reason, b.Version,
+			strings.Join(b.Worlds, ", "), ui.FormatSize(b.Size), b.SHA256[:min(12, len(b.SHA256))]})
+	}
+	table.Render()
+	return nil
+}
+
+func runBackupRestore(cmd *cobra.Command, _ []string) error {
+	id, err := cmd.Flags().GetString("backup")
+	if err != nil {
+		return err
+	}
+	wait, err := cmd.Flags().GetBool("wait")
+	if err != nil {
+		return err
+	}
+	waitTimeout, err := cmd.Flags().GetDuration("wait-timeout")
+	if err != nil {
+		return err
+	}
+	p, err := sshProvisioner(cmd, waitTimeout)
+	if err != nil {
+		return err
+	}
+	backend, _, err := backupSettings(cmd.Context())
+	if err != nil {
+		return err
+	}
+	name := p.MinecraftResource().GetName()
+	info, err := backup.Find(cmd.Context(), backend, name, id)
+	if err != nil {
+		return err
+	}
+	if !cmd.Flag("yes").Changed {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Do you want to replace the worlds of %s with the backup %s (%s)?",
+			name, info.ID, strings.Join(info.Worlds, ", ")))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			minectlUI.Warn("Restore canceled.")
+			return nil
+		}
+	}
+	return p.Restore(cmd.Context(), backend, info, wait)
+}
@@ -1,8 +1,12 @@
 package minectl
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl/internal/manifest"
+	"github.com/dirien/minectl/internal/output"
 	"github.com/dirien/minectl/internal/provisioner"
 	"github.com/dirien/minectl/internal/ui"
 
@@ -11,9 +15,12 @@ import (
 )
 
 func init() {
-	createCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+	createCmd.Flags().StringP("filename", "f", "", "Location of the manifest file or directory, creates a server for every manifest")
 	_ = createCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
 	createCmd.Flags().BoolP("wait", "w", true, "Wait for Minecraft Server is started")
+	createCmd.Flags().Duration("wait-timeout", provisioner.DefaultWaitTimeout, "Maximum time to wait for Minecraft Server to answer the ping")
+	createCmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key (required to configure the network of a proxy)")
+	createCmd.Flags().StringP("output", "o", "", output.FlagUsage)
 }
 
 var createCmd = &cobra.Command{
@@ -24,6 +31,7 @@ var createCmd = &cobra.Command{
 	RunE:          RunFunc(runCreate),
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	Annotations:   map[string]string{outputAnnotation: ""},
 }
 
 func runCreate(cmd *cobra.Command, _ []string) error {
@@ -34,9 +42,19 @@ func runCreate(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return errors.Wrap(err, "Please provide a valid manifest file")
 	}
-	p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
-		ManifestPath: filename,
-	}, minectlUI)
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	sshKey, err := cmd.Flags().GetString("ssh-key")
+	if err != nil {
+		return err
+	}
+	documents, err := manifest.Load(filename)
+	if err != nil {
+		return err
+	}
+	profile, err := currentProfile()
 	if err != nil {
 		return err
 	}
@@ -44,17 +62,72 @@ func runCreate(cmd *cobra.Command, _ []string) error {
 	if cmd.Flags().Changed("wait") {
 		wait, _ = cmd.Flags().GetBool("wait")
 	}
-	res, err := p.CreateServer(wait)
+	waitTimeout, err := cmd.Flags().GetDuration("wait-timeout")
+	if err != nil {
+		return err
+	}
+	provisioners := make([]*provisioner.MinectlProvisioner, 0, len(documents))
+	for _, document := range documents {
+		p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+			Document:          document,
+			SSHPrivateKeyPath: sshKey,
+			Store:             stateStore(),
+			Profile:           profile,
+			WaitTimeout:       waitTimeout,
+		}, minectlUI)
+		if err != nil {
+			return err
+		}
+		provisioners = append(provisioners, p)
+	}
+	if err := checkNetworkSSHKey(provisioners, sshKey); err != nil {
+		return err
+	}
+	if err := checkPluginsSSHKey(documents, provisioners, sshKey); err != nil {
+		return err
+	}
+
+	list := output.ServerList{Servers: make([]output.Server, 0, len(documents))}
+	servers := make([]*automation.ResourceResults, 0, len(documents))
+	for i, p := range provisioners {
+		res, err := p.CreateServer(cmd.Context(), wait)
+		if err != nil {
+			offerRollback(cmd, p, err)
+			return err
+		}
+		servers = append(servers, res)
+		list.Servers = append(list.Servers, output.NewServer(res, documents[i].Resource.GetCloud()))
+	}
+	err = configureNetworks(cmd.Context(), documents, provisioners, servers, func(int) bool {
+		return true
+	})
 	if err != nil {
 		return err
 	}
+	if err := syncManifestPlugins(cmd.Context(), documents, provisioners); err != nil {
+		return err
+	}
+	if format.IsMachineReadable() {
+		if len(list.Servers) == 1 {
+			return output.Write(cmd.OutOrStdout(), format, list.Servers[0])
+		}
+		return output.Write(cmd.OutOrStdout(), format, list)
+	}
 	if !headless {
 		table := ui.NewTable(minectlUI, "ID", "NAME", "REGION", "TAGS", "IP")
-		table.Append([]string{res.ID, res.Name, res.Region, res.Tags, res.PublicIP})
+		for _, server := range list.Servers {
+			table.Append([]string{server.ID, server.Name, server.Region, server.Tags, server.PublicIP})
+		}
 
 		fmt.Println("")
 		table.Render()
 
+		if len(list.Servers) > 1 {
+			minectlUI.Info(fmt.Sprintf("The servers were added to the local state. To delete all of them:\n\n  minectl delete -f %s", filename))
+			return nil
+		}
+		res := list.Servers[0]
+		minectlUI.Info(fmt.Sprintf("The server was added to the local state, so you can omit the --id flag:\n\n  minectl delete -f %s", filename))
 		minectlUI.Info(fmt.Sprintf("To delete the server:\n\n  minectl delete -f %s --id %s", filename, res.ID))
 		minectlUI.Info(fmt.Sprintf("To update the server:\n\n  minectl update -f %s --id %s", filename, res.ID))
 		minectlUI.Info(fmt.Sprintf("To connect via RCON:\n\n  minectl rcon -f %s --id %s", filename, res.ID))
@@ -63,3 +136,27 @@ func runCreate(cmd *cobra.Command, _ []string) error {
 	}
 	return nil
 }
+
+// offerRollback asks whether a server whose creation did not complete should
+// be deleted. In headless mode, only the command to delete it is printed.
+func offerRollback(cmd *cobra.Command, p *provisioner.MinectlProvisioner, err error) {
+	var incomplete *provisioner.IncompleteServerError
+	if !errors.As(err, &incomplete) {
+		return
+	}
+	hint := fmt.Sprintf("The server (%s) was created but is incomplete. To delete it:\n\n  minectl delete --name %s --yes", incomplete.Server.ID, incomplete.Server.Name)
+	if headless {
+		minectlUI.Warn(hint)
+		return
+	}
+	confirmed, confirmErr := ui.Confirm(fmt.Sprintf("Creating the server (%s) did not complete. Do you want to roll it back?", incomplete.Server.Name))
+	if confirmErr != nil || !confirmed {
+		minectlUI.Warn(hint)
+		return
+	}
+	// the context of the command is already done
+	if rollbackErr := p.Rollback(context.WithoutCancel(cmd.Context()), incomplete.Server); rollbackErr != nil {
+		minectlUI.ErrorMsg(rollbackErr)
+		minectlUI.Warn(hint)
+	}
+}
@@ -1,6 +1,9 @@
 package minectl
 
 import (
+	"fmt"
+
+	"github.com/dirien/minectl/internal/backup"
 	"github.com/dirien/minectl/internal/provisioner"
 	"github.com/dirien/minectl/internal/ui"
 	"github.com/pkg/errors"
@@ -8,10 +11,13 @@ import (
 )
 
 func init() {
-	deleteCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+	deleteCmd.Flags().StringP("filename", "f", "", "Location of the manifest file or directory, deletes the servers of all manifests")
 	_ = deleteCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
 	deleteCmd.Flags().String("id", "", "Contains the server id")
+	deleteCmd.Flags().String("name", "", "Name of the server in the local state (used when --id is omitted)")
 	deleteCmd.Flags().BoolP("yes", "y", false, "Automatically delete the server")
+	deleteCmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key (required for --backup)")
+	addBackupFlag(deleteCmd, "deleted")
 }
 
 var deleteCmd = &cobra.Command{
@@ -20,6 +26,9 @@ var deleteCmd = &cobra.Command{
 	Example: `mincetl delete  \
     --filename server-do.yaml
     --id xxx-xxx-xxx-xxx
+
+mincetl delete  \
+    --name minecraft-server
 	`,
 	RunE:          RunFunc(runDelete),
 	SilenceUsage:  true,
@@ -27,48 +36,66 @@ var deleteCmd = &cobra.Command{
 }
 
 func runDelete(cmd *cobra.Command, _ []string) error {
-	filename, err := cmd.Flags().GetString("filename")
+	refs, err := resolveServers(cmd)
 	if err != nil {
-		return errors.Wrap(err, "failed to get 'filename' value")
-	}
-	if filename == "" {
-		return errors.New("Please provide a valid manifest file via -f|--filename flag")
+		return err
 	}
-	id, err := cmd.Flags().GetString("id")
+	sshKey, err := cmd.Flags().GetString("ssh-key")
 	if err != nil {
 		return err
 	}
-	if id == "" {
-		return errors.New("Please provide a valid id")
+	backend, retention, err := backupBefore(cmd)
+	if err != nil {
+		return err
 	}
-	newProvisioner, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
-		ManifestPath: filename,
-		ID:           id,
-	}, minectlUI)
+	profile, err := currentProfile()
 	if err != nil {
 		return err
 	}
-
-	yes := cmd.Flag("yes").Changed
-	if yes {
-		err = newProvisioner.DeleteServer()
+	provisioners := make([]*provisioner.MinectlProvisioner, 0, len(refs))
+	for _, ref := range refs {
+		p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+			Document:          ref.Document,
+			ID:                ref.ID,
+			SSHPrivateKeyPath: sshKey,
+			Store:             stateStore(),
+			Profile:           profile,
+		}, minectlUI)
 		if err != nil {
 			return err
 		}
-	} else {
-		confirmed, err := ui.Confirm("Do you want to delete the Minecraft server?")
+		if backend != nil && p.RequiresSSHKey() && sshKey == "" {
+			return errors.New("Please provide a valid ssh key path via -k|--ssh-key flag to back up the server, or skip the backup with --backup=false")
+		}
+		provisioners = append(provisioners, p)
+	}
+
+	if !cmd.Flag("yes").Changed {
+		title := "Do you want to delete the Minecraft server?"
+		if len(refs) > 1 {
+			title = fmt.Sprintf("Do you want to delete the %d Minecraft servers (%s)?", len(refs), serverNames(refs))
+		}
+		confirmed, err := ui.Confirm(title)
 		if err != nil {
 			return err
 		}
-		if confirmed {
-			err = newProvisioner.DeleteServer()
-			if err != nil {
+		if !confirmed {
+			minectlUI.Warn("Delete canceled.")
+			return nil
+		}
+	}
+	// all servers are backed up first, so a failed backup deletes nothing
+	if backend != nil {
+		for _, p := range provisioners {
+			if err := snapshot(cmd.Context(), p, backend, retention, backup.ReasonPreDelete); err != nil {
 				return err
 			}
-		} else {
-			minectlUI.Warn("Delete canceled.")
 		}
 	}
-
+	for _, p := range provisioners {
+		if err := p.DeleteServer(cmd.Context()); err != nil {
+			return err
+		}
+	}
 	return nil
 }
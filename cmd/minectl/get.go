@@ -0,0 +1,66 @@
+package minectl
+
+import (
+	"fmt"
+
+	"github.com/dirien/minectl/internal/output"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	getCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+	_ = getCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+	getCmd.Flags().String("id", "", "contains the server id")
+	getCmd.Flags().String("name", "", "name of the server in the local state (used when --id is omitted)")
+	getCmd.Flags().StringP("output", "o", "", output.FlagUsage)
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get the details of a Minecraft Server.",
+	Example: `mincetl get  \
+    --filename server-do.yaml \
+    --id xxx-xxx-xxx-xxx \
+    --output json`,
+	RunE:          RunFunc(runGet),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Annotations:   map[string]string{outputAnnotation: ""},
+}
+
+func runGet(cmd *cobra.Command, _ []string) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	ref, err := resolveServer(cmd)
+	if err != nil {
+		return err
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return err
+	}
+	p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+		Document: ref.Document,
+		ID:       ref.ID,
+		Profile:  profile,
+	}, minectlUI)
+	if err != nil {
+		return err
+	}
+	res, err := p.GetServer(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if format.IsMachineReadable() {
+		return output.Write(cmd.OutOrStdout(), format, output.NewServer(res, p.MinecraftResource().GetCloud()))
+	}
+	fmt.Println("")
+	table := ui.NewTable(minectlUI, "ID", "NAME", "REGION", "TAGS", "IP")
+	table.Append([]string{res.ID, res.Name, res.Region, res.Tags, res.PublicIP})
+	table.Render()
+	return nil
+}
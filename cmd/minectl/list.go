@@ -1,17 +1,23 @@
 package minectl
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
 
+	"github.com/dirien/minectl/internal/output"
 	"github.com/dirien/minectl/internal/provisioner"
 	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/pkg/provider"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 func init() {
-	listCmd.Flags().StringP("provider", "p", "", "The cloud provider - civo|scaleway|do|hetzner|akamai|ovh|gce|vultr|azure|oci|aws|vexxhost|fuga|multipass|exoscale")
-	listCmd.Flags().StringP("region", "r", "", "The region (gce: zone) for your cloud provider - civo|gce")
+	listCmd.Flags().StringP("provider", "p", "", "The cloud provider - "+strings.Join(provider.Names(), "|"))
+	listCmd.Flags().StringP("region", "r", "", "The region (gce: zone) for your cloud provider - "+strings.Join(provider.Names(provider.Regions), "|"))
+	listCmd.Flags().StringP("output", "o", "", output.FlagUsage)
 }
 
 var listCmd = &cobra.Command{
@@ -23,32 +29,47 @@ var listCmd = &cobra.Command{
 	RunE:          RunFunc(runList),
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	Annotations:   map[string]string{outputAnnotation: ""},
 }
 
 func runList(cmd *cobra.Command, _ []string) error {
-	provider, err := cmd.Flags().GetString("provider")
+	providerName, err := cmd.Flags().GetString("provider")
 	if err != nil {
 		return errors.Wrap(err, "failed to get 'provider' value")
 	}
-	if provider == "" {
+	if providerName == "" {
 		return errors.New("Please provide a valid 'provider' value")
 	}
 	region, err := cmd.Flags().GetString("region")
 	if err != nil {
 		return errors.Wrap(err, "failed to get 'region' value")
 	}
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
 
+	profile, err := currentProfile()
+	if err != nil {
+		return err
+	}
 	newProvisioner, err := provisioner.ListProvisioner(&provisioner.MinectlProvisionerListOpts{
-		Provider: provider,
+		Provider: providerName,
 		Region:   region,
+		Profile:  profile,
 	}, minectlUI)
 	if err != nil {
 		return err
 	}
-	servers, err := newProvisioner.ListServer()
+	servers, err := newProvisioner.ListServer(cmd.Context())
 	if err != nil {
 		return err
 	}
+	list := output.NewServerList(servers, providerName)
+	powerStates(cmd.Context(), newProvisioner, list.Servers)
+	if format.IsMachineReadable() {
+		return output.Write(cmd.OutOrStdout(), format, list)
+	}
 
 	if len(servers) == 0 {
 		minectlUI.Info("No servers found")
@@ -57,11 +78,30 @@ func runList(cmd *cobra.Command, _ []string) error {
 
 	if !headless {
 		fmt.Println("")
-		table := ui.NewTable(minectlUI, "ID", "NAME", "REGION", "TAGS", "IP")
-		for _, server := range servers {
-			table.Append([]string{server.ID, server.Name, server.Region, server.Tags, server.PublicIP})
+		table := ui.NewTable(minectlUI, "ID", "NAME", "REGION", "TAGS", "IP", "POWER")
+		for _, server := range list.Servers {
+			table.Append([]string{server.ID, server.Name, server.Region, server.Tags, server.PublicIP, server.PowerState})
 		}
 		table.Render()
 	}
 	return nil
 }
+
+// powerStates queries the power states of the servers in parallel. A server
+// whose state can not be queried is shown as unknown.
+func powerStates(ctx context.Context, p *provisioner.MinectlProvisioner, servers []output.Server) {
+	errs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i := range servers {
+		wg.Go(func() {
+			servers[i].PowerState, errs[i] = p.PowerState(ctx, servers[i].ID)
+		})
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			servers[i].PowerState = provider.PowerUnknown
+			minectlUI.Warn(fmt.Sprintf("Failed to query the power state of %s: %v", servers[i].Name, err))
+		}
+	}
+}
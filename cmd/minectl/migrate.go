@@ -0,0 +1,196 @@
+package minectl
+
+import (
+	"fmt"
+
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/internal/manifest"
+	"github.com/dirien/minectl/internal/ping"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/internal/world"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	migrateCmd.Flags().String("from", "", "Location of the manifest file of the existing server")
+	_ = migrateCmd.Flags().SetAnnotation("from", cobra.BashCompFilenameExt, []string{"yaml"})
+	migrateCmd.Flags().String("to", "", "Location of the manifest file of the new server")
+	_ = migrateCmd.Flags().SetAnnotation("to", cobra.BashCompFilenameExt, []string{"yaml"})
+	migrateCmd.Flags().String("id", "", "Contains the id of the existing server (default: the server of --from in the local state)")
+	migrateCmd.Flags().String("name", "", "Name of the manifest in --from and --to, when they contain several manifests")
+	migrateCmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key of the existing server")
+	migrateCmd.Flags().String("to-ssh-key", "", "specify a specific path for the SSH key of the new server (default: --ssh-key)")
+	migrateCmd.Flags().Bool("delete", false, "Delete the existing server after the migration succeeded")
+	migrateCmd.Flags().BoolP("yes", "y", false, "Automatically delete the existing server with --delete")
+	migrateCmd.Flags().Bool("force", false, "Migrate even when the new server runs an older Minecraft version")
+	migrateCmd.Flags().Duration("wait-timeout", provisioner.DefaultWaitTimeout, "Maximum time to wait for Minecraft Server to answer the ping")
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move a Minecraft Server with its worlds to another cloud provider.",
+	Long: `The worlds of the existing server are backed up, the new server is created from
+the manifest of --to, the backup is restored to it and the new server is pinged.
+The existing server is only deleted with --delete, after all steps succeeded.`,
+	Example: `mincetl migrate  \
+    --from server-do.yaml \
+    --to server-hetzner.yaml \
+    --ssh-key ~/.ssh/minectl
+
+mincetl migrate  \
+    --from server-do.yaml \
+    --id xxx-xxx-xxx-xxx \
+    --to server-hetzner.yaml \
+    --ssh-key ~/.ssh/minectl \
+    --delete`,
+	RunE:          RunFunc(runMigrate),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func runMigrate(cmd *cobra.Command, _ []string) error {
+	from, err := cmd.Flags().GetString("from")
+	if err != nil {
+		return err
+	}
+	to, err := cmd.Flags().GetString("to")
+	if err != nil {
+		return err
+	}
+	if from == "" || to == "" {
+		return errors.New("Please provide the manifest files of the existing and the new server via --from and --to")
+	}
+	id, err := cmd.Flags().GetString("id")
+	if err != nil {
+		return err
+	}
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+	sshKey, err := cmd.Flags().GetString("ssh-key")
+	if err != nil {
+		return err
+	}
+	toSSHKey, err := cmd.Flags().GetString("to-ssh-key")
+	if err != nil {
+		return err
+	}
+	if toSSHKey == "" {
+		toSSHKey = sshKey
+	}
+	deleteOld, err := cmd.Flags().GetBool("delete")
+	if err != nil {
+		return err
+	}
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	waitTimeout, err := cmd.Flags().GetDuration("wait-timeout")
+	if err != nil {
+		return err
+	}
+
+	source, err := loadDocument(from, name)
+	if err != nil {
+		return err
+	}
+	target, err := loadDocument(to, name)
+	if err != nil {
+		return err
+	}
+	if err := checkMigration(source, target, force); err != nil {
+		return err
+	}
+	if id == "" {
+		server, err := findServer(stateStore(), source.Resource.GetName(), source.Resource.GetCloud())
+		if err != nil {
+			return err
+		}
+		id = server.ID
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return err
+	}
+	oldServer, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+		Document:          source,
+		ID:                id,
+		SSHPrivateKeyPath: sshKey,
+		Store:             stateStore(),
+		Profile:           profile,
+	}, minectlUI)
+	if err != nil {
+		return err
+	}
+	newServer, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+		Document:          target,
+		SSHPrivateKeyPath: toSSHKey,
+		Store:             stateStore(),
+		Profile:           profile,
+		WaitTimeout:       waitTimeout,
+	}, minectlUI)
+	if err != nil {
+		return err
+	}
+	if (oldServer.RequiresSSHKey() && sshKey == "") || (newServer.RequiresSSHKey() && toSSHKey == "") {
+		return errors.New("Please provide a valid ssh key path via -k|--ssh-key flag")
+	}
+	backend, retention, err := backupSettings(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	info, err := oldServer.Backup(cmd.Context(), backend, retention, backup.ReasonMigrate)
+	if err != nil {
+		return errors.Wrap(err, "the backup failed, nothing was changed")
+	}
+	created, err := newServer.CreateServer(cmd.Context(), true)
+	if err != nil {
+		offerRollback(cmd, newServer, err)
+		return err
+	}
+	if err := newServer.Restore(cmd.Context(), backend, info, true); err != nil {
+		minectlUI.Warn(fmt.Sprintf("The existing server was not changed. To restore the backup to the new server again:\n\n  minectl backup restore -f %s --id %s --backup %s",
+			to, created.ID, info.ID))
+		return err
+	}
+	minectlUI.Info(fmt.Sprintf("Migrated %s to %s (%s) with the backup %s.", source.Resource.GetName(), created.ID, created.PublicIP, info.ID))
+
+	if !deleteOld {
+		minectlUI.Info(fmt.Sprintf("To delete the existing server:\n\n  minectl delete -f %s --id %s", from, id))
+		return nil
+	}
+	if !cmd.Flag("yes").Changed {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Do you want to delete the existing server %s (%s)?", source.Resource.GetName(), id))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			minectlUI.Warn("Delete canceled.")
+			return nil
+		}
+	}
+	return oldServer.DeleteServer(cmd.Context())
+}
+
+// checkMigration rejects migrations the worlds can not survive: to a proxy,
+// between the Java and Bedrock editions or, unless force is set, to an older
+// Minecraft version.
+func checkMigration(source, target *manifest.Document, force bool) error {
+	if source.Resource.IsProxyServer() || target.Resource.IsProxyServer() {
+		return errors.New("proxy servers have no worlds to migrate")
+	}
+	if ping.IsBedrock(source.Resource.GetEdition()) != ping.IsBedrock(target.Resource.GetEdition()) {
+		return errors.Errorf("the worlds of the %s edition can not be migrated to the %s edition",
+			source.Resource.GetEdition(), target.Resource.GetEdition())
+	}
+	if force {
+		return nil
+	}
+	err := world.CheckVersion(&world.Level{Version: source.Resource.GetVersion()}, target.Resource.GetVersion())
+	return errors.Wrap(err, "use --force to migrate anyway")
+}
@@ -7,17 +7,26 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
+	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/blang/semver/v4"
+	"github.com/dirien/minectl/internal/config"
 	"github.com/dirien/minectl/internal/logging"
+	"github.com/dirien/minectl/internal/manifest"
+	"github.com/dirien/minectl/internal/output"
 	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/state"
 	"github.com/dirien/minectl/internal/ui"
+	// the fake provider registers itself
+	_ "github.com/dirien/minectl/pkg/provider/fake"
 	"github.com/mitchellh/go-homedir"
 	"github.com/morikuni/aec"
 	pkgerrors "github.com/pkg/errors"
@@ -31,39 +40,233 @@ var (
 	Date      string
 )
 
-func createUpdatePluginProvisioner(cmd *cobra.Command) (provisioner.Provisioner, error) {
+// Exit codes of minectl besides 0 for success and 1 for errors. They follow
+// the conventions of timeout(1) and of shells for SIGINT.
+const (
+	exitTimeout     = 124
+	exitInterrupted = 130
+)
+
+// exitCode returns the exit code for the error of a command.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	default:
+		return 1
+	}
+}
+
+// commandContext derives the context of a command, which is canceled by
+// Ctrl+C in a spinner and after --timeout.
+func commandContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// outputAnnotation marks commands which support machine-readable output via the --output flag.
+const outputAnnotation = "minectl.ediri.io/output"
+
+// outputFormat returns the value of the --output flag for commands marked with outputAnnotation.
+func outputFormat(cmd *cobra.Command) (output.Format, error) {
+	if _, ok := cmd.Annotations[outputAnnotation]; !ok {
+		return output.Table, nil
+	}
+	value, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", err
+	}
+	return output.ParseFormat(value)
+}
+
+func configPath() string {
+	return filepath.Join(GetHomeFolder(), config.FileName)
+}
+
+// currentProfile returns the credential profile selected via --profile, the
+// current profile of the configuration file or the default profile.
+func currentProfile() (config.Profile, error) {
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Profile(profileName)
+}
+
+func stateStore() *state.Store {
+	return state.NewStore(filepath.Join(GetHomeFolder(), state.FileName))
+}
+
+// serverRef is an existing server and its manifest.
+type serverRef struct {
+	Document *manifest.Document
+	ID       string
+}
+
+// resolveServers returns the servers of all manifests of -f|--filename, which
+// can be a multi-document file or a directory. With --id or --name a single
+// server is selected. When --id is omitted, the servers are looked up in the
+// local state. The manifest path is taken from the state when -f|--filename
+// is omitted.
+func resolveServers(cmd *cobra.Command) ([]serverRef, error) {
 	filename, err := cmd.Flags().GetString("filename")
 	if err != nil {
 		return nil, pkgerrors.Wrap(err, "Please provide a valid manifest file")
 	}
+	id, err := cmd.Flags().GetString("id")
+	if err != nil {
+		return nil, err
+	}
+	name, _ := cmd.Flags().GetString("name")
+	if id != "" {
+		if filename == "" {
+			return nil, pkgerrors.New("Please provide a valid manifest file via -f|--filename flag")
+		}
+		document, err := loadDocument(filename, name)
+		if err != nil {
+			return nil, err
+		}
+		return []serverRef{{Document: document, ID: id}}, nil
+	}
+
+	store := stateStore()
+	if name != "" {
+		server, err := findServer(store, name, "")
+		if err != nil {
+			return nil, err
+		}
+		if filename == "" {
+			filename = server.ManifestPath
+		}
+		document, err := loadDocument(filename, name)
+		if err != nil {
+			return nil, err
+		}
+		return []serverRef{{Document: document, ID: server.ID}}, nil
+	}
+
 	if filename == "" {
-		return nil, pkgerrors.New("Please provide a valid manifest file via -f|--filename flag")
+		return nil, pkgerrors.New("Please provide a valid manifest file via -f|--filename flag or a server name via --name")
 	}
-	id, err := cmd.Flags().GetString("id")
+	documents, err := manifest.Load(filename)
 	if err != nil {
 		return nil, err
 	}
-	if id == "" {
-		return nil, pkgerrors.New("Please provide a valid id")
+	refs := make([]serverRef, 0, len(documents))
+	for _, document := range documents {
+		server, err := findServer(store, document.Resource.GetName(), document.Resource.GetCloud())
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, serverRef{Document: document, ID: server.ID})
 	}
+	return refs, nil
+}
+
+// resolveServer is resolveServers for commands which work on a single server.
+func resolveServer(cmd *cobra.Command) (serverRef, error) {
+	refs, err := resolveServers(cmd)
+	if err != nil {
+		return serverRef{}, err
+	}
+	if len(refs) != 1 {
+		return serverRef{}, pkgerrors.Errorf("found %d manifests (%s), please select one via --name", len(refs), serverNames(refs))
+	}
+	return refs[0], nil
+}
+
+// sshProvisioner returns the provisioner of the server selected by the flags
+// for commands which connect to the server with the key of --ssh-key.
+func sshProvisioner(cmd *cobra.Command, waitTimeout time.Duration) (*provisioner.MinectlProvisioner, error) {
 	sshKey, err := cmd.Flags().GetString("ssh-key")
 	if err != nil {
 		return nil, err
 	}
-	if sshKey == "" {
-		return nil, pkgerrors.New("Please provide a valid ssh key path")
+	ref, err := resolveServer(cmd)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return nil, err
 	}
 	p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
-		ManifestPath:      filename,
-		ID:                id,
+		Document:          ref.Document,
+		ID:                ref.ID,
 		SSHPrivateKeyPath: sshKey,
+		Store:             stateStore(),
+		Profile:           profile,
+		WaitTimeout:       waitTimeout,
 	}, minectlUI)
 	if err != nil {
 		return nil, err
 	}
+	if p.RequiresSSHKey() && sshKey == "" {
+		return nil, pkgerrors.New("Please provide a valid ssh key path via -k|--ssh-key flag")
+	}
 	return p, nil
 }
 
+func loadDocument(filename, name string) (*manifest.Document, error) {
+	documents, err := manifest.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Select(documents, name)
+}
+
+func findServer(store *state.Store, name, cloud string) (*state.Server, error) {
+	server, err := store.Find(name, cloud)
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return nil, pkgerrors.Wrap(err, "Please provide a valid id via --id or import the server with 'minectl state import'")
+		}
+		return nil, err
+	}
+	return server, nil
+}
+
+func serverNames(refs []serverRef) string {
+	names := make([]string, len(refs))
+	for i, ref := range refs {
+		names[i] = ref.Document.Resource.GetName()
+	}
+	return strings.Join(names, ", ")
+}
+
+func createUpdatePluginProvisioner(cmd *cobra.Command) (*provisioner.MinectlProvisioner, *manifest.Document, error) {
+	ref, err := resolveServer(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	sshKey, err := cmd.Flags().GetString("ssh-key")
+	if err != nil {
+		return nil, nil, err
+	}
+	if sshKey == "" {
+		return nil, nil, pkgerrors.New("Please provide a valid ssh key path")
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+		Document:          ref.Document,
+		ID:                ref.ID,
+		SSHPrivateKeyPath: sshKey,
+		Store:             stateStore(),
+		Profile:           profile,
+	}, minectlUI)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, ref.Document, nil
+}
+
 func isDevVersion(s semver.Version) bool {
 	if len(s.Pre) == 0 {
 		return false
@@ -145,7 +348,7 @@ func RunFunc(run func(cmd *cobra.Command, args []string) error) func(*cobra.Comm
 			if postRunErr := runPostCommandHooks(cmd, args); postRunErr != nil {
 				minectlUI.ErrorMsg(postRunErr)
 			}
-			os.Exit(1)
+			os.Exit(exitCode(res))
 		}
 		os.Exit(0)
 		return nil
@@ -241,6 +444,9 @@ func parseBaseCommand(_ *cobra.Command, _ []string) {
 
 var (
 	headless          bool
+	profileName       string
+	timeout           time.Duration
+	cancelCommand     context.CancelFunc
 	minectlLog        *logging.MinectlLogging
 	minectlUI         *ui.UI
 	updateCheckResult chan *string
@@ -260,12 +466,20 @@ var minectlCmd = &cobra.Command{
 		if headless && verbose == "" {
 			verbose = "info"
 		}
+		var opts []logging.Option
+		if format, err := outputFormat(cmd); err == nil && format.IsMachineReadable() {
+			opts = append(opts, logging.WithStderr())
+		}
 		var err error
-		minectlLog, err = logging.NewLogging(verbose, logEncoding, headless)
+		minectlLog, err = logging.NewLogging(verbose, logEncoding, headless, opts...)
 		if err != nil {
 			os.Exit(0)
 		}
 		minectlUI = ui.NewUI(headless, minectlLog)
+		var ctx context.Context
+		ctx, cancelCommand = commandContext(cmd.Context())
+		cmd.SetContext(ctx)
+		minectlUI.OnInterrupt(cancelCommand)
 		var waitForUpdateCheck bool
 		defer func() {
 			if !waitForUpdateCheck {
@@ -285,8 +499,8 @@ var minectlCmd = &cobra.Command{
 		checkVersionMsg, ok := <-updateCheckResult
 		if ok && checkVersionMsg != nil {
 			zap.S().Infof("Warning(%v)", checkVersionMsg)
-			fmt.Println()
-			fmt.Println(*checkVersionMsg)
+			fmt.Fprintln(minectlUI.Writer())
+			fmt.Fprintln(minectlUI.Writer(), *checkVersionMsg)
 		}
 		return nil
 	},
@@ -322,14 +536,32 @@ func init() {
 		"Set the log encoding: console|json (default: console)")
 	minectlCmd.PersistentFlags().Bool("headless", false,
 		"Set this value to if mincetl is called by a CI system. Enables logging and disables human-readable output rendering (default: false)")
+	minectlCmd.PersistentFlags().StringVar(&profileName, "profile", "",
+		"Use the credentials of this profile from ~/.minectl/config.yaml. Environment variables take precedence")
+	minectlCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0,
+		"Abort the command after this duration, e.g. 15m (default: no timeout)")
 	minectlCmd.AddCommand(versionCmd)
 	minectlCmd.AddCommand(createCmd)
+	minectlCmd.AddCommand(applyCmd)
 	minectlCmd.AddCommand(deleteCmd)
 	minectlCmd.AddCommand(listCmd)
+	minectlCmd.AddCommand(getCmd)
+	minectlCmd.AddCommand(statusCmd)
+	minectlCmd.AddCommand(stopCmd)
+	minectlCmd.AddCommand(startCmd)
+	minectlCmd.AddCommand(restartCmd)
+	minectlCmd.AddCommand(watchdogCmd)
+	minectlCmd.AddCommand(backupCmd)
+	minectlCmd.AddCommand(worldCmd)
+	minectlCmd.AddCommand(migrateCmd)
 	minectlCmd.AddCommand(wizardCmd)
 	minectlCmd.AddCommand(pluginCmd)
+	minectlCmd.AddCommand(modpackCmd)
 	minectlCmd.AddCommand(rconCmd)
 	minectlCmd.AddCommand(updateCmd)
+	minectlCmd.AddCommand(stateCmd)
+	minectlCmd.AddCommand(authCmd)
+	minectlCmd.AddCommand(validateCmd)
 }
 
 func Execute(version, gitCommit, date string) error {
@@ -337,7 +569,11 @@ func Execute(version, gitCommit, date string) error {
 	GitCommit = gitCommit
 	Date = date
 
-	return minectlCmd.Execute()
+	// the first SIGINT cancels the command, a second one terminates minectl
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+	return minectlCmd.ExecuteContext(ctx)
 }
 
 func runMineCtl(cmd *cobra.Command, _ []string) {
@@ -0,0 +1,1153 @@
+package minectl
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dirien/minectl-sdk/model"
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/internal/config"
+	"github.com/dirien/minectl/internal/logging"
+	"github.com/dirien/minectl/internal/manifest"
+	"github.com/dirien/minectl/internal/output"
+	"github.com/dirien/minectl/internal/plugins"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/state"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/dirien/minectl/pkg/provider/fake"
+	"github.com/mitchellh/go-homedir"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	testManifest  = "testdata/server-fake.yaml"
+	testManifests = "testdata/multi/servers.yaml"
+	// testFabricManifest is a Fabric server for the plugin commands.
+	testFabricManifest = "testdata/fabric/server.yaml"
+)
+
+// setupCommandTest isolates the home folder and the fake provider and prepares the globals of the CLI.
+func setupCommandTest(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	homedir.DisableCache = true
+	t.Setenv("HOME", home)
+	t.Setenv(fake.EnvState, filepath.Join(home, "fake.json"))
+	t.Setenv(fake.EnvFail, "")
+	t.Setenv(fake.EnvLatency, "")
+
+	l, err := logging.NewLogging("error", "console", true)
+	if err != nil {
+		t.Fatalf("failed to create logging: %v", err)
+	}
+	headless = true
+	profileName = ""
+	timeout = 0
+	minectlUI = ui.NewUI(true, l)
+}
+
+// runCommand sets the flags on the command, runs fn and resets the flags afterward.
+func runCommand(t *testing.T, cmd *cobra.Command, fn func(*cobra.Command, []string) error, flags map[string]string) (string, error) {
+	t.Helper()
+	return runCommandArgs(t, cmd, fn, flags)
+}
+
+// runCommandArgs is runCommand for commands with positional arguments.
+func runCommandArgs(t *testing.T, cmd *cobra.Command, fn func(*cobra.Command, []string) error, flags map[string]string, args ...string) (string, error) {
+	t.Helper()
+	for name, value := range flags {
+		if err := cmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("failed to set flag %s: %v", name, err)
+		}
+	}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	ctx, cancel := commandContext(t.Context())
+	defer cancel()
+	cmd.SetContext(ctx)
+	defer func() {
+		cmd.SetOut(nil)
+		for name := range flags {
+			f := cmd.Flags().Lookup(name)
+			if sv, ok := f.Value.(pflag.SliceValue); ok {
+				_ = sv.Replace(nil)
+			} else {
+				_ = f.Value.Set(f.DefValue)
+			}
+			f.Changed = false
+		}
+	}()
+	err := fn(cmd, args)
+	return buf.String(), err
+}
+
+func TestCreateGetDelete(t *testing.T) {
+	setupCommandTest(t)
+
+	out, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest, "output": "json"})
+	if err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	var created output.Server
+	if err := json.Unmarshal([]byte(out), &created); err != nil {
+		t.Fatalf("create output is not valid JSON: %v\n%s", err, out)
+	}
+	if created.ID == "" || created.Cloud != "fake" || created.Name != "minecraft-server" {
+		t.Fatalf("unexpected create output %+v", created)
+	}
+
+	// the server is resolved by its name from the local state, without -f and --id
+	out, err = runCommand(t, getCmd, runGet, map[string]string{"name": "minecraft-server", "output": "json"})
+	if err != nil {
+		t.Fatalf("get error = %v", err)
+	}
+	var got output.Server
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("get output is not valid JSON: %v\n%s", err, out)
+	}
+	if got != created {
+		t.Errorf("get = %+v, want %+v", got, created)
+	}
+
+	if _, err := runCommand(t, deleteCmd, runDelete, map[string]string{"filename": testManifest, "yes": "true"}); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+	servers, err := stateStore().List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(servers) != 0 {
+		t.Errorf("got %d servers in the state after delete, want 0", len(servers))
+	}
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		create  int
+		wantErr bool
+	}{
+		{"json with servers", "json", 2, false},
+		{"json without servers", "json", 0, false},
+		{"unknown format", "xml", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupCommandTest(t)
+			for range tt.create {
+				if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+					t.Fatalf("create error = %v", err)
+				}
+			}
+			out, err := runCommand(t, listCmd, runList, map[string]string{"provider": "fake", "output": tt.format})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("list error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var list output.ServerList
+			if err := json.Unmarshal([]byte(out), &list); err != nil {
+				t.Fatalf("list output is not valid JSON: %v\n%s", err, out)
+			}
+			if len(list.Servers) != tt.create {
+				t.Errorf("got %d servers, want %d", len(list.Servers), tt.create)
+			}
+		})
+	}
+}
+
+func TestApplyDryRun(t *testing.T) {
+	setupCommandTest(t)
+
+	if _, err := runCommand(t, applyCmd, runApply, map[string]string{"filename": testManifest, "dry-run": "true"}); err != nil {
+		t.Fatalf("apply error = %v", err)
+	}
+	servers, err := stateStore().List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(servers) != 0 {
+		t.Errorf("dry-run created %d servers", len(servers))
+	}
+
+	if _, err := runCommand(t, applyCmd, runApply, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("apply error = %v", err)
+	}
+	servers, err = stateStore().List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(servers) != 1 {
+		t.Errorf("got %d servers after apply, want 1", len(servers))
+	}
+}
+
+func TestResolveServer(t *testing.T) {
+	tests := []struct {
+		name         string
+		flags        map[string]string
+		wantID       string
+		wantNotFound bool
+		wantErr      bool
+	}{
+		{"explicit id", map[string]string{"filename": testManifest, "id": "42"}, "42", false, false},
+		{"id without manifest", map[string]string{"id": "42"}, "", false, true},
+		{"neither manifest nor name", map[string]string{}, "", false, true},
+		{"unknown name", map[string]string{"name": "unknown"}, "", true, true},
+		{"several manifests without name", map[string]string{"filename": testManifests, "id": "42"}, "", false, true},
+		{"several manifests with name", map[string]string{"filename": testManifests, "id": "42", "name": "lobby"}, "42", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupCommandTest(t)
+			var id string
+			_, err := runCommand(t, getCmd, func(cmd *cobra.Command, _ []string) error {
+				ref, err := resolveServer(cmd)
+				id = ref.ID
+				return err
+			}, tt.flags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveServer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantNotFound && !errors.Is(err, state.ErrNotFound) {
+				t.Errorf("got error %v, want ErrNotFound", err)
+			}
+			if id != tt.wantID {
+				t.Errorf("got id %q, want %q", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestAuthAddCheckRemove(t *testing.T) {
+	setupCommandTest(t)
+	profileName = "work"
+
+	_, err := runCommand(t, authAddCmd, runAuthAdd, map[string]string{"provider": "hetzner", "set": "UNKNOWN=value"})
+	if err == nil {
+		t.Fatal("expected an error for an unknown credential key")
+	}
+	_, err = runCommand(t, authAddCmd, runAuthAdd, map[string]string{"provider": "fake", "set": fake.EnvLatency + "=0s"})
+	if err != nil {
+		t.Fatalf("auth add error = %v", err)
+	}
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Profiles["work"].For("fake").Get(fake.EnvLatency); got != "0s" {
+		t.Fatalf("got %q, want %q", got, "0s")
+	}
+
+	if _, err := runCommand(t, authCheckCmd, runAuthCheck, nil); err != nil {
+		t.Fatalf("auth check error = %v", err)
+	}
+	t.Setenv(fake.EnvFail, "list")
+	if _, err := runCommand(t, authCheckCmd, runAuthCheck, nil); err == nil {
+		t.Fatal("expected auth check to fail")
+	}
+
+	if _, err := runCommand(t, authRemoveCmd, runAuthRemove, map[string]string{"yes": "true"}); err != nil {
+		t.Fatalf("auth remove error = %v", err)
+	}
+	profileName = "work"
+	if _, err := currentProfile(); err == nil {
+		t.Fatal("expected an error for the removed profile")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	setupCommandTest(t)
+
+	out, err := runCommand(t, validateCmd, runValidate, map[string]string{"filename": "testdata/*.yaml", "output": "json"})
+	if err != nil {
+		t.Fatalf("validate error = %v", err)
+	}
+	var report validateReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("validate output is not valid JSON: %v\n%s", err, out)
+	}
+	if len(report.Files) != 1 || report.Errors != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if _, err := runCommand(t, validateCmd, runValidate, map[string]string{"filename": "testdata/missing.yaml"}); err == nil {
+		t.Error("expected an error for a missing manifest")
+	}
+}
+
+func TestCreateDeleteMultiDocument(t *testing.T) {
+	setupCommandTest(t)
+
+	out, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": "testdata/multi", "output": "json"})
+	if err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	var list output.ServerList
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("create output is not valid JSON: %v\n%s", err, out)
+	}
+	if len(list.Servers) != 2 || list.Servers[0].Name != "lobby" || list.Servers[1].Name != "survival" {
+		t.Fatalf("unexpected create output %+v", list)
+	}
+
+	if _, err := runCommand(t, applyCmd, runApply, map[string]string{"filename": testManifests, "dry-run": "true"}); err != nil {
+		t.Fatalf("apply error = %v", err)
+	}
+
+	if _, err := runCommand(t, deleteCmd, runDelete, map[string]string{"filename": testManifests, "yes": "true"}); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+	servers, err := stateStore().List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(servers) != 0 {
+		t.Errorf("got %d servers after delete, want 0", len(servers))
+	}
+}
+
+func TestCreateApplyNetwork(t *testing.T) {
+	setupCommandTest(t)
+	t.Setenv("MINECTL_TEST_FORWARDING_SECRET", "s3cr3t")
+
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": "testdata/network"}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	servers, err := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	if len(servers) != 3 || servers[2].Name != "proxy" {
+		t.Fatalf("expected the backends to be created before the proxy, got %+v", servers)
+	}
+	config := servers[2].Files["/minecraft/velocity.toml"]
+	for _, want := range []string{
+		`lobby = "` + servers[0].PublicIP + `:25565"`,
+		`survival = "` + servers[1].PublicIP + `:25566"`,
+		`try = ["lobby"]`,
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("velocity.toml does not contain %s:\n%s", want, config)
+		}
+	}
+	if got := servers[2].Files["/minecraft/forwarding.secret"]; got != "s3cr3t" {
+		t.Errorf("got forwarding secret %q", got)
+	}
+	if len(servers[2].Commands) != 1 {
+		t.Errorf("expected the proxy to be restarted once, got %v", servers[2].Commands)
+	}
+
+	// nothing changed, so the network is not configured again
+	if _, err := runCommand(t, applyCmd, runApply, map[string]string{"filename": "testdata/network"}); err != nil {
+		t.Fatalf("apply error = %v", err)
+	}
+	servers, err = fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	if len(servers[2].Commands) != 1 {
+		t.Errorf("expected no restart of the proxy in sync, got %v", servers[2].Commands)
+	}
+}
+
+func TestCreateTimeout(t *testing.T) {
+	setupCommandTest(t)
+	t.Setenv(fake.EnvLatency, "300ms")
+	timeout = 50 * time.Millisecond
+
+	_, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest})
+	var incomplete *provisioner.IncompleteServerError
+	if !errors.As(err, &incomplete) {
+		t.Fatalf("expected IncompleteServerError, got %v", err)
+	}
+	if got := exitCode(err); got != exitTimeout {
+		t.Errorf("got exit code %d, want %d", got, exitTimeout)
+	}
+	// the server created after the timeout is recorded, so it can be deleted by name
+	if _, err := stateStore().Find("minecraft-server", "fake"); err != nil {
+		t.Errorf("expected the incomplete server in the state, got %v", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"error", errors.New("failed"), 1},
+		{"interrupted", pkgerrors.Wrap(context.Canceled, "interrupted while creating the server"), exitInterrupted},
+		{"timeout", pkgerrors.Wrap(context.DeadlineExceeded, "timed out while creating the server"), exitTimeout},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: got exit code %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	setupCommandTest(t)
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+
+	out, err := runCommandArgs(t, statusCmd, runStatus, map[string]string{"output": "json"}, "minecraft-server")
+	if err != nil {
+		t.Fatalf("status error = %v", err)
+	}
+	var status output.ServerStatus
+	if err := json.Unmarshal([]byte(out), &status); err != nil {
+		t.Fatalf("status output is not valid JSON: %v\n%s", err, out)
+	}
+	if !status.Online || status.Version != "1.21.11" || status.Edition != "java" || status.RCON != provisioner.RCONReachable {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// servers of the provider are listed with their manifest from the local state
+	out, err = runCommand(t, statusCmd, runStatus, map[string]string{"provider": "fake", "output": "json"})
+	if err != nil {
+		t.Fatalf("status --provider error = %v", err)
+	}
+	var list output.ServerStatusList
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("status output is not valid JSON: %v\n%s", err, out)
+	}
+	if len(list.Servers) != 1 || list.Servers[0].Cloud != "fake" || list.Servers[0].RCON != provisioner.RCONReachable {
+		t.Errorf("unexpected status list %+v", list)
+	}
+
+	t.Setenv(fake.EnvFail, string(fake.OperationPing))
+	out, err = runCommand(t, statusCmd, runStatus, map[string]string{"output": "json"})
+	if err != nil {
+		t.Fatalf("status error = %v", err)
+	}
+	list = output.ServerStatusList{}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("status output is not valid JSON: %v\n%s", err, out)
+	}
+	if len(list.Servers) != 1 || list.Servers[0].Online || list.Servers[0].Error == "" {
+		t.Errorf("got %+v, want an offline server with an error", list)
+	}
+
+	if _, err := runCommandArgs(t, statusCmd, runStatus, nil, "unknown"); !errors.Is(err, state.ErrNotFound) {
+		t.Errorf("status of an unknown server error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStatusWatch(t *testing.T) {
+	setupCommandTest(t)
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	// the timeout ends watching without an error
+	timeout = 250 * time.Millisecond
+	out, err := runCommand(t, statusCmd, runStatus, map[string]string{"watch": "true", "interval": "100ms", "output": "json"})
+	if err != nil {
+		t.Fatalf("status --watch error = %v", err)
+	}
+	if n := strings.Count(out, `"online": true`); n < 2 {
+		t.Errorf("got %d refreshes, want at least 2:\n%s", n, out)
+	}
+}
+
+func TestStopStartRestart(t *testing.T) {
+	setupCommandTest(t)
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	powerState := func() string {
+		t.Helper()
+		out, err := runCommand(t, listCmd, runList, map[string]string{"provider": "fake", "output": "json"})
+		if err != nil {
+			t.Fatalf("list error = %v", err)
+		}
+		var list output.ServerList
+		if err := json.Unmarshal([]byte(out), &list); err != nil {
+			t.Fatalf("list output is not valid JSON: %v\n%s", err, out)
+		}
+		if len(list.Servers) != 1 {
+			t.Fatalf("got %d servers, want 1", len(list.Servers))
+		}
+		return list.Servers[0].PowerState
+	}
+
+	steps := []struct {
+		name      string
+		cmd       *cobra.Command
+		action    string
+		flags     map[string]string
+		wantPower string
+	}{
+		{"stop", stopCmd, powerStop, map[string]string{"name": "minecraft-server"}, "stopped"},
+		{"start", startCmd, powerStart, map[string]string{"name": "minecraft-server"}, "running"},
+		{"restart", restartCmd, powerRestart, map[string]string{"name": "minecraft-server"}, "running"},
+		{"stop service only", stopCmd, powerStop, map[string]string{"name": "minecraft-server", "service-only": "true"}, "running"},
+		{"start service only", startCmd, powerStart, map[string]string{"name": "minecraft-server", "service-only": "true"}, "running"},
+	}
+	for _, step := range steps {
+		if _, err := runCommand(t, step.cmd, runPower(step.action), step.flags); err != nil {
+			t.Fatalf("%s error = %v", step.name, err)
+		}
+		if got := powerState(); got != step.wantPower {
+			t.Errorf("got power state %q after %s, want %q", got, step.name, step.wantPower)
+		}
+	}
+
+	servers, err := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	want := []string{"sudo systemctl stop minecraft.service", "sudo systemctl start minecraft.service"}
+	if strings.Join(servers[0].Commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got commands %q, want %q", servers[0].Commands, want)
+	}
+}
+
+func TestListUnknownPowerState(t *testing.T) {
+	setupCommandTest(t)
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	t.Setenv(fake.EnvFail, string(fake.OperationGet))
+	out, err := runCommand(t, listCmd, runList, map[string]string{"provider": "fake", "output": "json"})
+	if err != nil {
+		t.Fatalf("list error = %v", err)
+	}
+	var list output.ServerList
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("list output is not valid JSON: %v\n%s", err, out)
+	}
+	if len(list.Servers) != 1 || list.Servers[0].PowerState != provider.PowerUnknown {
+		t.Errorf("got servers %+v, want one with power state %s", list.Servers, provider.PowerUnknown)
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	setupCommandTest(t)
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	if _, err := runCommand(t, watchdogCmd, runWatchdog, map[string]string{"name": "minecraft-server"}); err == nil {
+		t.Error("watchdog without spec.autoShutdown and --idle should fail")
+	}
+	flags := map[string]string{
+		"name":     "minecraft-server",
+		"idle":     "100ms",
+		"warning":  "50ms",
+		"interval": "10ms",
+		"action":   "delete",
+	}
+	if _, err := runCommand(t, watchdogCmd, runWatchdog, flags); err != nil {
+		t.Fatalf("watchdog error = %v", err)
+	}
+	servers, err := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	if len(servers) != 0 {
+		t.Errorf("got %d servers after the watchdog, want the idle server deleted", len(servers))
+	}
+}
+
+func TestWatchdogConfig(t *testing.T) {
+	five := 5
+	tests := []struct {
+		name         string
+		autoShutdown *manifest.AutoShutdown
+		flags        map[string]string
+		wantWarning  time.Duration
+		wantErr      bool
+	}{
+		{"short idle time of the flag", nil, map[string]string{"idle": "3m"}, 90 * time.Second, false},
+		{"short idle time of the manifest", &manifest.AutoShutdown{IdleMinutes: 5}, nil, 150 * time.Second, false},
+		{"default warning", &manifest.AutoShutdown{IdleMinutes: 30}, nil, 5 * time.Minute, false},
+		{"flag shortens the idle time", &manifest.AutoShutdown{IdleMinutes: 30}, map[string]string{"idle": "2m"}, time.Minute, false},
+		{"explicit warning after the shutdown", &manifest.AutoShutdown{IdleMinutes: 30, WarningMinutes: &five}, map[string]string{"idle": "5m"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := &manifest.Document{Resource: &model.MinecraftResource{}, AutoShutdown: tt.autoShutdown}
+			var config *shutdownConfig
+			_, err := runCommand(t, watchdogCmd, func(cmd *cobra.Command, _ []string) error {
+				var err error
+				config, err = watchdogConfig(cmd, document)
+				return err
+			}, tt.flags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("watchdogConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && config.warning != tt.wantWarning {
+				t.Errorf("warning = %s, want %s", config.warning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestBackupCreateListRestore(t *testing.T) {
+	setupCommandTest(t)
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	out, err := runCommand(t, backupCreateCmd, runBackupCreate, map[string]string{"name": "minecraft-server", "output": "json"})
+	if err != nil {
+		t.Fatalf("backup create error = %v", err)
+	}
+	var created output.Backup
+	if err := json.Unmarshal([]byte(out), &created); err != nil {
+		t.Fatalf("backup create output is not valid JSON: %v\n%s", err, out)
+	}
+	if created.Server != "minecraft-server" || created.SHA256 == "" || strings.Join(created.Worlds, ",") != "world,world_nether,world_the_end" {
+		t.Errorf("got backup %+v", created)
+	}
+
+	out, err = runCommandArgs(t, backupListCmd, runBackupList, map[string]string{"output": "json"}, "minecraft-server")
+	if err != nil {
+		t.Fatalf("backup list error = %v", err)
+	}
+	var list output.BackupList
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("backup list output is not valid JSON: %v\n%s", err, out)
+	}
+	if len(list.Backups) != 1 || list.Backups[0].ID != created.ID {
+		t.Fatalf("got backups %+v, want %s", list.Backups, created.ID)
+	}
+
+	if _, err := runCommand(t, backupRestoreCmd, runBackupRestore, map[string]string{"name": "minecraft-server", "yes": "true"}); err != nil {
+		t.Fatalf("backup restore error = %v", err)
+	}
+	servers, err := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	commands := strings.Join(servers[0].Commands, "\n")
+	for _, want := range []string{"tar -cf -", "systemctl stop minecraft.service", "rm -rf -- 'world' 'world_nether' 'world_the_end' && sudo tar -xf -", "systemctl start minecraft.service"} {
+		if !strings.Contains(commands, want) {
+			t.Errorf("commands %q do not contain %q", commands, want)
+		}
+	}
+	if len(servers[0].World["world/level.dat"]) == 0 {
+		t.Error("the restored world has no level.dat")
+	}
+	if _, err := runCommand(t, backupRestoreCmd, runBackupRestore, map[string]string{"name": "minecraft-server", "yes": "true", "backup": "20000101T000000Z"}); err == nil {
+		t.Error("restoring an unknown backup should fail")
+	}
+}
+
+func TestBackupBeforeDeleteAndUpdate(t *testing.T) {
+	setupCommandTest(t)
+	backups := filepath.Join(t.TempDir(), "backups")
+	cfg := &config.Config{Backup: &backup.Config{Local: &backup.LocalConfig{Path: backups}}}
+	if err := cfg.Save(configPath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	listBackups := func() []output.Backup {
+		t.Helper()
+		out, err := runCommand(t, backupListCmd, runBackupList, map[string]string{"output": "json"})
+		if err != nil {
+			t.Fatalf("backup list error = %v", err)
+		}
+		var list output.BackupList
+		if err := json.Unmarshal([]byte(out), &list); err != nil {
+			t.Fatalf("backup list output is not valid JSON: %v\n%s", err, out)
+		}
+		return list.Backups
+	}
+
+	// update backs up by default, as a backend is configured
+	if _, err := runCommand(t, updateCmd, runUpdate, map[string]string{"name": "minecraft-server", "ssh-key": "unused"}); err != nil {
+		t.Fatalf("update error = %v", err)
+	}
+	if got := listBackups(); len(got) != 1 || got[0].Reason != backup.ReasonPreUpdate || !strings.HasPrefix(got[0].Location, backups) {
+		t.Fatalf("got backups %+v after update, want one pre-update backup in %s", got, backups)
+	}
+
+	// a failed backup aborts the delete
+	t.Setenv(fake.EnvFail, string(fake.OperationRemote))
+	if _, err := runCommand(t, deleteCmd, runDelete, map[string]string{"name": "minecraft-server", "yes": "true"}); err == nil {
+		t.Fatal("delete should fail when the backup fails")
+	}
+	if servers, _ := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers(); len(servers) != 1 {
+		t.Fatalf("got %d servers after the failed backup, want the server kept", len(servers))
+	}
+	t.Setenv(fake.EnvFail, "")
+
+	// the backups are taken in different seconds, as the ID is the creation time
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	if _, err := runCommand(t, deleteCmd, runDelete, map[string]string{"name": "minecraft-server", "yes": "true"}); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+	got := listBackups()
+	if len(got) != 2 || got[0].Reason != backup.ReasonPreDelete {
+		t.Errorf("got backups %+v after delete, want a pre-delete backup", got)
+	}
+}
+
+func TestBackupBeforeApplyAndWatchdog(t *testing.T) {
+	setupCommandTest(t)
+	backups := filepath.Join(t.TempDir(), "backups")
+	cfg := &config.Config{Backup: &backup.Config{Local: &backup.LocalConfig{Path: backups}}}
+	if err := cfg.Save(configPath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	content, err := os.ReadFile(testManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := filepath.Join(t.TempDir(), "server.yaml")
+	if err := os.WriteFile(changed, bytes.Replace(content, []byte("max-players=100"), []byte("max-players=50"), 1), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	countBackups := func(reason string) int {
+		t.Helper()
+		out, err := runCommand(t, backupListCmd, runBackupList, map[string]string{"output": "json"})
+		if err != nil {
+			t.Fatalf("backup list error = %v", err)
+		}
+		var list output.BackupList
+		if err := json.Unmarshal([]byte(out), &list); err != nil {
+			t.Fatalf("backup list output is not valid JSON: %v\n%s", err, out)
+		}
+		count := 0
+		for _, b := range list.Backups {
+			if b.Reason == reason {
+				count++
+			}
+		}
+		return count
+	}
+
+	// a failed backup aborts the apply
+	t.Setenv(fake.EnvFail, string(fake.OperationRemote))
+	if _, err := runCommand(t, applyCmd, runApply, map[string]string{"filename": changed, "ssh-key": "unused"}); err == nil {
+		t.Fatal("apply should fail when the backup fails")
+	}
+	t.Setenv(fake.EnvFail, "")
+	if _, err := runCommand(t, applyCmd, runApply, map[string]string{"filename": changed, "ssh-key": "unused"}); err != nil {
+		t.Fatalf("apply error = %v", err)
+	}
+	if got := countBackups(backup.ReasonPreUpdate); got != 1 {
+		t.Fatalf("got %d pre-update backups after apply, want 1", got)
+	}
+
+	flags := map[string]string{
+		"name":     "minecraft-server",
+		"idle":     "100ms",
+		"warning":  "50ms",
+		"interval": "10ms",
+		"action":   "delete",
+	}
+
+	// a failed backup aborts the watchdog before the delete
+	t.Setenv(fake.EnvFail, string(fake.OperationRemote))
+	if _, err := runCommand(t, watchdogCmd, runWatchdog, flags); err == nil {
+		t.Fatal("watchdog should fail when the backup fails")
+	}
+	if servers, _ := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers(); len(servers) != 1 {
+		t.Fatalf("got %d servers after the failed backup, want the server kept", len(servers))
+	}
+	t.Setenv(fake.EnvFail, "")
+	if _, err := runCommand(t, watchdogCmd, runWatchdog, flags); err != nil {
+		t.Fatalf("watchdog error = %v", err)
+	}
+	if got := countBackups(backup.ReasonPreDelete); got != 1 {
+		t.Errorf("got %d pre-delete backups after the watchdog, want 1", got)
+	}
+}
+
+func TestDeleteWithoutBackup(t *testing.T) {
+	setupCommandTest(t)
+	backups := filepath.Join(t.TempDir(), "backups")
+	cfg := &config.Config{Backup: &backup.Config{Local: &backup.LocalConfig{Path: backups}}}
+	if err := cfg.Save(configPath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	// --backup=false skips the backup even though the server can not be reached
+	t.Setenv(fake.EnvFail, string(fake.OperationRemote))
+	if _, err := runCommand(t, deleteCmd, runDelete, map[string]string{"name": "minecraft-server", "yes": "true", "backup": "false"}); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+	if entries, err := os.ReadDir(backups); err == nil && len(entries) > 0 {
+		t.Errorf("got backups %v, want none", entries)
+	}
+}
+
+func TestWorldPullPush(t *testing.T) {
+	setupCommandTest(t)
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	dir := t.TempDir()
+	if _, err := runCommand(t, worldPullCmd, runWorldPull, map[string]string{"name": "minecraft-server", "dir": dir}); err != nil {
+		t.Fatalf("world pull error = %v", err)
+	}
+	for _, name := range []string{"world/level.dat", "world/region/r.0.0.mca", "world_nether/level.dat", "world_the_end/level.dat"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("pulled worlds miss %s: %v", name, err)
+		}
+	}
+	if _, err := runCommand(t, worldPullCmd, runWorldPull, map[string]string{"name": "minecraft-server", "dir": dir}); err == nil {
+		t.Error("pulling into existing worlds should fail")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "world", "region", "r.1.0.mca"), []byte("region"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommandArgs(t, worldPushCmd, runWorldPush, map[string]string{"name": "minecraft-server", "yes": "true"}, filepath.Join(dir, "world")); err != nil {
+		t.Fatalf("world push error = %v", err)
+	}
+	servers, err := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	if string(servers[0].World["world/region/r.1.0.mca"]) != "region" {
+		t.Error("the pushed world misses region/r.1.0.mca")
+	}
+	commands := strings.Join(servers[0].Commands, "\n")
+	for _, want := range []string{"systemctl stop minecraft.service", "rm -rf -- 'world' 'world_nether' 'world_the_end' && sudo tar -xf -", "systemctl start minecraft.service"} {
+		if !strings.Contains(commands, want) {
+			t.Errorf("commands %q do not contain %q", commands, want)
+		}
+	}
+
+	if _, err := runCommandArgs(t, worldPushCmd, runWorldPush, map[string]string{"name": "minecraft-server", "yes": "true"}, dir); err == nil {
+		t.Error("pushing a directory without level.dat should fail")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	setupCommandTest(t)
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	servers, err := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	oldID := servers[0].ID
+
+	data, err := os.ReadFile(testManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	older := filepath.Join(t.TempDir(), "older.yaml")
+	if err := os.WriteFile(older, bytes.ReplaceAll(data, []byte(`"1.21.11"`), []byte(`"1.20.4"`)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, migrateCmd, runMigrate, map[string]string{"from": testManifest, "to": older}); err == nil {
+		t.Error("migrating to an older Minecraft version should fail")
+	}
+
+	if _, err := runCommand(t, migrateCmd, runMigrate, map[string]string{"from": testManifest, "to": testManifest, "delete": "true", "yes": "true"}); err != nil {
+		t.Fatalf("migrate error = %v", err)
+	}
+	servers, err = fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	if len(servers) != 1 || servers[0].ID == oldID {
+		t.Fatalf("got servers %+v, want only the new server", servers)
+	}
+	if commands := strings.Join(servers[0].Commands, "\n"); !strings.Contains(commands, "sudo tar -xf -") {
+		t.Errorf("the backup was not restored to the new server, commands %q", commands)
+	}
+	server, err := stateStore().Find("minecraft-server", "fake")
+	if err != nil || server.ID != servers[0].ID {
+		t.Errorf("local state has %+v, %v, want the new server %s", server, err, servers[0].ID)
+	}
+}
+
+// testJar returns a jar containing the files with their content.
+func testJar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// usePluginStandIns replaces the plugin sources with a stand-in for Modrinth,
+// which serves lithium requiring fabric-api, and a catalog with fabric-api.
+// Every jar is downloaded from the stand-in.
+func usePluginStandIns(t *testing.T) *httptest.Server {
+	t.Helper()
+	jar := testJar(t, map[string]string{"fabric.mod.json": `{"id": "mod"}`})
+	lithium := testJar(t, map[string]string{"fabric.mod.json": `{"id": "lithium", "name": "Lithium", "version": "0.14.0"}`})
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a stand-in for Modrinth with lithium, which requires fabric-api
+		var body any
+		switch r.URL.Path {
+		case "/project/lithium":
+			body = map[string]string{"id": "lithium-id", "slug": "lithium"}
+		case "/project/fabric-api-id":
+			body = map[string]string{"id": "fabric-api-id", "slug": "fabric-api"}
+		case "/project/lithium-id/version":
+			body = []map[string]any{{"version_number": "0.14.0", "files": []map[string]any{{"url": server.URL + "/lithium-0.14.0.jar", "filename": "lithium-0.14.0.jar", "primary": true}},
+				"dependencies": []map[string]string{{"project_id": "fabric-api-id", "dependency_type": "required"}}}}
+		case "/project/fabric-api-id/version":
+			body = []map[string]any{{"version_number": "0.110.0+1.21.4", "files": []map[string]any{{"url": server.URL + "/fabric-api-0.110.0.jar", "filename": "fabric-api-0.110.0.jar", "primary": true}}}}
+		case "/search":
+			body = map[string]any{"hits": []map[string]any{{"slug": "lithium", "title": "Lithium", "downloads": 42}}}
+		case "/lithium-0.14.0.jar":
+			_, _ = w.Write(lithium)
+			return
+		default:
+			_, _ = w.Write(jar)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+	sources := maps.Clone(pluginSources)
+	t.Cleanup(func() { pluginSources = sources })
+	pluginSources["modrinth"] = plugins.NewModrinth(server.Client(), server.URL)
+	pluginSources["catalog"] = plugins.Catalog{{
+		Name: "fabric-api",
+		Mod:  plugins.Fabric,
+		Version: []plugins.Version{
+			{Version: "0.110.0+1.21.4", Minecraft: []string{"1.21.4"}, SHA256: fmt.Sprintf("%x", sha256.Sum256(jar))},
+			{Version: "0.120.0+1.21.5", Minecraft: []string{"1.21.5"}, SHA256: fmt.Sprintf("%x", sha256.Sum256(jar))},
+		},
+		DownloadURL: server.URL + "/{{ .Version }}/fabric-api-{{ .Version }}.jar",
+	}}
+	return server
+}
+
+func TestPluginInstall(t *testing.T) {
+	setupCommandTest(t)
+	usePluginStandIns(t)
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testFabricManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	out, err := runCommandArgs(t, pluginSearchCmd, runPluginSearch, map[string]string{"name": "fabric-server", "output": "json"}, "lith")
+	if err != nil {
+		t.Fatalf("plugins search error = %v", err)
+	}
+	var found output.PluginList
+	if err := json.Unmarshal([]byte(out), &found); err != nil || len(found.Plugins) != 1 || found.Plugins[0].Name != "lithium" {
+		t.Errorf("plugins search = %s, %v", out, err)
+	}
+
+	if _, err := runCommandArgs(t, pluginInstallCmd, runPluginInstall, map[string]string{"name": "fabric-server"}, "fabric-api"); err != nil {
+		t.Fatalf("plugins install error = %v", err)
+	}
+	if _, err := runCommandArgs(t, pluginInstallCmd, runPluginInstall, map[string]string{"name": "fabric-server"}, "lithium"); err != nil {
+		t.Fatalf("plugins install error = %v", err)
+	}
+	servers, err := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	want := []string{"/minecraft/mods/fabric-api-0.110.0+1.21.4.jar", "/minecraft/mods/lithium-0.14.0.jar", "/minecraft/mods/fabric-api-0.110.0.jar"}
+	if !slices.Equal(servers[0].Plugins, want) {
+		t.Errorf("got plugins %v, want %v", servers[0].Plugins, want)
+	}
+	if _, err := runCommandArgs(t, pluginInstallCmd, runPluginInstall, map[string]string{"name": "fabric-server", "version": "0.120.0+1.21.5"}, "fabric-api"); err == nil {
+		t.Error("installing a version for another Minecraft version should fail")
+	}
+	if _, err := runCommandArgs(t, pluginInstallCmd, runPluginInstall, map[string]string{"name": "fabric-server", "source": "curseforge"}, "fabric-api"); err == nil {
+		t.Error("installing from an unknown source should fail")
+	}
+}
+
+func TestPluginSync(t *testing.T) {
+	setupCommandTest(t)
+	server := usePluginStandIns(t)
+	data, err := os.ReadFile(testFabricManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "server.yaml")
+	section := fmt.Sprintf("  plugins:\n    - name: lithium\n      version: 0.14.0\n    - name: chunky\n      url: %s/chunky-1.4.jar\n", server.URL)
+	if err := os.WriteFile(filename, append(data, section...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	installed := func() []string {
+		t.Helper()
+		servers, err := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+		if err != nil {
+			t.Fatalf("Servers() error = %v", err)
+		}
+		return servers[0].Plugins
+	}
+
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": filename}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	want := []string{"/minecraft/mods/lithium-0.14.0.jar", "/minecraft/mods/fabric-api-0.110.0.jar", "/minecraft/mods/chunky-1.4.jar"}
+	if got := installed(); !slices.Equal(got, want) {
+		t.Errorf("got plugins %v after create, want %v", got, want)
+	}
+	lock, err := os.ReadFile(filepath.Join(filepath.Dir(filename), "minectl.lock"))
+	if err != nil {
+		t.Fatalf("the lockfile was not written: %v", err)
+	}
+	for _, s := range []string{"fabric-server:", "requiredBy: lithium", "source: url"} {
+		if !strings.Contains(string(lock), s) {
+			t.Errorf("lockfile %s does not contain %q", lock, s)
+		}
+	}
+
+	// a jar unknown to the lockfile is removed, the others are kept
+	if _, err := runCommandArgs(t, pluginInstallCmd, runPluginInstall, map[string]string{"filename": filename}, "fabric-api"); err != nil {
+		t.Fatalf("plugins install error = %v", err)
+	}
+	if _, err := runCommand(t, pluginSyncCmd, runPluginSync, map[string]string{"filename": filename}); err != nil {
+		t.Fatalf("plugins sync error = %v", err)
+	}
+	if got := installed(); !slices.Equal(got, want) {
+		t.Errorf("got plugins %v after sync, want %v", got, want)
+	}
+	synced, err := os.ReadFile(filepath.Join(filepath.Dir(filename), "minectl.lock"))
+	if err != nil || !bytes.Equal(synced, lock) {
+		t.Errorf("the lockfile changed without a change of the plugins section: %s", synced)
+	}
+
+	if err := os.WriteFile(filename, append(data, "  plugins: []\n"...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, pluginSyncCmd, runPluginSync, map[string]string{"filename": filename}); err != nil {
+		t.Fatalf("plugins sync error = %v", err)
+	}
+	if got := installed(); len(got) != 0 {
+		t.Errorf("got plugins %v, want none for an empty plugins section", got)
+	}
+	if _, err := runCommand(t, pluginSyncCmd, runPluginSync, map[string]string{"filename": testFabricManifest}); err == nil {
+		t.Error("syncing a manifest without plugins section should fail")
+	}
+}
+
+func TestPluginListRemove(t *testing.T) {
+	setupCommandTest(t)
+	usePluginStandIns(t)
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testFabricManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	if _, err := runCommandArgs(t, pluginInstallCmd, runPluginInstall, map[string]string{"name": "fabric-server"}, "lithium"); err != nil {
+		t.Fatalf("plugins install error = %v", err)
+	}
+
+	out, err := runCommand(t, pluginListCmd, runPluginList, map[string]string{"name": "fabric-server", "output": "json"})
+	if err != nil {
+		t.Fatalf("plugins list error = %v", err)
+	}
+	var list output.InstalledPluginList
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("plugins list = %s, %v", out, err)
+	}
+	want := []output.InstalledPlugin{
+		{Name: "Lithium", Version: "0.14.0", Loader: "fabric", File: "lithium-0.14.0.jar", Path: "/minecraft/mods/lithium-0.14.0.jar"},
+		{Name: "mod", Loader: "fabric", File: "fabric-api-0.110.0.jar", Path: "/minecraft/mods/fabric-api-0.110.0.jar"},
+	}
+	for i := range list.Plugins {
+		list.Plugins[i].SHA256 = ""
+	}
+	if !slices.Equal(list.Plugins, want) {
+		t.Errorf("plugins list = %+v, want %+v", list.Plugins, want)
+	}
+
+	if _, err := runCommandArgs(t, pluginRemoveCmd, runPluginRemove, map[string]string{"name": "fabric-server", "restart": "true"}, "lithium"); err != nil {
+		t.Fatalf("plugins remove error = %v", err)
+	}
+	servers, err := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	if want := []string{"/minecraft/mods/fabric-api-0.110.0.jar"}; !slices.Equal(servers[0].Plugins, want) {
+		t.Errorf("got plugins %v, want %v", servers[0].Plugins, want)
+	}
+	if commands := strings.Join(servers[0].Commands, "\n"); !strings.Contains(commands, "systemctl restart minecraft.service") {
+		t.Errorf("Minecraft was not restarted, commands %q", commands)
+	}
+	if _, err := runCommandArgs(t, pluginRemoveCmd, runPluginRemove, map[string]string{"name": "fabric-server"}, "lithium"); err == nil {
+		t.Error("removing a plugin which is not installed should fail")
+	}
+}
+
+func TestModpackInstall(t *testing.T) {
+	setupCommandTest(t)
+	jar := testJar(t, map[string]string{"fabric.mod.json": `{"id": "lithium", "version": "0.14.0"}`})
+	sum := sha512.Sum512(jar)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(jar)
+	}))
+	defer server.Close()
+	writePack := func(minecraft string) string {
+		t.Helper()
+		index := fmt.Sprintf(`{"game": "minecraft", "name": "Optimized", "versionId": "1.0", "dependencies": {"minecraft": %q, "fabric-loader": "0.16.9"},
+			"files": [{"path": "mods/lithium.jar", "hashes": {"sha512": %q}, "env": {"server": "required"}, "downloads": [%q]}]}`,
+			minecraft, hex.EncodeToString(sum[:]), server.URL+"/lithium.jar")
+		pack := filepath.Join(t.TempDir(), "optimized.mrpack")
+		data := testJar(t, map[string]string{"modrinth.index.json": index, "overrides/config/lithium.properties": "mixin.ai=false"})
+		if err := os.WriteFile(pack, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return pack
+	}
+
+	if _, err := runCommand(t, createCmd, runCreate, map[string]string{"filename": testFabricManifest}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	if _, err := runCommandArgs(t, modpackInstallCmd, runModpackInstall, map[string]string{"name": "fabric-server"}, writePack("1.20.1")); err == nil {
+		t.Error("installing a modpack for another Minecraft version should fail")
+	}
+	if _, err := runCommandArgs(t, modpackInstallCmd, runModpackInstall, map[string]string{"name": "fabric-server"}, writePack("1.21.4")); err != nil {
+		t.Fatalf("modpack install error = %v", err)
+	}
+	servers, err := fake.New(fake.Options{Path: os.Getenv(fake.EnvState)}).Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	if want := []string{"/minecraft/mods/lithium.jar"}; !slices.Equal(servers[0].Plugins, want) {
+		t.Errorf("got plugins %v, want %v", servers[0].Plugins, want)
+	}
+	if got := servers[0].Files["/minecraft/config/lithium.properties"]; got != "mixin.ai=false" {
+		t.Errorf("the overrides were not uploaded, got %q", got)
+	}
+	if servers[0].ServiceStopped {
+		t.Error("Minecraft was not started again")
+	}
+}
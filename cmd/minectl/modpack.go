@@ -0,0 +1,102 @@
+package minectl
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/dirien/minectl/internal/modpack"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// curseForgeResolver looks up the files of CurseForge modpacks.
+var curseForgeResolver = func() *modpack.CurseForgeResolver {
+	return modpack.NewCurseForgeResolver(nil, "", os.Getenv(modpack.CurseForgeAPIKeyEnv))
+}
+
+func init() {
+	modpackInstallCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+	_ = modpackInstallCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+	modpackInstallCmd.Flags().String("id", "", "Contains the server id")
+	modpackInstallCmd.Flags().String("name", "", "Name of the server in the local state (used when --id is omitted)")
+	modpackInstallCmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key")
+	modpackInstallCmd.Flags().BoolP("wait", "w", true, "Wait for Minecraft Server is started")
+	modpackInstallCmd.Flags().Duration("wait-timeout", provisioner.DefaultWaitTimeout, "Maximum time to wait for Minecraft Server to answer the ping")
+
+	modpackCmd.AddCommand(modpackInstallCmd)
+}
+
+var modpackCmd = &cobra.Command{
+	Use:   "modpack",
+	Short: "Install modpacks on Forge and Fabric servers.",
+}
+
+var modpackInstallCmd = &cobra.Command{
+	Use:   "install <pack>",
+	Short: "Install a Modrinth (.mrpack) or CurseForge (.zip) modpack.",
+	Long: `The mods of the pack are downloaded and verified against the checksums of the
+pack, and uploaded over SSH with the configs and other overrides of the pack.
+The mod loader and Minecraft version of the pack have to match the edition and
+version of the manifest. The files of CurseForge modpacks are looked up via the
+CurseForge API, which needs the key in CURSEFORGE_API_KEY.`,
+	Example: `mincetl modpack install fabulously-optimized-6.4.0.mrpack \
+    --name minecraft-server \
+    --ssh-key ~/.ssh/minectl`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          RunFunc(runModpackInstall),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func runModpackInstall(cmd *cobra.Command, args []string) error {
+	wait, err := cmd.Flags().GetBool("wait")
+	if err != nil {
+		return err
+	}
+	waitTimeout, err := cmd.Flags().GetDuration("wait-timeout")
+	if err != nil {
+		return err
+	}
+	pack, err := modpack.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer pack.Close()
+	p, err := sshProvisioner(cmd, waitTimeout)
+	if err != nil {
+		return err
+	}
+	resource := p.MinecraftResource()
+	if err := pack.Check(resource.GetEdition(), resource.GetVersion()); err != nil {
+		return err
+	}
+	minectlUI.Info(fmt.Sprintf("%s %s needs %s %s on Minecraft %s", pack.Name, pack.Version, pack.Loader, pack.LoaderVersion, pack.Minecraft))
+	if p.ManagedPlugins() != nil {
+		minectlUI.Warn("The manifest has a plugins section, the next plugins sync removes the mods of the modpack which are not in it.")
+	}
+	if err := curseForgeResolver().Resolve(cmd.Context(), pack); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "minectl-modpack-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	progress := ui.NewProgress(fmt.Sprintf("Downloading %d files of %s...", len(pack.Files), pack.Name), pack.Size(), minectlUI)
+	progress.FinalMessage = fmt.Sprintf("%d files of %s downloaded and verified.", len(pack.Files), pack.Name)
+	progress.ErrorMessage = fmt.Sprintf("Files of %s not downloaded.", pack.Name)
+	progress.Start()
+	err = pack.Download(cmd.Context(), http.DefaultClient, dir, progress)
+	progress.Stop(err)
+	if err != nil {
+		return err
+	}
+	// the overrides replace downloaded files with the same path
+	if err := pack.ExtractOverrides(dir); err != nil {
+		return err
+	}
+	return p.UploadDir(cmd.Context(), dir, wait)
+}
@@ -0,0 +1,50 @@
+package minectl
+
+import (
+	"context"
+	"slices"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl/internal/manifest"
+	"github.com/dirien/minectl/internal/network"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/pkg/errors"
+)
+
+// checkNetworkSSHKey fails early, before any server is created, when a proxy
+// with a network can not be configured without the SSH key.
+func checkNetworkSSHKey(provisioners []*provisioner.MinectlProvisioner, sshKey string) error {
+	for _, p := range provisioners {
+		if p.HasNetwork() && p.RequiresSSHKey() && sshKey == "" {
+			return errors.Errorf("Please provide a valid ssh key path via -k|--ssh-key flag to configure the network of %s", p.MinecraftResource().GetName())
+		}
+	}
+	return nil
+}
+
+// configureNetworks configures the proxies with a network with the servers
+// of their backends. The slices are indexed like documents, changed reports
+// whether the server of a document was created or updated, so proxies whose
+// network is in sync are skipped.
+func configureNetworks(ctx context.Context, documents []*manifest.Document, provisioners []*provisioner.MinectlProvisioner, servers []*automation.ResourceResults, changed func(i int) bool) error {
+	for i, document := range documents {
+		if document.Network == nil {
+			continue
+		}
+		backends := manifest.Backends(documents, document)
+		needsUpdate := changed(i)
+		list := make([]network.Backend, 0, len(backends))
+		for _, backend := range backends {
+			j := slices.Index(documents, backend)
+			needsUpdate = needsUpdate || changed(j)
+			list = append(list, network.NewBackend(backend.Resource, servers[j].PublicIP))
+		}
+		if !needsUpdate {
+			continue
+		}
+		if err := provisioners[i].ConfigureNetwork(ctx, servers[i], list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,33 +1,80 @@
 package minectl
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/dirien/minectl-sdk/common"
+	"github.com/dirien/minectl-sdk/model"
+	"github.com/dirien/minectl/internal/manifest"
+	"github.com/dirien/minectl/internal/output"
+	"github.com/dirien/minectl/internal/plugins"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
+// pluginSources are the sources of plugins search and install by the name of --source.
+var pluginSources = map[string]plugins.Source{
+	"catalog":  plugins.DefaultCatalog,
+	"modrinth": plugins.NewModrinth(nil, ""),
+	"hangar":   plugins.NewHangar(nil, ""),
+}
+
 func init() {
 	pluginCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
 	_ = pluginCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
 	pluginCmd.Flags().String("id", "", "contains the server id")
+	pluginCmd.Flags().String("name", "", "name of the server in the local state (used when --id is omitted)")
 	pluginCmd.Flags().StringP("plugin", "p", "", "Location of the plugin")
 	_ = pluginCmd.Flags().SetAnnotation("plugin", cobra.BashCompFilenameExt, []string{"jar"})
 	pluginCmd.Flags().StringP("destination", "d", "", "Plugin destination folder")
 	_ = pluginCmd.Flags().SetAnnotation("destination", cobra.BashCompSubdirsInDir, []string{})
 	pluginCmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key")
-}
 
-type ModType string
+	pluginInstallCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+	_ = pluginInstallCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+	pluginInstallCmd.Flags().String("id", "", "contains the server id")
+	pluginInstallCmd.Flags().String("name", "", "name of the server in the local state (used when --id is omitted)")
+	pluginInstallCmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key")
+	pluginInstallCmd.Flags().String("version", "", "Version of the plugin (default: the newest version supporting the Minecraft version of the manifest)")
+	pluginInstallCmd.Flags().String("source", "", "Source of the plugin: catalog, modrinth or hangar (default: the catalog when it contains the plugin, otherwise modrinth)")
 
-const (
-	Forge  ModType = "forge"
-	Fabric ModType = "fabric"
-)
+	pluginSearchCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+	_ = pluginSearchCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+	pluginSearchCmd.Flags().String("name", "", "name of the server in the local state")
+	pluginSearchCmd.Flags().String("source", "modrinth", "Source to search: catalog, modrinth or hangar")
+	pluginSearchCmd.Flags().StringP("output", "o", "", output.FlagUsage)
+
+	pluginSyncCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+	_ = pluginSyncCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+	pluginSyncCmd.Flags().String("id", "", "contains the server id")
+	pluginSyncCmd.Flags().String("name", "", "name of the server in the local state (used when --id is omitted)")
+	pluginSyncCmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key")
+	pluginSyncCmd.Flags().Bool("upgrade", false, "Resolve the plugins again, even when minectl.lock matches the manifest")
+	pluginSyncCmd.Flags().Bool("restart", true, "Restart Minecraft when plugins were changed")
 
-type Plugin struct {
-	Name        string
-	Mod         ModType
-	Version     []string
-	DownloadURL string
-	Destination string
+	for _, cmd := range []*cobra.Command{pluginListCmd, pluginRemoveCmd} {
+		cmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+		_ = cmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+		cmd.Flags().String("id", "", "contains the server id")
+		cmd.Flags().String("name", "", "name of the server in the local state (used when --id is omitted)")
+		cmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key")
+	}
+	pluginListCmd.Flags().StringP("output", "o", "", output.FlagUsage)
+	pluginRemoveCmd.Flags().Bool("restart", false, "Restart Minecraft to unload the plugin, online players are warned via RCON")
+
+	pluginCmd.AddCommand(pluginInstallCmd)
+	pluginCmd.AddCommand(pluginSearchCmd)
+	pluginCmd.AddCommand(pluginSyncCmd)
+	pluginCmd.AddCommand(pluginListCmd)
+	pluginCmd.AddCommand(pluginRemoveCmd)
 }
 
 var pluginCmd = &cobra.Command{
@@ -43,26 +90,481 @@ var pluginCmd = &cobra.Command{
 	SilenceErrors: true,
 }
 
-var _ = []Plugin{
-	{
-		Name:        "Fabric API",
-		Mod:         Fabric,
-		Version:     []string{"0.37.1+1.16", "0.37.1+1.17"},
-		DownloadURL: "https://github.com/FabricMC/fabric/releases/download/{{ .Version }}/fabric-api-{{ .Version }}.jar",
-		Destination: "/mincraft/mods",
-	},
+var pluginInstallCmd = &cobra.Command{
+	Use:   "install <plugin>",
+	Short: "Install a plugin and its required dependencies on a specific server",
+	Long: `The version of the plugin and its required dependencies is resolved for the
+edition and Minecraft version of the manifest. The jars are downloaded,
+verified and uploaded to the plugins folder of Bukkit, Spigot, Paper and
+Purpur servers or the mods folder of Fabric and Forge servers.`,
+	Example: `mincetl plugins install fabric-api \
+    --name minecraft-server \
+    --ssh-key ~/.ssh/minectl
+
+mincetl plugins install luckperms \
+    --source hangar \
+    --name minecraft-server \
+    --ssh-key ~/.ssh/minectl`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          RunFunc(runPluginInstall),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var pluginSearchCmd = &cobra.Command{
+	Use:   "search <term>",
+	Short: "Search plugins for the edition and Minecraft version of a server",
+	Example: `mincetl plugins search lithium \
+    --name minecraft-server
+
+mincetl plugins search economy \
+    --filename server-paper.yaml \
+    --source hangar`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          RunFunc(runPluginSearch),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Annotations:   map[string]string{outputAnnotation: ""},
+}
+
+var pluginSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Make the plugins of a server match the plugins section of its manifest",
+	Long: `The plugins section of the manifest is resolved into minectl.lock next to the
+manifest, which pins the exact versions and checksums. The lockfile is only
+resolved again when the plugins section, the edition or the Minecraft version
+change, or with --upgrade. Jars missing on the server are uploaded, jars
+unknown to the lockfile are removed and Minecraft is restarted.`,
+	Example: `mincetl plugins sync \
+    --filename server-fabric.yaml \
+    --ssh-key ~/.ssh/minectl`,
+	RunE:          RunFunc(runPluginSync),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the plugins installed on a server",
+	Long: `List the jars in the plugin folder of the server with the name and version
+they declare in plugin.yml, fabric.mod.json or mods.toml.`,
+	Example: `mincetl plugins list \
+    --name minecraft-server \
+    --ssh-key ~/.ssh/minectl`,
+	RunE:          RunFunc(runPluginList),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Annotations:   map[string]string{outputAnnotation: ""},
+}
+
+var pluginRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a plugin from a server",
+	Long: `Remove the jars of a plugin from the plugin folder of the server. The plugin
+is matched by its name or ID, or by the file name of the jar.`,
+	Example: `mincetl plugins remove lithium \
+    --name minecraft-server \
+    --ssh-key ~/.ssh/minectl \
+    --restart`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          RunFunc(runPluginRemove),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// pluginSource returns the source of --source. Without it, plugins of the
+// catalog are resolved from the catalog and all others from Modrinth.
+func pluginSource(cmd *cobra.Command, plugin string) (plugins.Source, error) {
+	name, err := cmd.Flags().GetString("source")
+	if err != nil {
+		return nil, err
+	}
+	if name == plugins.SourceURL {
+		return nil, errors.New("plugins of the url source can only be installed via the plugins section of the manifest")
+	}
+	return plugins.SourceFor(pluginSources, plugins.Spec{Name: plugin, Source: name})
+}
+
+// pluginTarget returns the target of the manifest.
+func pluginTarget(resource *model.MinecraftResource) (plugins.Target, error) {
+	if resource.IsProxyServer() {
+		return plugins.Target{}, errors.New("plugins of proxy servers are not supported yet")
+	}
+	return plugins.TargetOf(resource.GetEdition(), resource.GetVersion())
 }
 
 func runPlugin(cmd *cobra.Command, _ []string) error {
-	p, err := createUpdatePluginProvisioner(cmd)
+	p, _, err := createUpdatePluginProvisioner(cmd)
 	if err != nil {
 		return err
 	}
 	plugin, _ := cmd.Flags().GetString("plugin")
 	destination, _ := cmd.Flags().GetString("destination")
-	err = p.UploadPlugin(plugin, destination)
+	err = p.UploadPlugin(cmd.Context(), plugin, destination)
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func runPluginInstall(cmd *cobra.Command, args []string) error {
+	version, err := cmd.Flags().GetString("version")
+	if err != nil {
+		return err
+	}
+	source, err := pluginSource(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	p, err := sshProvisioner(cmd, 0)
+	if err != nil {
+		return err
+	}
+	target, err := pluginTarget(p.MinecraftResource())
+	if err != nil {
+		return err
+	}
+	releases, err := plugins.ResolveAll(cmd.Context(), source, args[0], version, target)
+	if err != nil {
+		return err
+	}
+	if len(releases) > 1 {
+		dependencies := make([]string, 0, len(releases)-1)
+		for _, release := range releases[1:] {
+			dependencies = append(dependencies, release.Name+" "+release.Version)
+		}
+		minectlUI.Info(fmt.Sprintf("%s requires %s", releases[0].Name, strings.Join(dependencies, ", ")))
+	}
+
+	dir, err := os.MkdirTemp("", "minectl-plugin-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	for _, release := range releases {
+		jar, err := downloadPlugin(cmd.Context(), release, dir)
+		if err != nil {
+			return err
+		}
+		if err := p.UploadPlugin(cmd.Context(), jar, release.Destination); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadPlugin downloads and verifies the jar of the release into dir.
+func downloadPlugin(ctx context.Context, release *plugins.Release, dir string) (string, error) {
+	progress := ui.NewProgress(fmt.Sprintf("Downloading %s %s...", common.Green(release.Name), release.Version), 0, minectlUI)
+	progress.FinalMessage = fmt.Sprintf("%s %s downloaded and verified.", common.Green(release.Name), release.Version)
+	progress.ErrorMessage = fmt.Sprintf("%s %s not downloaded.", common.Green(release.Name), release.Version)
+	progress.Start()
+	jar, err := plugins.Download(ctx, http.DefaultClient, release, dir, progress)
+	progress.Stop(err)
+	return jar, err
+}
+
+func runPluginSearch(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	source, err := pluginSource(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	filename, err := cmd.Flags().GetString("filename")
+	if err != nil {
+		return err
+	}
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+	if filename == "" && name != "" {
+		server, err := findServer(stateStore(), name, "")
+		if err != nil {
+			return err
+		}
+		filename = server.ManifestPath
+	}
+	if filename == "" {
+		return errors.New("Please provide a valid manifest file via -f|--filename flag or a server name via --name")
+	}
+	document, err := loadDocument(filename, name)
+	if err != nil {
+		return err
+	}
+	target, err := pluginTarget(document.Resource)
+	if err != nil {
+		return err
+	}
+	projects, err := source.Search(cmd.Context(), args[0], target)
 	if err != nil {
 		return err
 	}
+	list := output.NewPluginList(projects)
+	if format.IsMachineReadable() {
+		return output.Write(cmd.OutOrStdout(), format, list)
+	}
+	if len(list.Plugins) == 0 {
+		minectlUI.Info(fmt.Sprintf("No plugins found for %s on Minecraft %s in %s", target.Mod, target.Minecraft, source))
+		return nil
+	}
+	fmt.Println("")
+	table := ui.NewTable(minectlUI, "NAME", "TITLE", "DOWNLOADS", "SOURCE", "DESCRIPTION")
+	for _, plugin := range list.Plugins {
+		description := []rune(plugin.Description)
+		if len(description) > 60 {
+			description = append(description[:57], []rune("...")...)
+		}
+		table.Append([]string{plugin.Name, plugin.Title, fmt.Sprint(plugin.Downloads), plugin.Source, string(description)})
+	}
+	table.Render()
+	return nil
+}
+
+func runPluginSync(cmd *cobra.Command, _ []string) error {
+	upgrade, err := cmd.Flags().GetBool("upgrade")
+	if err != nil {
+		return err
+	}
+	restart, err := cmd.Flags().GetBool("restart")
+	if err != nil {
+		return err
+	}
+	ref, err := resolveServer(cmd)
+	if err != nil {
+		return err
+	}
+	if ref.Document.Plugins == nil {
+		return errors.Errorf("the manifest of %s has no plugins section", ref.Document.Resource.GetName())
+	}
+	p, err := sshProvisioner(cmd, 0)
+	if err != nil {
+		return err
+	}
+	return syncPlugins(cmd.Context(), p, ref.Document, upgrade, restart)
+}
+
+// checkPluginsSSHKey fails early, before any server is created, when the
+// plugins of a manifest can not be synced without the SSH key.
+func checkPluginsSSHKey(documents []*manifest.Document, provisioners []*provisioner.MinectlProvisioner, sshKey string) error {
+	for i, p := range provisioners {
+		if documents[i].Plugins != nil && p.RequiresSSHKey() && sshKey == "" {
+			return errors.Errorf("Please provide a valid ssh key path via -k|--ssh-key flag to install the plugins of %s", p.MinecraftResource().GetName())
+		}
+	}
+	return nil
+}
+
+// syncManifestPlugins syncs the plugins of the manifests with a plugins
+// section, after their servers were created or updated.
+func syncManifestPlugins(ctx context.Context, documents []*manifest.Document, provisioners []*provisioner.MinectlProvisioner) error {
+	for i, document := range documents {
+		if document.Plugins == nil {
+			continue
+		}
+		if err := syncPlugins(ctx, provisioners[i], document, false, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncPlugins makes the plugin folder of the server match the lockfile of
+// the manifest, resolving the plugins section into the lockfile first when
+// it is outdated.
+func syncPlugins(ctx context.Context, p *provisioner.MinectlProvisioner, document *manifest.Document, upgrade, restart bool) error {
+	name := document.Resource.GetName()
+	target, err := pluginTarget(document.Resource)
+	if err != nil {
+		return err
+	}
+	lockPath := plugins.LockPath(document.File)
+	lock, err := plugins.ReadLock(lockPath)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "minectl-plugin-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// jars downloaded while locking, keyed by their path on the server
+	downloaded := map[string]string{}
+	specs := plugins.SpecsHash(document.Plugins, target)
+	locked := lock.Servers[name]
+	if upgrade || locked == nil || locked.Specs != specs {
+		releases, err := plugins.ResolveSpecs(ctx, pluginSources, document.Plugins, target)
+		if err != nil {
+			return err
+		}
+		locked = &plugins.LockedServer{Mod: target.Mod, Minecraft: target.Minecraft, Specs: specs, Plugins: []plugins.LockedPlugin{}}
+		for _, release := range releases {
+			jar, err := downloadPlugin(ctx, release, dir)
+			if err != nil {
+				return err
+			}
+			sum, err := plugins.Checksum(jar)
+			if err != nil {
+				return err
+			}
+			plugin := plugins.LockedPlugin{
+				Name:        release.Name,
+				Source:      release.Source,
+				Version:     release.Version,
+				URL:         release.URL,
+				File:        release.FileName(),
+				SHA256:      sum,
+				Destination: release.Destination,
+				RequiredBy:  release.RequiredBy,
+			}
+			locked.Plugins = append(locked.Plugins, plugin)
+			downloaded[plugin.Path()] = jar
+		}
+		lock.Servers[name] = locked
+		if err := lock.Write(lockPath); err != nil {
+			return err
+		}
+		minectlUI.Info(fmt.Sprintf("Locked %d plugin(s) of %s in %s", len(locked.Plugins), name, lockPath))
+	}
+
+	installed, err := p.InstalledJars(ctx, target.Mod.Destination())
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, plugin := range locked.Plugins {
+		if slices.Contains(installed, provisioner.Jar{Path: plugin.Path(), SHA256: plugin.SHA256}) {
+			continue
+		}
+		jar, ok := downloaded[plugin.Path()]
+		if !ok {
+			if jar, err = downloadPlugin(ctx, plugin.Release(locked.Mod), dir); err != nil {
+				return err
+			}
+		}
+		if err := p.UploadPlugin(ctx, jar, plugin.Destination); err != nil {
+			return err
+		}
+		changed = true
+	}
+	var unknown []string
+	for _, jar := range installed {
+		if !slices.ContainsFunc(locked.Plugins, func(plugin plugins.LockedPlugin) bool { return plugin.Path() == jar.Path }) {
+			unknown = append(unknown, jar.Path)
+		}
+	}
+	if err := p.RemovePlugins(ctx, unknown); err != nil {
+		return err
+	}
+	changed = changed || len(unknown) > 0
+	if !changed {
+		minectlUI.Success(fmt.Sprintf("The plugins of %s match %s.", name, lockPath))
+		return nil
+	}
+	if restart {
+		_, err = p.RestartServer(ctx, true, true)
+	}
+	return err
+}
+
+// installedPlugins returns the provisioner of the server selected by the
+// flags and the plugins in its plugin folder.
+func installedPlugins(cmd *cobra.Command) (*provisioner.MinectlProvisioner, []provisioner.Plugin, error) {
+	p, err := sshProvisioner(cmd, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	mod, err := plugins.ModTypeOf(p.MinecraftResource().GetEdition())
+	if err != nil {
+		return nil, nil, err
+	}
+	installed, err := p.InstalledPlugins(cmd.Context(), mod.Destination())
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, installed, nil
+}
+
+func runPluginList(cmd *cobra.Command, _ []string) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	p, installed, err := installedPlugins(cmd)
+	if err != nil {
+		return err
+	}
+	list := output.InstalledPluginList{Plugins: make([]output.InstalledPlugin, 0, len(installed))}
+	for _, plugin := range installed {
+		list.Plugins = append(list.Plugins, output.NewInstalledPlugin(plugin.Path, plugin.SHA256, plugin.Metadata))
+	}
+	if format.IsMachineReadable() {
+		return output.Write(cmd.OutOrStdout(), format, list)
+	}
+	if len(list.Plugins) == 0 {
+		minectlUI.Info(fmt.Sprintf("No plugins installed on %s", p.MinecraftResource().GetName()))
+		return nil
+	}
+	fmt.Println("")
+	table := ui.NewTable(minectlUI, "NAME", "VERSION", "LOADER", "FILE")
+	for _, plugin := range list.Plugins {
+		table.Append([]string{plugin.Name, plugin.Version, plugin.Loader, plugin.File})
+	}
+	table.Render()
+	return nil
+}
+
+// matchesPlugin reports whether the plugin has the name or ID, or the jar the file name.
+func matchesPlugin(plugin provisioner.Plugin, name string) bool {
+	file := path.Base(plugin.Path)
+	if strings.EqualFold(file, name) || strings.EqualFold(strings.TrimSuffix(file, ".jar"), name) {
+		return true
+	}
+	return plugin.Metadata != nil && (strings.EqualFold(plugin.Metadata.ID, name) || strings.EqualFold(plugin.Metadata.Name, name))
+}
+
+func runPluginRemove(cmd *cobra.Command, args []string) error {
+	restart, err := cmd.Flags().GetBool("restart")
+	if err != nil {
+		return err
+	}
+	p, installed, err := installedPlugins(cmd)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, plugin := range installed {
+		if matchesPlugin(plugin, args[0]) {
+			paths = append(paths, plugin.Path)
+		}
+	}
+	resource := p.MinecraftResource()
+	if len(paths) == 0 {
+		return errors.Errorf("the plugin %s is not installed on %s, see minectl plugins list", args[0], resource.GetName())
+	}
+	if err := p.RemovePlugins(cmd.Context(), paths); err != nil {
+		return err
+	}
+	if slices.ContainsFunc(p.ManagedPlugins(), func(spec plugins.Spec) bool { return strings.EqualFold(spec.Name, args[0]) }) {
+		minectlUI.Warn(fmt.Sprintf("%s is in the plugins section of the manifest, the next plugins sync installs it again.", args[0]))
+	}
+	if !restart {
+		minectlUI.Info(fmt.Sprintf("The plugin is unloaded with the next restart of Minecraft, or use --restart:\n\n  minectl restart --service-only --name %s", resource.GetName()))
+		return nil
+	}
+	if resource.HasRCON() {
+		server, err := p.GetServer(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if err := p.Say(cmd.Context(), server, "The server restarts to remove "+args[0]); err != nil {
+			minectlUI.Warn("Could not warn the players: " + err.Error())
+		}
+	}
+	_, err = p.RestartServer(cmd.Context(), true, true)
 	return err
 }
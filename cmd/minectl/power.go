@@ -0,0 +1,135 @@
+package minectl
+
+import (
+	"time"
+
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Power actions of the stop, start and restart commands.
+const (
+	powerStop    = "stop"
+	powerStart   = "start"
+	powerRestart = "restart"
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{stopCmd, startCmd, restartCmd} {
+		cmd.Flags().StringP("filename", "f", "", "Location of the manifest file or directory, selects the servers of all manifests")
+		_ = cmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+		cmd.Flags().String("id", "", "Contains the server id")
+		cmd.Flags().String("name", "", "Name of the server in the local state (used when --id is omitted)")
+		cmd.Flags().Bool("service-only", false, "Only stop/start the Minecraft service, the server keeps running")
+		cmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key (required for --service-only)")
+	}
+	for _, cmd := range []*cobra.Command{startCmd, restartCmd} {
+		cmd.Flags().BoolP("wait", "w", true, "Wait for Minecraft Server is started")
+		cmd.Flags().Duration("wait-timeout", provisioner.DefaultWaitTimeout, "Maximum time to wait for Minecraft Server to answer the ping")
+	}
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Power off a Minecraft Server, keeping its disks.",
+	Example: `mincetl stop  \
+    --name minecraft-server
+
+mincetl stop  \
+    --name minecraft-server \
+    --service-only \
+    --ssh-key ~/.ssh/minectl`,
+	RunE:          RunFunc(runPower(powerStop)),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var startCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Power on a stopped Minecraft Server.",
+	Example: `mincetl start  \
+    --name minecraft-server`,
+	RunE:          RunFunc(runPower(powerStart)),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart a Minecraft Server.",
+	Example: `mincetl restart  \
+    --name minecraft-server \
+    --service-only \
+    --ssh-key ~/.ssh/minectl`,
+	RunE:          RunFunc(runPower(powerRestart)),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func runPower(action string) func(cmd *cobra.Command, _ []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		serviceOnly, err := cmd.Flags().GetBool("service-only")
+		if err != nil {
+			return err
+		}
+		sshKey, err := cmd.Flags().GetString("ssh-key")
+		if err != nil {
+			return err
+		}
+		wait := action != powerStop
+		var waitTimeout time.Duration
+		if wait {
+			if wait, err = cmd.Flags().GetBool("wait"); err != nil {
+				return err
+			}
+			if waitTimeout, err = cmd.Flags().GetDuration("wait-timeout"); err != nil {
+				return err
+			}
+		}
+		refs, err := resolveServers(cmd)
+		if err != nil {
+			return err
+		}
+		profile, err := currentProfile()
+		if err != nil {
+			return err
+		}
+		provisioners := make([]*provisioner.MinectlProvisioner, 0, len(refs))
+		for _, ref := range refs {
+			p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+				Document:          ref.Document,
+				ID:                ref.ID,
+				SSHPrivateKeyPath: sshKey,
+				Store:             stateStore(),
+				Profile:           profile,
+				WaitTimeout:       waitTimeout,
+			}, minectlUI)
+			if err != nil {
+				return err
+			}
+			if serviceOnly && p.RequiresSSHKey() && sshKey == "" {
+				return errors.New("Please provide a valid ssh key path via -k|--ssh-key flag to use --service-only")
+			}
+			if !serviceOnly && !p.SupportsPower() {
+				return errors.Errorf("%s can not be powered off and on by its cloud provider, please use --service-only", p.MinecraftResource().GetName())
+			}
+			provisioners = append(provisioners, p)
+		}
+
+		for _, p := range provisioners {
+			switch action {
+			case powerStop:
+				err = p.StopServer(cmd.Context(), serviceOnly)
+			case powerStart:
+				_, err = p.StartServer(cmd.Context(), serviceOnly, wait)
+			case powerRestart:
+				_, err = p.RestartServer(cmd.Context(), serviceOnly, wait)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -2,7 +2,6 @@ package minectl
 
 import (
 	"github.com/dirien/minectl/internal/provisioner"
-	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -10,6 +9,7 @@ func init() {
 	rconCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
 	_ = rconCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
 	rconCmd.Flags().String("id", "", "contains the server id")
+	rconCmd.Flags().String("name", "", "name of the server in the local state (used when --id is omitted)")
 }
 
 var rconCmd = &cobra.Command{
@@ -24,28 +24,23 @@ var rconCmd = &cobra.Command{
 }
 
 func runRCON(cmd *cobra.Command, _ []string) error {
-	filename, err := cmd.Flags().GetString("filename")
-	if filename == "" {
-		return errors.New("Please provide a valid manifest file via -f|--filename flag")
-	}
+	ref, err := resolveServer(cmd)
 	if err != nil {
-		return errors.Wrap(err, "Please provide a valid manifest file")
+		return err
 	}
-	id, err := cmd.Flags().GetString("id")
+	profile, err := currentProfile()
 	if err != nil {
 		return err
 	}
-	if id == "" {
-		return errors.New("Please provide a valid id")
-	}
 	p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
-		ManifestPath: filename,
-		ID:           id,
+		Document: ref.Document,
+		ID:       ref.ID,
+		Profile:  profile,
 	}, minectlUI)
 	if err != nil {
 		return err
 	}
-	err = p.DoRCON()
+	err = p.DoRCON(cmd.Context())
 	if err != nil {
 		return err
 	}
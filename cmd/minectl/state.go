@@ -0,0 +1,193 @@
+package minectl
+
+import (
+	"fmt"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/state"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	statePruneCmd.Flags().BoolP("yes", "y", false, "Automatically remove the stale entries")
+	stateImportCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+	_ = stateImportCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+	stateImportCmd.Flags().String("id", "", "contains the server id")
+	stateImportCmd.Flags().String("name", "", "Name of the manifest, when the file contains several manifests")
+
+	stateCmd.AddCommand(stateListCmd)
+	stateCmd.AddCommand(statePruneCmd)
+	stateCmd.AddCommand(stateImportCmd)
+}
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage the local inventory of your Minecraft Server.",
+	Long: `minectl records every server it creates in ~/.minectl/state.json.
+Commands like delete, update, rcon and plugins use this inventory to look up
+the server id when --id is omitted.`,
+}
+
+var stateListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "List all Minecraft Server in the local inventory.",
+	Example:       `mincetl state list`,
+	RunE:          RunFunc(runStateList),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var statePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove entries of Minecraft Server which do not exist anymore.",
+	Example: `mincetl state prune  \
+    --yes`,
+	RunE:          RunFunc(runStatePrune),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var stateImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Add an existing Minecraft Server to the local inventory.",
+	Example: `mincetl state import  \
+    --filename server-do.yaml \
+    --id xxx-xxx-xxx-xxx`,
+	RunE:          RunFunc(runStateImport),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func runStateList(_ *cobra.Command, _ []string) error {
+	servers, err := stateStore().List()
+	if err != nil {
+		return err
+	}
+	if len(servers) == 0 {
+		minectlUI.Info("No servers found")
+		return nil
+	}
+	fmt.Println("")
+	table := ui.NewTable(minectlUI, "NAME", "ID", "CLOUD", "REGION", "IP", "MANIFEST")
+	for _, server := range servers {
+		table.Append([]string{server.Name, server.ID, server.Cloud, server.Region, server.PublicIP, server.ManifestPath})
+	}
+	table.Render()
+	return nil
+}
+
+func runStatePrune(cmd *cobra.Command, _ []string) error {
+	store := stateStore()
+	servers, err := store.List()
+	if err != nil {
+		return err
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return err
+	}
+
+	// list every cloud/region combination only once
+	existing := map[string]map[string]bool{}
+	var stale []state.Server
+	for _, server := range servers {
+		key := server.Cloud + "/" + server.Region
+		ids, ok := existing[key]
+		if !ok {
+			p, err := provisioner.ListProvisioner(&provisioner.MinectlProvisionerListOpts{
+				Provider: server.Cloud,
+				Region:   server.Region,
+				Profile:  profile,
+			}, minectlUI)
+			if err != nil {
+				return err
+			}
+			results, err := p.ListServer(cmd.Context())
+			if err != nil {
+				return err
+			}
+			ids = serverIDs(results)
+			existing[key] = ids
+		}
+		if !ids[server.ID] {
+			stale = append(stale, server)
+		}
+	}
+
+	if len(stale) == 0 {
+		minectlUI.Info("No stale entries found")
+		return nil
+	}
+	for _, server := range stale {
+		minectlUI.Info(fmt.Sprintf("Stale entry: %s (%s) on %s", server.Name, server.ID, server.Cloud))
+	}
+	if !cmd.Flag("yes").Changed {
+		confirmed, err := ui.Confirm("Do you want to remove the stale entries?")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			minectlUI.Warn("Prune canceled.")
+			return nil
+		}
+	}
+	for _, server := range stale {
+		if err := store.Remove(server.ID, server.Cloud); err != nil {
+			return err
+		}
+	}
+	minectlUI.Success(fmt.Sprintf("Removed %d stale entries.", len(stale)))
+	return nil
+}
+
+func serverIDs(results []automation.ResourceResults) map[string]bool {
+	ids := make(map[string]bool, len(results))
+	for _, result := range results {
+		ids[result.ID] = true
+	}
+	return ids
+}
+
+func runStateImport(cmd *cobra.Command, _ []string) error {
+	filename, err := cmd.Flags().GetString("filename")
+	if err != nil {
+		return errors.Wrap(err, "failed to get 'filename' value")
+	}
+	if filename == "" {
+		return errors.New("Please provide a valid manifest file via -f|--filename flag")
+	}
+	id, err := cmd.Flags().GetString("id")
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return errors.New("Please provide a valid id")
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return err
+	}
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+	p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+		ManifestPath: filename,
+		Name:         name,
+		ID:           id,
+		Store:        stateStore(),
+		Profile:      profile,
+	}, minectlUI)
+	if err != nil {
+		return err
+	}
+	server, err := p.ImportServer(cmd.Context())
+	if err != nil {
+		return err
+	}
+	minectlUI.Success(fmt.Sprintf("Server %s (%s) imported.", server.Name, server.ID))
+	return nil
+}
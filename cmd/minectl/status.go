@@ -0,0 +1,341 @@
+package minectl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl-sdk/common"
+	"github.com/dirien/minectl-sdk/model"
+	"github.com/dirien/minectl/internal/config"
+	"github.com/dirien/minectl/internal/output"
+	"github.com/dirien/minectl/internal/ping"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/state"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	statusCmd.Flags().StringP("provider", "p", "", "Query all servers of the cloud provider instead of the local state - "+strings.Join(provider.Names(), "|"))
+	statusCmd.Flags().StringP("region", "r", "", "The region (gce: zone) for your cloud provider - "+strings.Join(provider.Names(provider.Regions), "|"))
+	statusCmd.Flags().BoolP("watch", "w", false, "Refresh the status until Ctrl+C is pressed")
+	statusCmd.Flags().Duration("interval", 5*time.Second, "Time between two refreshes with --watch")
+	statusCmd.Flags().StringP("output", "o", "", output.FlagUsage)
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status [name]",
+	Short: "Show the live health of Minecraft Servers.",
+	Long: `Status pings the servers like the server list of the game does and shows
+whether they are online, their MOTD, version, players, latency and whether RCON
+is reachable. Without a name, all servers of the local state are shown.`,
+	Example: `mincetl status minecraft-server
+
+mincetl status --watch
+
+mincetl status \
+    --provider civo \
+    --region LON1 \
+    --output json`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          RunFunc(runStatus),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Annotations:   map[string]string{outputAnnotation: ""},
+}
+
+// statusQuery fetches the servers and pings them.
+type statusQuery func(ctx context.Context) ([]*provisioner.ServerStatus, error)
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	providerName, err := cmd.Flags().GetString("provider")
+	if err != nil {
+		return errors.Wrap(err, "failed to get 'provider' value")
+	}
+	region, err := cmd.Flags().GetString("region")
+	if err != nil {
+		return errors.Wrap(err, "failed to get 'region' value")
+	}
+	watch, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		return err
+	}
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		return errors.New("Please provide a positive --interval")
+	}
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return err
+	}
+
+	var query statusQuery
+	if providerName != "" {
+		query, err = providerStatusQuery(providerName, region, name, profile)
+	} else {
+		query, err = stateStatusQuery(name, profile)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	for {
+		statuses, err := query(ctx)
+		if ctx.Err() != nil {
+			if watch {
+				// Ctrl+C and --timeout end watching
+				return nil
+			}
+			return errors.Wrap(ctx.Err(), "failed to query the status")
+		}
+		if err != nil {
+			return err
+		}
+		if watch && !format.IsMachineReadable() {
+			minectlUI.Info(fmt.Sprintf("Status at %s, press Ctrl+C to stop watching", time.Now().Format(time.TimeOnly)))
+		}
+		if err := printStatus(cmd, format, statuses, name != ""); err != nil {
+			return err
+		}
+		if !watch {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// stateStatusQuery queries the servers of the local state, or the one with the name.
+func stateStatusQuery(name string, profile config.Profile) (statusQuery, error) {
+	store := stateStore()
+	servers, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		server, err := findServer(store, name, "")
+		if err != nil {
+			return nil, err
+		}
+		servers = []state.Server{*server}
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("No servers found in the local state, please create one or use --provider")
+	}
+
+	provisioners := make([]*provisioner.MinectlProvisioner, len(servers))
+	loadErrs := make([]error, len(servers))
+	for i, server := range servers {
+		document, err := loadDocument(server.ManifestPath, server.Name)
+		if err == nil {
+			provisioners[i], err = provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+				Document: document,
+				ID:       server.ID,
+				Profile:  profile,
+			}, minectlUI)
+		}
+		if err != nil && name != "" {
+			return nil, err
+		}
+		loadErrs[i] = err
+	}
+	return func(ctx context.Context) ([]*provisioner.ServerStatus, error) {
+		return probeAll(len(servers), func(i int) *provisioner.ServerStatus {
+			server := servers[i]
+			if loadErrs[i] != nil {
+				return stateServerStatus(server, loadErrs[i])
+			}
+			status, err := provisioners[i].Status(ctx)
+			if err != nil {
+				return stateServerStatus(server, err)
+			}
+			return status
+		}), nil
+	}, nil
+}
+
+// stateServerStatus is the status of a server of the local state which could not be queried.
+func stateServerStatus(server state.Server, err error) *provisioner.ServerStatus {
+	resource := &model.MinecraftResource{}
+	resource.Metadata.Name = server.Name
+	resource.Spec.Server.Cloud = server.Cloud
+	resource.Spec.Server.Region = server.Region
+	return &provisioner.ServerStatus{
+		Server: &automation.ResourceResults{
+			ID:       server.ID,
+			Name:     server.Name,
+			Region:   server.Region,
+			PublicIP: server.PublicIP,
+			Tags:     server.Tags,
+		},
+		Resource: resource,
+		Err:      err,
+		RCON:     provisioner.RCONDisabled,
+	}
+}
+
+// providerStatusQuery queries all servers of the cloud provider, or the ones
+// with the name. Servers in the local state are pinged with their manifest.
+func providerStatusQuery(providerName, region, name string, profile config.Profile) (statusQuery, error) {
+	p, err := provisioner.ListProvisioner(&provisioner.MinectlProvisionerListOpts{
+		Provider: providerName,
+		Region:   region,
+		Profile:  profile,
+	}, minectlUI)
+	if err != nil {
+		return nil, err
+	}
+	tracked, err := stateStore().List()
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) ([]*provisioner.ServerStatus, error) {
+		servers, err := p.ListServer(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			servers = filterServers(servers, name)
+			if len(servers) == 0 {
+				return nil, errors.Errorf("no server with the name %s found at %s", name, provider.FullName(providerName))
+			}
+		}
+		return probeAll(len(servers), func(i int) *provisioner.ServerStatus {
+			return p.Probe(ctx, providerResource(servers[i], providerName, tracked), &servers[i])
+		}), nil
+	}, nil
+}
+
+func filterServers(servers []automation.ResourceResults, name string) []automation.ResourceResults {
+	var filtered []automation.ResourceResults
+	for _, server := range servers {
+		if server.Name == name {
+			filtered = append(filtered, server)
+		}
+	}
+	return filtered
+}
+
+// providerResource returns the manifest of a server of the cloud provider. For
+// servers which are not in the local state, the edition is taken from the
+// tags and the default port of the edition is assumed.
+func providerResource(server automation.ResourceResults, cloud string, tracked []state.Server) *model.MinecraftResource {
+	for _, t := range tracked {
+		if t.ID != server.ID || t.Cloud != cloud {
+			continue
+		}
+		if document, err := loadDocument(t.ManifestPath, t.Name); err == nil {
+			return document.Resource
+		}
+	}
+	resource := &model.MinecraftResource{}
+	resource.Metadata.Name = server.Name
+	resource.Spec.Server.Cloud = cloud
+	resource.Spec.Server.Region = server.Region
+	resource.Spec.Minecraft.Edition = "java"
+	for tag := range strings.SplitSeq(server.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" && tag != common.InstanceTag {
+			resource.Spec.Minecraft.Edition = tag
+			break
+		}
+	}
+	resource.Spec.Server.Port = ping.DefaultPort(resource.GetEdition())
+	return resource
+}
+
+// probeAll runs the probes in parallel, so offline servers do not add up their timeouts.
+func probeAll(n int, probe func(i int) *provisioner.ServerStatus) []*provisioner.ServerStatus {
+	statuses := make([]*provisioner.ServerStatus, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			statuses[i] = probe(i)
+		})
+	}
+	wg.Wait()
+	return statuses
+}
+
+func newServerStatus(status *provisioner.ServerStatus) output.ServerStatus {
+	out := output.ServerStatus{
+		Server:  output.NewServer(status.Server, status.Resource.GetCloud()),
+		Edition: status.Resource.GetEdition(),
+		Online:  status.Online(),
+		RCON:    status.RCON,
+	}
+	if out.Name == "" {
+		out.Name = status.Resource.GetName()
+	}
+	if status.Ping != nil {
+		out.MOTD = status.Ping.MOTD
+		out.Version = status.Ping.Version
+		out.Protocol = status.Ping.Protocol
+		out.Players = status.Ping.Players
+		out.MaxPlayers = status.Ping.MaxPlayers
+		out.Sample = status.Ping.Sample
+		out.LatencyMS = status.Ping.Latency.Milliseconds()
+	}
+	if status.Err != nil {
+		out.Error = status.Err.Error()
+	}
+	return out
+}
+
+func printStatus(cmd *cobra.Command, format output.Format, statuses []*provisioner.ServerStatus, single bool) error {
+	list := output.ServerStatusList{Servers: make([]output.ServerStatus, 0, len(statuses))}
+	for _, status := range statuses {
+		list.Servers = append(list.Servers, newServerStatus(status))
+	}
+	if format.IsMachineReadable() {
+		if single && len(list.Servers) == 1 {
+			return output.Write(cmd.OutOrStdout(), format, list.Servers[0])
+		}
+		return output.Write(cmd.OutOrStdout(), format, list)
+	}
+	if len(list.Servers) == 0 {
+		minectlUI.Info("No servers found")
+		return nil
+	}
+	fmt.Println("")
+	table := ui.NewTable(minectlUI, "NAME", "CLOUD", "IP", "STATUS", "VERSION", "PLAYERS", "LATENCY", "RCON", "MOTD")
+	for _, server := range list.Servers {
+		if !server.Online {
+			table.Append([]string{server.Name, server.Cloud, server.PublicIP, "offline", "", "", "", server.RCON, ""})
+			continue
+		}
+		players := fmt.Sprintf("%d/%d", server.Players, server.MaxPlayers)
+		if len(server.Sample) > 0 {
+			players += " (" + strings.Join(server.Sample, ", ") + ")"
+		}
+		table.Append([]string{server.Name, server.Cloud, server.PublicIP, common.Green("online"), server.Version, players,
+			fmt.Sprintf("%dms", server.LatencyMS), server.RCON, server.MOTD})
+	}
+	table.Render()
+	for _, server := range list.Servers {
+		if server.Error != "" {
+			minectlUI.Warn(fmt.Sprintf("%s: %s", server.Name, server.Error))
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package minectl
 
 import (
+	"github.com/dirien/minectl/internal/backup"
 	"github.com/spf13/cobra"
 )
 
@@ -9,6 +10,8 @@ func init() {
 	updateCmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key")
 	_ = updateCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
 	updateCmd.Flags().String("id", "", "contains the server id")
+	updateCmd.Flags().String("name", "", "name of the server in the local state (used when --id is omitted)")
+	addBackupFlag(updateCmd, "updated")
 }
 
 var updateCmd = &cobra.Command{
@@ -23,13 +26,25 @@ var updateCmd = &cobra.Command{
 }
 
 func runUpdate(cmd *cobra.Command, _ []string) error {
-	p, err := createUpdatePluginProvisioner(cmd)
+	p, document, err := createUpdatePluginProvisioner(cmd)
 	if err != nil {
 		return err
 	}
-	err = p.UpdateServer()
+	backend, retention, err := backupBefore(cmd)
 	if err != nil {
 		return err
 	}
-	return err
+	if backend != nil {
+		if err := snapshot(cmd.Context(), p, backend, retention, backup.ReasonPreUpdate); err != nil {
+			return err
+		}
+	}
+	err = p.UpdateServer(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if document.Plugins != nil {
+		return syncPlugins(cmd.Context(), p, document, false, true)
+	}
+	return nil
 }
@@ -0,0 +1,142 @@
+package minectl
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/dirien/minectl/internal/lint"
+	"github.com/dirien/minectl/internal/output"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	validateCmd.Flags().StringArrayP("filename", "f", nil, "Location of the manifest file or directory, can be repeated and contain globs")
+	_ = validateCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+	validateCmd.Flags().Bool("strict", false, "Fail on warnings too")
+	validateCmd.Flags().StringP("output", "o", "", output.FlagUsage)
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [manifest...]",
+	Short: "Validate manifests without touching any cloud provider.",
+	Long: `Validate checks the manifests against their schema and runs semantic checks:
+the Java version required by the Minecraft version, the heap settings and the
+memory of the instance size, RCON without a password, port collisions, spot or
+ARM instances on providers which do not support them and unknown keys in the
+server properties. It exits with a non-zero code when an error was found.`,
+	Example: `mincetl validate  \
+    --filename "config/*/*.yaml" \
+    --strict`,
+	RunE:          RunFunc(runValidate),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Annotations:   map[string]string{outputAnnotation: ""},
+}
+
+// validateReport is the machine-readable output of validate.
+type validateReport struct {
+	Files    []string       `json:"files"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Findings []lint.Finding `json:"findings"`
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	patterns, err := cmd.Flags().GetStringArray("filename")
+	if err != nil {
+		return errors.Wrap(err, "failed to get 'filename' value")
+	}
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		return err
+	}
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	files, err := expandFiles(append(patterns, args...))
+	if err != nil {
+		return err
+	}
+
+	report := validateReport{Files: files, Findings: []lint.Finding{}}
+	for _, file := range files {
+		findings, err := lint.Path(file)
+		if err != nil {
+			return err
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	lint.Sort(report.Findings)
+	report.Errors, report.Warnings = lint.Count(report.Findings)
+
+	if format.IsMachineReadable() {
+		if err := output.Write(cmd.OutOrStdout(), format, report); err != nil {
+			return err
+		}
+	} else {
+		renderFindings(report.Findings)
+	}
+
+	summary := fmt.Sprintf("Found %d error(s) and %d warning(s) in %d file(s).", report.Errors, report.Warnings, len(files))
+	if report.Errors > 0 || (strict && report.Warnings > 0) {
+		return errors.New(summary)
+	}
+	minectlUI.Success(summary)
+	return nil
+}
+
+// expandFiles resolves the globs of the patterns. Patterns without glob
+// characters are kept as they are, so missing files are reported.
+func expandFiles(patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return nil, errors.New("Please provide at least one manifest file via -f|--filename flag or as argument")
+	}
+	var files []string
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %s", pattern)
+		}
+		if len(matches) == 0 {
+			matches = []string{pattern}
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+	return files, nil
+}
+
+func renderFindings(findings []lint.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	if headless {
+		for _, finding := range findings {
+			if finding.Severity == lint.SeverityError {
+				minectlUI.ErrorMsg(errors.New(finding.String()))
+			} else {
+				minectlUI.Warn(finding.String())
+			}
+		}
+		return
+	}
+	fmt.Fprintln(minectlUI.Writer())
+	table := ui.NewTable(minectlUI, "FILE", "LINE", "SEVERITY", "RULE", "MESSAGE")
+	for _, finding := range findings {
+		line := ""
+		if finding.Line > 0 {
+			line = strconv.Itoa(finding.Line)
+		}
+		table.Append([]string{finding.File, line, string(finding.Severity), finding.Rule, finding.Message})
+	}
+	table.Render()
+}
@@ -0,0 +1,199 @@
+package minectl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/internal/manifest"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/watchdog"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	watchdogCmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+	_ = watchdogCmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+	watchdogCmd.Flags().String("id", "", "Contains the server id")
+	watchdogCmd.Flags().String("name", "", "Name of the server in the local state (used when --id is omitted)")
+	watchdogCmd.Flags().Duration("idle", 0, "Shut the server down after this time without players (default: spec.autoShutdown.idleMinutes)")
+	watchdogCmd.Flags().Duration("warning", 0, "Warn the players via RCON this time before the shutdown, 0s disables the warning (default: spec.autoShutdown.warningMinutes)")
+	watchdogCmd.Flags().String("action", "", "Action once the server is idle: stop|delete (default: spec.autoShutdown.action or stop)")
+	watchdogCmd.Flags().Duration("interval", time.Minute, "Time between two checks of the players")
+	watchdogCmd.Flags().Bool("rcon", false, "Count the players via the RCON list command instead of the Server List Ping")
+	watchdogCmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key (required to back up the server before a delete)")
+	addBackupFlag(watchdogCmd, "deleted by --action delete")
+}
+
+var watchdogCmd = &cobra.Command{
+	Use:   "watchdog",
+	Short: "Shut a Minecraft Server down once no players are online.",
+	Long: `Watchdog checks the players of the server every --interval and stops or
+deletes it once no players were online for the idle time of spec.autoShutdown
+or --idle. The players are warned via RCON say before. It runs until the
+server is deleted or Ctrl+C is pressed; a stopped server is watched again once
+it is started.`,
+	Example: `mincetl watchdog  \
+    --name minecraft-server
+
+mincetl watchdog  \
+    --filename server-do.yaml \
+    --idle 30m \
+    --action delete`,
+	RunE:          RunFunc(runWatchdog),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func runWatchdog(cmd *cobra.Command, _ []string) error {
+	ref, err := resolveServer(cmd)
+	if err != nil {
+		return err
+	}
+	config, err := watchdogConfig(cmd, ref.Document)
+	if err != nil {
+		return err
+	}
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+	viaRCON, err := cmd.Flags().GetBool("rcon")
+	if err != nil {
+		return err
+	}
+	sshKey, err := cmd.Flags().GetString("ssh-key")
+	if err != nil {
+		return err
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return err
+	}
+	p, err := provisioner.NewProvisioner(&provisioner.MinectlProvisionerOpts{
+		Document:          ref.Document,
+		ID:                ref.ID,
+		SSHPrivateKeyPath: sshKey,
+		Store:             stateStore(),
+		Profile:           profile,
+	}, minectlUI)
+	if err != nil {
+		return err
+	}
+	action := config.action
+	if action == manifest.ShutdownStop && !p.SupportsPower() {
+		return errors.Errorf("%s can not be powered off by its cloud provider, please use --action delete", p.MinecraftResource().GetName())
+	}
+	var backend backup.Backend
+	var retention backup.Retention
+	if action == manifest.ShutdownDelete {
+		if backend, retention, err = backupBefore(cmd); err != nil {
+			return err
+		}
+		if backend != nil && p.RequiresSSHKey() && sshKey == "" {
+			return errors.New("Please provide a valid ssh key path via -k|--ssh-key flag to back up the server before the delete, or skip the backup with --backup=false")
+		}
+	}
+	warning := config.warning
+	if warning > 0 && !p.MinecraftResource().HasRCON() {
+		minectlUI.Warn("RCON is not enabled in the manifest, the players are not warned before the shutdown.")
+		warning = 0
+	}
+
+	ctx := cmd.Context()
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return err
+	}
+	w := &watchdog.Watchdog{
+		Idle:     config.idle,
+		Warning:  warning,
+		Interval: interval,
+		Players: func(ctx context.Context) (int, error) {
+			players, err := p.Players(ctx, server, viaRCON)
+			if err != nil {
+				// the IP changes on some cloud providers when the server is started again
+				if refreshed, getErr := p.GetServer(ctx); getErr == nil {
+					server = refreshed
+				}
+			}
+			return players, err
+		},
+		Warn: func(ctx context.Context, message string) error {
+			return p.Say(ctx, server, message)
+		},
+		Shutdown: func(ctx context.Context) (watchdog.Done, error) {
+			if action == manifest.ShutdownDelete {
+				// a failed backup stops the watchdog, the server is not deleted
+				if backend != nil {
+					if err := snapshot(ctx, p, backend, retention, backup.ReasonPreDelete); err != nil {
+						return false, err
+					}
+				}
+				return true, p.DeleteServer(ctx)
+			}
+			return false, p.StopServer(ctx, false)
+		},
+		Log: func(message string) {
+			minectlUI.Info(fmt.Sprintf("%s %s: %s", time.Now().Format(time.TimeOnly), p.MinecraftResource().GetName(), message))
+		},
+	}
+	minectlUI.Info(fmt.Sprintf("Watching %s, it is %s after %s without players. Press Ctrl+C to stop watching.",
+		p.MinecraftResource().GetName(), map[string]string{manifest.ShutdownStop: "stopped", manifest.ShutdownDelete: "deleted"}[action], w.Idle))
+	return w.Run(ctx)
+}
+
+// shutdownConfig is the auto-shutdown of the manifest, overridden by the flags.
+type shutdownConfig struct {
+	idle    time.Duration
+	warning time.Duration
+	action  string
+}
+
+func watchdogConfig(cmd *cobra.Command, document *manifest.Document) (*shutdownConfig, error) {
+	config := &shutdownConfig{action: manifest.ShutdownStop}
+	// without an explicit warning, the default depends on the idle time
+	explicitWarning := false
+	if autoShutdown := document.AutoShutdown; autoShutdown != nil {
+		config.idle, config.action = autoShutdown.Idle(), autoShutdown.ShutdownAction()
+		if autoShutdown.WarningMinutes != nil {
+			config.warning, explicitWarning = autoShutdown.Warning(), true
+		}
+	}
+	if cmd.Flags().Changed("idle") {
+		idle, err := cmd.Flags().GetDuration("idle")
+		if err != nil {
+			return nil, err
+		}
+		config.idle = idle
+	}
+	if config.idle <= 0 {
+		return nil, errors.Errorf("The manifest %s has no spec.autoShutdown, please provide the idle time via --idle", document.Resource.GetName())
+	}
+	if cmd.Flags().Changed("warning") {
+		warning, err := cmd.Flags().GetDuration("warning")
+		if err != nil {
+			return nil, err
+		}
+		config.warning, explicitWarning = warning, true
+	}
+	if !explicitWarning {
+		config.warning = manifest.DefaultWarning(config.idle)
+	}
+	if cmd.Flags().Changed("action") {
+		action, err := cmd.Flags().GetString("action")
+		if err != nil {
+			return nil, err
+		}
+		if action != manifest.ShutdownStop && action != manifest.ShutdownDelete {
+			return nil, errors.Errorf("unknown action %q, please use stop or delete", action)
+		}
+		config.action = action
+	}
+	if config.warning >= config.idle {
+		return nil, errors.Errorf("The warning (%s) must be less than the idle time (%s)", config.warning, config.idle)
+	}
+	return config, nil
+}
@@ -9,10 +9,12 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/huh"
+	"github.com/dirien/minectl-sdk/cloud"
 	"github.com/dirien/minectl-sdk/common"
 	"github.com/dirien/minectl-sdk/model"
 	"github.com/dirien/minectl-sdk/template"
 	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/pkg/provider"
 	"github.com/spf13/cobra"
 )
 
@@ -30,23 +32,17 @@ func init() {
 	_ = wizardCmd.Flags().SetAnnotation("output", cobra.BashCompSubdirsInDir, []string{})
 }
 
-// Provider options for the wizard
-var providerOptions = []huh.Option[string]{
-	huh.NewOption("DigitalOcean", "DigitalOcean"),
-	huh.NewOption("Civo", "Civo"),
-	huh.NewOption("Scaleway", "Scaleway"),
-	huh.NewOption("Hetzner", "Hetzner"),
-	huh.NewOption("Akamai Connected Cloud", "Akamai Connected Cloud"),
-	huh.NewOption("OVHcloud", "OVHcloud"),
-	huh.NewOption("Google Compute Engine", "Google Compute Engine"),
-	huh.NewOption("vultr", "vultr"),
-	huh.NewOption("Azure", "Azure"),
-	huh.NewOption("Oracle Cloud Infrastructure", "Oracle Cloud Infrastructure"),
-	huh.NewOption("Amazon Web Services", "Amazon Web Services"),
-	huh.NewOption("VEXXHOST", "VEXXHOST"),
-	huh.NewOption("Ubuntu Multipass", "Ubuntu Multipass"),
-	huh.NewOption("Exoscale", "Exoscale"),
-	huh.NewOption("Fuga Cloud", "Fuga Cloud"),
+// providerOptions returns the wizard options for all registered providers the
+// SDK config template knows about.
+func providerOptions() []huh.Option[string] {
+	var options []huh.Option[string]
+	for _, p := range provider.All() {
+		if cloud.GetCloudProviderCode(p.FullName) == "" {
+			continue
+		}
+		options = append(options, huh.NewOption(p.FullName, p.FullName))
+	}
+	return options
 }
 
 var javaVersionOptions = []huh.Option[string]{
@@ -141,7 +137,7 @@ func runWizard(cmd *cobra.Command, _ []string) error {
 		huh.NewSelect[string]().
 			Title("Cloud Provider").
 			Description("Choose a cloud provider").
-			Options(providerOptions...).
+			Options(providerOptions()...).
 			Value(&wizard.Provider),
 		huh.NewInput().
 			Title("Plan/Size").
@@ -0,0 +1,119 @@
+package minectl
+
+import (
+	"fmt"
+
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/internal/provisioner"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{worldPullCmd, worldPushCmd} {
+		cmd.Flags().StringP("filename", "f", "", "Location of the manifest file")
+		_ = cmd.Flags().SetAnnotation("filename", cobra.BashCompFilenameExt, []string{"yaml"})
+		cmd.Flags().String("id", "", "Contains the server id")
+		cmd.Flags().String("name", "", "Name of the server in the local state (used when --id is omitted)")
+		cmd.Flags().StringP("ssh-key", "k", "", "specify a specific path for the SSH key")
+	}
+	worldPullCmd.Flags().StringP("dir", "d", ".", "Directory to pull the worlds into")
+	worldPushCmd.Flags().Bool("force", false, "Push the world even when it was saved by a newer Minecraft version than the server runs")
+	worldPushCmd.Flags().BoolP("yes", "y", false, "Automatically replace the world of the server")
+	worldPushCmd.Flags().BoolP("wait", "w", true, "Wait for Minecraft Server is started")
+	worldPushCmd.Flags().Duration("wait-timeout", provisioner.DefaultWaitTimeout, "Maximum time to wait for Minecraft Server to answer the ping")
+	addBackupFlag(worldPushCmd, "changed by the push")
+
+	worldCmd.AddCommand(worldPullCmd)
+	worldCmd.AddCommand(worldPushCmd)
+}
+
+var worldCmd = &cobra.Command{
+	Use:   "world",
+	Short: "Copy worlds between your computer and your Minecraft Server.",
+	Long: `The worlds are copied as tar stream over SSH. Saving is turned off via RCON
+while the worlds are pulled, so the copy is consistent.`,
+}
+
+var worldPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Copy the worlds of a Minecraft Server to your computer.",
+	Example: `mincetl world pull  \
+    --name minecraft-server \
+    --ssh-key ~/.ssh/minectl \
+    --dir ./minecraft-server`,
+	RunE:          RunFunc(runWorldPull),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var worldPushCmd = &cobra.Command{
+	Use:   "push <dir>",
+	Short: "Replace the world of a Minecraft Server with a world of your computer.",
+	Long: `The world in <dir> replaces the world of level-name in server.properties. Its
+level.dat is validated before Minecraft is stopped, and worlds saved by a
+newer Minecraft version than the server runs are rejected unless --force is set.`,
+	Example: `mincetl world push ./world \
+    --name minecraft-server \
+    --ssh-key ~/.ssh/minectl`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          RunFunc(runWorldPush),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func runWorldPull(cmd *cobra.Command, _ []string) error {
+	dir, err := cmd.Flags().GetString("dir")
+	if err != nil {
+		return err
+	}
+	p, err := sshProvisioner(cmd, 0)
+	if err != nil {
+		return err
+	}
+	_, err = p.PullWorld(cmd.Context(), dir)
+	return err
+}
+
+func runWorldPush(cmd *cobra.Command, args []string) error {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	wait, err := cmd.Flags().GetBool("wait")
+	if err != nil {
+		return err
+	}
+	waitTimeout, err := cmd.Flags().GetDuration("wait-timeout")
+	if err != nil {
+		return err
+	}
+	p, err := sshProvisioner(cmd, waitTimeout)
+	if err != nil {
+		return err
+	}
+	// the world is validated before the backup, so a broken world changes nothing
+	if _, err := p.ValidateWorld(args[0], force); err != nil {
+		return err
+	}
+	backend, retention, err := backupBefore(cmd)
+	if err != nil {
+		return err
+	}
+	if !cmd.Flag("yes").Changed {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Do you want to replace the world of %s with %s?", p.MinecraftResource().GetName(), args[0]))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			minectlUI.Warn("Push canceled.")
+			return nil
+		}
+	}
+	if backend != nil {
+		if err := snapshot(cmd.Context(), p, backend, retention, backup.ReasonPrePush); err != nil {
+			return err
+		}
+	}
+	return p.PushWorld(cmd.Context(), args[0], force, wait)
+}
@@ -0,0 +1,331 @@
+// Package backup archives the worlds of Minecraft servers as tar+zstd and
+// keeps them in a storage backend, a local directory or an S3 bucket. Every
+// archive has a JSON metadata object next to it holding its SHA-256 checksum.
+package backup
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+const (
+	archiveExt  = ".tar.zst"
+	metadataExt = ".json"
+	// idLayout has milliseconds, so a backup right after another one, e.g.
+	// before a delete, gets its own ID
+	idLayout = "20060102T150405.000Z"
+)
+
+// Latest selects the newest backup of a server.
+const Latest = "latest"
+
+// Reasons a backup was taken for.
+const (
+	ReasonManual    = "manual"
+	ReasonPreDelete = "pre-delete"
+	ReasonPreUpdate = "pre-update"
+	ReasonPrePush   = "pre-push"
+	ReasonMigrate   = "migrate"
+)
+
+// Backend stores the backups. Keys are slash separated paths.
+type Backend interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the keys starting with the prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, key string) error
+	// String describes the location of the backups, e.g. s3://bucket/prefix.
+	String() string
+}
+
+// Info is the metadata of a backup.
+type Info struct {
+	ID      string `json:"id"`
+	Server  string `json:"server"`
+	Edition string `json:"edition,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Worlds are the directories in the archive, relative to the Minecraft directory.
+	Worlds  []string  `json:"worlds"`
+	Created time.Time `json:"created"`
+	// Size is the size of the compressed archive in bytes.
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Reason is manual or the operation the backup was taken before.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Archive returns the key of the archive. The keys only contain the name of
+// the server, not its cloud, so a server keeps its backups when it is
+// migrated to another cloud.
+func (i *Info) Archive() string {
+	return i.Server + "/" + i.ID + archiveExt
+}
+
+func (i *Info) metadata() string {
+	return i.Server + "/" + i.ID + metadataExt
+}
+
+// Create compresses the tar stream of the worlds and stores it in the
+// backend. The ID and the checksum of the returned info are set.
+func Create(ctx context.Context, backend Backend, info Info, worlds io.Reader) (*Info, error) {
+	if info.Server == "" || strings.Contains(info.Server, "/") {
+		return nil, errors.Errorf("invalid server name %q", info.Server)
+	}
+	if info.Created.IsZero() {
+		info.Created = time.Now()
+	}
+	info.Created = info.Created.UTC().Truncate(time.Millisecond)
+	info.ID = info.Created.Format(idLayout)
+	// backups are never overwritten
+	existing, err := backend.List(ctx, info.Server+"/"+info.ID+".")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the backups in %s", backend)
+	}
+	if len(existing) > 0 {
+		return nil, errors.Errorf("the backup %s of %s already exists in %s", info.ID, info.Server, backend)
+	}
+
+	file, err := os.CreateTemp("", "minectl-backup-*"+archiveExt)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	hash := sha256.New()
+	enc, err := zstd.NewWriter(io.MultiWriter(file, hash))
+	if err != nil {
+		return nil, err
+	}
+	info.Worlds, err = copyTar(enc, worlds)
+	if err != nil {
+		enc.Close()
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	if len(info.Worlds) == 0 {
+		return nil, errors.New("the server has no worlds to back up")
+	}
+	info.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	if info.Size, err = file.Seek(0, io.SeekCurrent); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if err := backend.Put(ctx, info.Archive(), file, info.Size); err != nil {
+		return nil, errors.Wrapf(err, "failed to store the backup in %s", backend)
+	}
+	// the metadata is written last, so only complete backups are listed
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Put(ctx, info.metadata(), strings.NewReader(string(data)), int64(len(data))); err != nil {
+		return nil, errors.Wrapf(err, "failed to store the backup in %s", backend)
+	}
+	return &info, nil
+}
+
+// copyTar copies the tar stream to w and returns the top-level directories of
+// its entries.
+func copyTar(w io.Writer, r io.Reader) ([]string, error) {
+	tee := io.TeeReader(r, w)
+	tr := tar.NewReader(tee)
+	var dirs []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the worlds")
+		}
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == "." || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, errors.Errorf("invalid entry %q in the worlds", header.Name)
+		}
+		dir, _, _ := strings.Cut(name, "/")
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	// copy the end of the archive after the last entry
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return nil, errors.Wrap(err, "failed to read the worlds")
+	}
+	slices.Sort(dirs)
+	return dirs, nil
+}
+
+// List returns the backups of the server, or of all servers when server is
+// empty, newest first.
+func List(ctx context.Context, backend Backend, server string) ([]Info, error) {
+	prefix := ""
+	if server != "" {
+		prefix = server + "/"
+	}
+	keys, err := backend.List(ctx, prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the backups in %s", backend)
+	}
+	backups := []Info{}
+	for _, key := range keys {
+		if !strings.HasSuffix(key, metadataExt) {
+			continue
+		}
+		info, err := readInfo(ctx, backend, key)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, *info)
+	}
+	slices.SortFunc(backups, func(a, b Info) int {
+		if c := b.Created.Compare(a.Created); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Server, b.Server)
+	})
+	return backups, nil
+}
+
+func readInfo(ctx context.Context, backend Backend, key string) (*Info, error) {
+	r, err := backend.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var info Info
+	if err := json.NewDecoder(r).Decode(&info); err != nil {
+		return nil, errors.Wrapf(err, "invalid backup metadata %s", key)
+	}
+	return &info, nil
+}
+
+// Find returns the backup of the server with the ID, or the newest backup for Latest.
+func Find(ctx context.Context, backend Backend, server, id string) (*Info, error) {
+	backups, err := List(ctx, backend, server)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, errors.Errorf("no backups of %s found in %s", server, backend)
+	}
+	if id == "" || id == Latest {
+		return &backups[0], nil
+	}
+	for i := range backups {
+		if backups[i].ID == id {
+			return &backups[i], nil
+		}
+	}
+	return nil, errors.Errorf("backup %s of %s not found in %s", id, server, backend)
+}
+
+// Open downloads the backup, verifies its checksum and returns the tar
+// stream of the worlds.
+func Open(ctx context.Context, backend Backend, info *Info) (io.ReadCloser, error) {
+	r, err := backend.Get(ctx, info.Archive())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download the backup %s", info.ID)
+	}
+	defer r.Close()
+	file, err := os.CreateTemp("", "minectl-restore-*"+archiveExt)
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), r)
+	if err != nil {
+		cleanup()
+		return nil, errors.Wrapf(err, "failed to download the backup %s", info.ID)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != info.SHA256 || size != info.Size {
+		cleanup()
+		return nil, errors.Errorf("the backup %s is corrupted: got %d bytes with checksum %s, want %d bytes with checksum %s", info.ID, size, sum, info.Size, info.SHA256)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, err
+	}
+	dec, err := zstd.NewReader(file)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	return &archiveReader{Decoder: dec, cleanup: cleanup}, nil
+}
+
+type archiveReader struct {
+	*zstd.Decoder
+	cleanup func()
+}
+
+func (r *archiveReader) Close() error {
+	r.Decoder.Close()
+	r.cleanup()
+	return nil
+}
+
+// Retention limits the number of backups kept per server. The newest backup
+// is always kept.
+type Retention struct {
+	// KeepLast is the number of backups to keep, 0 keeps all.
+	KeepLast int `yaml:"keepLast,omitempty"`
+	// MaxAgeDays deletes backups older than this, 0 keeps all.
+	MaxAgeDays int `yaml:"maxAgeDays,omitempty"`
+}
+
+// expired reports whether the i-th newest backup is deleted by the retention.
+func (r Retention) expired(i int, info *Info, now time.Time) bool {
+	if i == 0 {
+		return false
+	}
+	if r.KeepLast > 0 && i >= r.KeepLast {
+		return true
+	}
+	return r.MaxAgeDays > 0 && now.Sub(info.Created) > time.Duration(r.MaxAgeDays)*24*time.Hour
+}
+
+// Prune deletes the backups of the server expired by the retention and returns them.
+func Prune(ctx context.Context, backend Backend, server string, retention Retention, now time.Time) ([]Info, error) {
+	backups, err := List(ctx, backend, server)
+	if err != nil {
+		return nil, err
+	}
+	var deleted []Info
+	for i := range backups {
+		info := &backups[i]
+		if !retention.expired(i, info, now) {
+			continue
+		}
+		// without its metadata, a backup is no longer listed
+		for _, key := range []string{info.metadata(), info.Archive()} {
+			if err := backend.Delete(ctx, key); err != nil {
+				return deleted, errors.Wrapf(err, "failed to delete the backup %s", info.ID)
+			}
+		}
+		deleted = append(deleted, *info)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,204 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// worlds returns a tar stream of the files.
+func worlds(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for _, name := range []string{"world/level.dat", "world/region/r.0.0.mca", "world_nether/level.dat", "../evil", "/etc/passwd"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+var testWorlds = map[string]string{
+	"world/level.dat":        "level",
+	"world/region/r.0.0.mca": "region",
+	"world_nether/level.dat": "nether",
+}
+
+// testBackend creates, lists, opens and prunes backups in the backend.
+func testBackend(t *testing.T, backend Backend) {
+	t.Helper()
+	ctx := t.Context()
+	archive := worlds(t, testWorlds)
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var ids []string
+	for i := range 3 {
+		info, err := Create(ctx, backend, Info{Server: "test", Version: "1.21.4", Created: created.Add(time.Duration(i) * time.Hour)}, bytes.NewReader(archive))
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		ids = append(ids, info.ID)
+	}
+	if _, err := Create(ctx, backend, Info{Server: "other", Created: created}, bytes.NewReader(archive)); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// a backup in the same second gets its own ID, the same millisecond is refused
+	if info, err := Create(ctx, backend, Info{Server: "other", Created: created.Add(500 * time.Millisecond)}, bytes.NewReader(archive)); err != nil || info.ID != "20240101T120000.500Z" {
+		t.Fatalf("Create() in the same second = %v, %v", info, err)
+	}
+	if _, err := Create(ctx, backend, Info{Server: "other", Created: created}, bytes.NewReader(archive)); err == nil {
+		t.Fatal("Create() overwrote an existing backup")
+	}
+
+	backups, err := List(ctx, backend, "test")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(backups) != 3 || backups[0].ID != ids[2] {
+		t.Fatalf("List() = %+v, want the 3 backups of test, newest first", backups)
+	}
+	if got := backups[0].Worlds; len(got) != 2 || got[0] != "world" || got[1] != "world_nether" {
+		t.Errorf("Worlds = %v, want [world world_nether]", got)
+	}
+	all, err := List(ctx, backend, "")
+	if err != nil || len(all) != 5 {
+		t.Fatalf("List() of all servers = %d backups, %v, want 5", len(all), err)
+	}
+
+	latest, err := Find(ctx, backend, "test", Latest)
+	if err != nil || latest.ID != ids[2] {
+		t.Fatalf("Find(latest) = %v, %v, want %s", latest, err, ids[2])
+	}
+	if _, err := Find(ctx, backend, "test", "20000101T000000Z"); err == nil {
+		t.Error("Find() of an unknown backup should fail")
+	}
+	r, err := Open(ctx, backend, latest)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	restored, err := io.ReadAll(r)
+	_ = r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(restored, archive) {
+		t.Error("the restored worlds differ from the backup")
+	}
+
+	deleted, err := Prune(ctx, backend, "test", Retention{KeepLast: 2}, created)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != ids[0] {
+		t.Errorf("Prune() deleted %+v, want %s", deleted, ids[0])
+	}
+	keys, err := backend.List(ctx, "test/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 4 {
+		t.Errorf("got keys %v after pruning, want the archive and metadata of 2 backups", keys)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	backend, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBackend(t, backend)
+}
+
+func TestOpenCorrupted(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := Create(t.Context(), backend, Info{Server: "test"}, bytes.NewReader(worlds(t, testWorlds)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "test", info.ID+archiveExt)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(t.Context(), backend, info); err == nil {
+		t.Error("Open() of a corrupted backup should fail")
+	}
+}
+
+func TestCreateInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  string
+		archive []byte
+	}{
+		{"no worlds", "test", worlds(t, nil)},
+		{"not a tar archive", "test", []byte("tar: Cowardly refusing to create an empty archive")},
+		{"parent directory", "test", worlds(t, map[string]string{"../evil": "x"})},
+		{"absolute path", "test", worlds(t, map[string]string{"/etc/passwd": "x"})},
+		{"invalid server name", "a/b", worlds(t, testWorlds)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend, err := NewLocal(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Create(context.Background(), backend, Info{Server: tt.server}, bytes.NewReader(tt.archive)); err == nil {
+				t.Fatal("Create() should fail")
+			}
+			if keys, _ := backend.List(t.Context(), ""); len(keys) != 0 {
+				t.Errorf("Create() left %v behind", keys)
+			}
+		})
+	}
+}
+
+func TestRetention(t *testing.T) {
+	now := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tests := []struct {
+		name      string
+		retention Retention
+		i         int
+		age       time.Duration
+		want      bool
+	}{
+		{"keep all", Retention{}, 10, 365 * day, false},
+		{"newest is always kept", Retention{KeepLast: 1, MaxAgeDays: 1}, 0, 365 * day, false},
+		{"within keepLast", Retention{KeepLast: 3}, 2, day, false},
+		{"beyond keepLast", Retention{KeepLast: 3}, 3, day, true},
+		{"younger than maxAgeDays", Retention{MaxAgeDays: 7}, 5, 6 * day, false},
+		{"older than maxAgeDays", Retention{MaxAgeDays: 7}, 1, 8 * day, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &Info{Created: now.Add(-tt.age)}
+			if got := tt.retention.expired(tt.i, info, now); got != tt.want {
+				t.Errorf("expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package backup
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Config is the backup section of the configuration file. Without a
+// backend, backups are stored in a local default directory.
+type Config struct {
+	Local     *LocalConfig `yaml:"local,omitempty"`
+	S3        *S3Config    `yaml:"s3,omitempty"`
+	Retention Retention    `yaml:"retention,omitempty"`
+}
+
+// LocalConfig configures a directory for the backups.
+type LocalConfig struct {
+	Path string `yaml:"path"`
+}
+
+// HasBackend returns true when a backend is configured, which enables the
+// backups before delete and update by default.
+func (c *Config) HasBackend() bool {
+	return c != nil && (c.Local != nil || c.S3 != nil)
+}
+
+// NewBackend returns the backend of the configuration, or a local backend in
+// defaultDir when none is configured.
+func NewBackend(ctx context.Context, cfg *Config, defaultDir string) (Backend, error) {
+	switch {
+	case !cfg.HasBackend():
+		return NewLocal(defaultDir)
+	case cfg.Local != nil && cfg.S3 != nil:
+		return nil, errors.New("please configure either a local or an S3 backup backend, not both")
+	case cfg.S3 != nil:
+		return NewS3(ctx, cfg.S3)
+	case cfg.Local.Path == "":
+		return nil, errors.New("the local backup backend requires a path")
+	default:
+		return NewLocal(cfg.Local.Path)
+	}
+}
@@ -0,0 +1,133 @@
+package backup
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 implements the object operations of the S3 API used by the backend
+// with path-style addressing.
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+}
+
+type listBucketResult struct {
+	XMLName  xml.Name `xml:"ListBucketResult"`
+	Name     string   `xml:"Name"`
+	Prefix   string   `xml:"Prefix"`
+	KeyCount int      `xml:"KeyCount"`
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int    `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated bool `xml:"IsTruncated"`
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		prefix := r.URL.Query().Get("prefix")
+		result := listBucketResult{Name: bucket, Prefix: prefix}
+		keys := slices.Sorted(func(yield func(string) bool) {
+			for k := range s.objects {
+				if strings.HasPrefix(k, prefix) && !yield(k) {
+					return
+				}
+			}
+		})
+		for _, k := range keys {
+			result.Contents = append(result.Contents, struct {
+				Key  string `xml:"Key"`
+				Size int    `xml:"Size"`
+			}{k, len(s.objects[k])})
+		}
+		result.KeyCount = len(keys)
+		w.Header().Set("Content-Type", "application/xml")
+		_ = xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = data
+	case r.Method == http.MethodGet:
+		data, ok := s.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		_, _ = w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "NotImplemented", http.StatusNotImplemented)
+	}
+}
+
+func TestS3(t *testing.T) {
+	fake := &fakeS3{bucket: "minectl", objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	backend, err := NewS3(t.Context(), &S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "minectl",
+		Prefix:          "backups/",
+		AccessKeyID:     "minectl",
+		SecretAccessKey: "s3cr3t",
+		PathStyle:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBackend(t, backend)
+	for key := range fake.objects {
+		if !strings.HasPrefix(key, "backups/test/") && !strings.HasPrefix(key, "backups/other/") {
+			t.Errorf("object %s is not below the prefix", key)
+		}
+	}
+}
+
+// TestMinIO runs against a real S3-compatible service, e.g.
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	mc mb local/minectl-test
+//	MINECTL_TEST_S3_ENDPOINT=http://localhost:9000 MINECTL_TEST_S3_BUCKET=minectl-test \
+//	AWS_ACCESS_KEY_ID=minioadmin AWS_SECRET_ACCESS_KEY=minioadmin go test ./internal/backup/
+func TestMinIO(t *testing.T) {
+	endpoint := os.Getenv("MINECTL_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("MINECTL_TEST_S3_ENDPOINT is not set")
+	}
+	backend, err := NewS3(t.Context(), &S3Config{
+		Endpoint:  endpoint,
+		Bucket:    os.Getenv("MINECTL_TEST_S3_BUCKET"),
+		Prefix:    fmt.Sprintf("minectl-test-%d/", time.Now().UnixNano()),
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBackend(t, backend)
+}
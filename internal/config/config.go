@@ -0,0 +1,146 @@
+// Package config reads and writes the minectl configuration file
+// ~/.minectl/config.yaml, which holds named credential profiles and the
+// backup settings.
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FileName is the name of the configuration file inside the minectl home folder.
+	FileName = "config.yaml"
+	// DefaultProfile is used when neither --profile nor currentProfile is set.
+	DefaultProfile = "default"
+)
+
+// Config is the content of the configuration file.
+type Config struct {
+	// CurrentProfile is used when no --profile flag is given.
+	CurrentProfile string             `yaml:"currentProfile,omitempty"`
+	Profiles       map[string]Profile `yaml:"profiles,omitempty"`
+	// Backup configures where minectl backup stores the worlds.
+	Backup *backup.Config `yaml:"backup,omitempty"`
+}
+
+// Profile holds the credentials per provider, keyed by the short name of the provider.
+type Profile map[string]provider.Credentials
+
+// For returns the credentials of the provider in the profile.
+func (p Profile) For(providerName string) provider.Credentials {
+	if p == nil {
+		return nil
+	}
+	return p[providerName]
+}
+
+// Providers returns the sorted names of the providers in the profile.
+func (p Profile) Providers() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load reads the configuration file. A missing file results in an empty configuration.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the configuration file %s", path)
+	}
+	return cfg, nil
+}
+
+// Save writes the configuration file, readable only by the current user as it contains secrets.
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// ProfileName returns the name of the profile to use: the given name, the
+// current profile of the configuration or DefaultProfile.
+func (c *Config) ProfileName(name string) string {
+	if name != "" {
+		return name
+	}
+	if c.CurrentProfile != "" {
+		return c.CurrentProfile
+	}
+	return DefaultProfile
+}
+
+// Profile returns the profile with the given name. Only an explicitly
+// requested profile has to exist, otherwise an empty profile is returned.
+func (c *Config) Profile(name string) (Profile, error) {
+	profile, ok := c.Profiles[c.ProfileName(name)]
+	if !ok && name != "" {
+		return nil, errors.Errorf("profile %s not found in the configuration file", name)
+	}
+	return profile, nil
+}
+
+// SetCredentials stores the credentials of a provider in the profile.
+func (c *Config) SetCredentials(profileName, providerName string, creds provider.Credentials) {
+	if c.Profiles == nil {
+		c.Profiles = map[string]Profile{}
+	}
+	profile := c.Profiles[profileName]
+	if profile == nil {
+		profile = Profile{}
+	}
+	profile[providerName] = creds
+	c.Profiles[profileName] = profile
+}
+
+// Remove deletes the credentials of a provider from the profile, or the whole
+// profile if providerName is empty.
+func (c *Config) Remove(profileName, providerName string) error {
+	profile, ok := c.Profiles[profileName]
+	if !ok {
+		return errors.Errorf("profile %s not found in the configuration file", profileName)
+	}
+	if providerName == "" {
+		delete(c.Profiles, profileName)
+		if c.CurrentProfile == profileName {
+			c.CurrentProfile = ""
+		}
+		return nil
+	}
+	if _, ok := profile[providerName]; !ok {
+		return errors.Errorf("profile %s has no credentials for %s", profileName, providerName)
+	}
+	delete(profile, providerName)
+	return nil
+}
+
+// ProfileNames returns the sorted names of all profiles.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/pkg/provider"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Profiles) != 0 {
+		t.Errorf("got %d profiles, want 0", len(cfg.Profiles))
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	cfg := &Config{CurrentProfile: "work"}
+	cfg.SetCredentials("work", "hetzner", provider.Credentials{"HCLOUD_TOKEN": "secret"})
+	cfg.SetCredentials("work", "do", provider.Credentials{"DIGITALOCEAN_TOKEN": "token"})
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("got permissions %o, want 600", perm)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.CurrentProfile != "work" {
+		t.Errorf("got current profile %q, want %q", loaded.CurrentProfile, "work")
+	}
+	profile, err := loaded.Profile("")
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	if got := profile.For("hetzner").Get("HCLOUD_TOKEN"); got != "secret" {
+		t.Errorf("got token %q, want %q", got, "secret")
+	}
+	if got := profile.Providers(); len(got) != 2 || got[0] != "do" || got[1] != "hetzner" {
+		t.Errorf("got providers %v, want [do hetzner]", got)
+	}
+}
+
+func TestLoadBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	data := `backup:
+  s3:
+    endpoint: http://localhost:9000
+    bucket: minecraft
+    pathStyle: true
+  retention:
+    keepLast: 7
+    maxAgeDays: 30
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := &backup.Config{
+		S3:        &backup.S3Config{Endpoint: "http://localhost:9000", Bucket: "minecraft", PathStyle: true},
+		Retention: backup.Retention{KeepLast: 7, MaxAgeDays: 30},
+	}
+	if !reflect.DeepEqual(cfg.Backup, want) {
+		t.Errorf("got backup %+v, want %+v", cfg.Backup, want)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		profile string
+		want    string
+		wantErr bool
+	}{
+		{"default profile", &Config{}, "", DefaultProfile, false},
+		{"current profile", &Config{CurrentProfile: "work"}, "", "work", false},
+		{"flag overrides current profile", &Config{CurrentProfile: "work"}, "private", "private", true},
+		{"missing default profile is empty", &Config{}, "", DefaultProfile, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.ProfileName(tt.profile); got != tt.want {
+				t.Errorf("ProfileName() = %q, want %q", got, tt.want)
+			}
+			_, err := tt.cfg.Profile(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Profile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	cfg := &Config{CurrentProfile: "work"}
+	cfg.SetCredentials("work", "hetzner", provider.Credentials{"HCLOUD_TOKEN": "secret"})
+	cfg.SetCredentials("work", "do", provider.Credentials{"DIGITALOCEAN_TOKEN": "token"})
+
+	if err := cfg.Remove("work", "civo"); err == nil {
+		t.Error("expected an error for a provider without credentials")
+	}
+	if err := cfg.Remove("work", "hetzner"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if cfg.Profiles["work"].For("hetzner") != nil {
+		t.Error("expected the hetzner credentials to be removed")
+	}
+	if err := cfg.Remove("work", ""); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if len(cfg.ProfileNames()) != 0 || cfg.CurrentProfile != "" {
+		t.Errorf("expected the profile to be removed, got %v (current %q)", cfg.ProfileNames(), cfg.CurrentProfile)
+	}
+	if err := cfg.Remove("unknown", ""); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
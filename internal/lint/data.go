@@ -0,0 +1,99 @@
+package lint
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func toSet(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// javaProperties are the keys of server.properties of the Java edition,
+// including keys of older versions which are still accepted.
+var javaProperties = toSet(
+	"accepts-transfers", "allow-flight", "allow-nether", "announce-player-achievements",
+	"broadcast-console-to-ops", "broadcast-rcon-to-ops", "bug-report-link", "debug", "difficulty",
+	"enable-command-block", "enable-jmx-monitoring", "enable-query", "enable-rcon", "enable-status",
+	"enforce-secure-profile", "enforce-whitelist", "entity-broadcast-range-percentage",
+	"force-gamemode", "function-permission-level", "gamemode", "generate-structures",
+	"generator-settings", "hardcore", "hide-online-players", "initial-disabled-packs",
+	"initial-enabled-packs", "level-name", "level-seed", "level-type", "log-ips",
+	"management-server-enabled", "management-server-host", "management-server-port",
+	"management-server-secret", "management-server-tls-enabled", "management-server-tls-keystore",
+	"management-server-tls-keystore-password", "max-build-height", "max-chained-neighbor-updates",
+	"max-players", "max-tick-time", "max-world-size", "motd", "network-compression-threshold",
+	"online-mode", "op-permission-level", "pause-when-empty-seconds", "player-idle-timeout",
+	"prevent-proxy-connections", "previews-chat", "pvp", "query.port", "rate-limit",
+	"rcon.password", "rcon.port", "region-file-compression", "require-resource-pack",
+	"resource-pack", "resource-pack-id", "resource-pack-prompt", "resource-pack-sha1", "server-ip",
+	"server-port", "simulation-distance", "snooper-enabled", "spawn-animals", "spawn-monsters",
+	"spawn-npcs", "spawn-protection", "status-heartbeat-interval", "sync-chunk-writes",
+	"text-filtering-config", "text-filtering-version", "use-native-transport", "view-distance",
+	"white-list",
+)
+
+// bedrockProperties are the keys of server.properties of the Bedrock edition.
+var bedrockProperties = toSet(
+	"allow-cheats", "allow-inbound-script-debugging", "allow-list", "allow-outbound-script-debugging",
+	"block-network-ids-are-hashes", "chat-restriction", "client-side-chunk-generation-enabled",
+	"compression-algorithm", "compression-threshold", "content-log-console-output-enabled",
+	"content-log-file-enabled", "correct-player-movement", "default-player-permission-level",
+	"difficulty", "disable-custom-skins", "disable-persona", "disable-player-interaction",
+	"emit-server-telemetry", "enable-lan-visibility", "force-gamemode", "gamemode", "level-name",
+	"level-seed", "level-type", "max-players", "max-threads", "online-mode",
+	"player-idle-timeout", "player-movement-action-direction-threshold",
+	"player-movement-distance-threshold", "player-movement-duration-threshold-in-ms",
+	"player-movement-score-threshold", "script-debugger-auto-attach",
+	"server-authoritative-block-breaking", "server-authoritative-movement",
+	"server-build-radius-ratio", "server-name", "server-port", "server-portv6",
+	"texturepack-required", "tick-distance", "view-distance", "white-list",
+)
+
+// instanceSizes maps well-known instance sizes to their memory in megabytes.
+var instanceSizes = map[string]int{
+	// Hetzner
+	"cx11": 2048, "cx21": 4096, "cx31": 8192, "cx41": 16384, "cx51": 32768,
+	"cx22": 4096, "cx32": 8192, "cx42": 16384, "cx52": 32768,
+	"cpx11": 2048, "cpx21": 4096, "cpx31": 8192, "cpx41": 16384, "cpx51": 32768,
+	"cax11": 4096, "cax21": 8192, "cax31": 16384, "cax41": 32768,
+	// Civo
+	"g3.xsmall": 1024, "g3.small": 2048, "g3.medium": 4096, "g3.large": 8192, "g3.xlarge": 16384, "g3.2xlarge": 32768,
+	// Akamai
+	"g6-nanode-1": 1024, "g6-standard-1": 2048, "g6-standard-2": 4096, "g6-standard-4": 8192,
+	"g6-standard-6": 16384, "g6-standard-8": 32768,
+	// AWS
+	"t3.medium": 4096, "t3.large": 8192, "t3.xlarge": 16384, "t3.2xlarge": 32768,
+	"c6g.large": 4096, "c6g.xlarge": 8192, "c6g.2xlarge": 16384,
+	// Scaleway
+	"DEV1-S": 2048, "DEV1-M": 4096, "DEV1-L": 8192, "DEV1-XL": 12288, "GP1-XS": 16384,
+}
+
+var (
+	// sizes with the memory in the name, like s-4vcpu-8gb (DigitalOcean) or vc2-2c-4gb (Vultr)
+	memoryInNameRegex = regexp.MustCompile(`(?i)-(\d+)gb?$`)
+	// GCE standard machine types have 4 GB per vCPU
+	gceStandardRegex = regexp.MustCompile(`^(?:e2|n2|n2d|t2a|t2d)-standard-(\d+)$`)
+)
+
+// instanceMemory returns the memory of an instance size in megabytes. The
+// lookup is best-effort, ok is false for unknown sizes.
+func instanceMemory(size string) (int, bool) {
+	if memory, ok := instanceSizes[size]; ok {
+		return memory, true
+	}
+	if match := memoryInNameRegex.FindStringSubmatch(size); match != nil && strings.Count(size, "-") > 1 {
+		gb, _ := strconv.Atoi(match[1])
+		return gb * 1024, true
+	}
+	if match := gceStandardRegex.FindStringSubmatch(size); match != nil {
+		cpus, _ := strconv.Atoi(match[1])
+		return cpus * 4 * 1024, true
+	}
+	return 0, false
+}
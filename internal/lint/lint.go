@@ -0,0 +1,150 @@
+// Package lint runs semantic checks on manifests which go beyond the JSON
+// schema, without talking to any cloud provider.
+package lint
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dirien/minectl-sdk/model"
+	"github.com/dirien/minectl/internal/manifest"
+)
+
+// Severity of a finding. Only errors fail the validation.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a single problem of a manifest.
+type Finding struct {
+	File     string   `json:"file"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// Field is the dotted path of the field, e.g. spec.minecraft.java.xmx.
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+func (f Finding) String() string {
+	location := f.File
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", location, f.Severity, f.Message, f.Rule)
+}
+
+// Rule is a single semantic check.
+type Rule struct {
+	Name  string
+	Check func(resource *model.MinecraftResource) []Finding
+}
+
+// Rules are all checks Lint runs, in order.
+var Rules = []Rule{
+	{Name: "java-version", Check: checkJavaVersion},
+	{Name: "heap", Check: checkHeap},
+	{Name: "heap-size", Check: checkHeapSize},
+	{Name: "rcon-password", Check: checkRCONPassword},
+	{Name: "port-collision", Check: checkPortCollision},
+	{Name: "provider-capability", Check: checkProviderCapability},
+	{Name: "unknown-property", Check: checkProperties},
+}
+
+// Lint runs all rules on the resource.
+func Lint(resource *model.MinecraftResource) []Finding {
+	var findings []Finding
+	for _, rule := range Rules {
+		for _, finding := range rule.Check(resource) {
+			finding.Rule = rule.Name
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// Path runs File for the manifest file or all manifest files of a directory.
+func Path(path string) ([]Finding, error) {
+	files, err := manifest.Files(path)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, file := range files {
+		fileFindings, err := File(file)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, fileFindings...)
+	}
+	return findings, nil
+}
+
+// File parses and validates all manifests of the file and runs all rules on
+// them. Schema violations and manifests which can not be parsed are reported
+// as findings, the returned error is only set when the file can not be read.
+func File(path string) ([]Finding, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	documents, err := manifest.LoadFile(path)
+	if err != nil {
+		var verrs *manifest.ValidationErrors
+		if !errors.As(err, &verrs) {
+			return []Finding{{File: path, Rule: "manifest", Severity: SeverityError, Message: err.Error()}}, nil
+		}
+		findings := make([]Finding, 0, len(verrs.Errors))
+		for _, verr := range verrs.Errors {
+			findings = append(findings, Finding{
+				File:     path,
+				Rule:     "schema",
+				Severity: SeverityError,
+				Field:    verr.Field,
+				Message:  verr.Message,
+				Line:     verr.Line,
+				Column:   verr.Column,
+			})
+		}
+		return findings, nil
+	}
+
+	var findings []Finding
+	for _, document := range documents {
+		for _, finding := range Lint(document.Resource) {
+			finding.File = path
+			finding.Line, finding.Column = document.Position(finding.Field)
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// Count returns the number of errors and warnings.
+func Count(findings []Finding) (errs, warnings int) {
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	return errs, warnings
+}
+
+// Sort orders the findings by file and line.
+func Sort(findings []Finding) {
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		if c := strings.Compare(a.File, b.File); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Line, b.Line)
+	})
+}
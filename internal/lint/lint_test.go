@@ -0,0 +1,142 @@
+package lint
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dirien/minectl-sdk/model"
+	// testdata/server.yaml uses the fake provider
+	_ "github.com/dirien/minectl/pkg/provider/fake"
+)
+
+func newResource() *model.MinecraftResource {
+	return &model.MinecraftResource{
+		Kind:     "MinecraftServer",
+		Metadata: model.Metadata{Name: "test"},
+		Spec: model.Spec{
+			Server: model.Server{Cloud: "hetzner", Size: "cpx31", Port: 25565, SSH: model.SSH{Port: 22}},
+			Minecraft: model.Minecraft{
+				Edition: "java",
+				Version: "1.21.11",
+				Java: model.Java{
+					OpenJDK: 21,
+					Xmx:     "2G",
+					Xms:     "1G",
+					Rcon:    model.Rcon{Enabled: true, Password: "secret", Port: 25575},
+				},
+				Properties: "level-seed=minectl\nmotd=test\n",
+			},
+		},
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name         string
+		modify       func(r *model.MinecraftResource)
+		wantRule     string
+		wantSeverity Severity
+	}{
+		{"valid", func(_ *model.MinecraftResource) {}, "", ""},
+		{"java too old", func(r *model.MinecraftResource) { r.Spec.Minecraft.Java.OpenJDK = 17 }, "java-version", SeverityError},
+		{"old minecraft with java 8", func(r *model.MinecraftResource) {
+			r.Spec.Minecraft.Version = "1.16.5"
+			r.Spec.Minecraft.Java.OpenJDK = 8
+		}, "", ""},
+		{"unknown version", func(r *model.MinecraftResource) { r.Spec.Minecraft.Version = "latest" }, "java-version", SeverityWarning},
+		{"xms larger than xmx", func(r *model.MinecraftResource) { r.Spec.Minecraft.Java.Xms = "4G" }, "heap", SeverityError},
+		{"invalid heap", func(r *model.MinecraftResource) { r.Spec.Minecraft.Java.Xmx = "2 GB" }, "heap", SeverityError},
+		{"heap exceeds instance", func(r *model.MinecraftResource) { r.Spec.Minecraft.Java.Xmx = "10G" }, "heap-size", SeverityError},
+		{"heap without headroom", func(r *model.MinecraftResource) { r.Spec.Minecraft.Java.Xmx = "7680M" }, "heap-size", SeverityWarning},
+		{"rcon without password", func(r *model.MinecraftResource) { r.Spec.Minecraft.Java.Rcon.Password = "" }, "rcon-password", SeverityError},
+		{"port collision", func(r *model.MinecraftResource) { r.Spec.Minecraft.Java.Rcon.Port = 25565 }, "port-collision", SeverityError},
+		{"spot not supported", func(r *model.MinecraftResource) { r.Spec.Server.Spot = true }, "provider-capability", SeverityError},
+		{"unknown provider", func(r *model.MinecraftResource) { r.Spec.Server.Cloud = "unknown" }, "provider-capability", SeverityError},
+		{"unknown property", func(r *model.MinecraftResource) { r.Spec.Minecraft.Properties = "max-player=10\n" }, "unknown-property", SeverityWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newResource()
+			tt.modify(r)
+			findings := Lint(r)
+			if tt.wantRule == "" {
+				if len(findings) != 0 {
+					t.Fatalf("expected no findings, got %v", findings)
+				}
+				return
+			}
+			if len(findings) != 1 {
+				t.Fatalf("expected one finding, got %v", findings)
+			}
+			if findings[0].Rule != tt.wantRule || findings[0].Severity != tt.wantSeverity {
+				t.Errorf("got %s/%s, want %s/%s", findings[0].Rule, findings[0].Severity, tt.wantRule, tt.wantSeverity)
+			}
+		})
+	}
+}
+
+func TestParseHeap(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"2G", 2048, false},
+		{"512m", 512, false},
+		{"1048576k", 1024, false},
+		{"2GB", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseHeap(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHeap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseHeap() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstanceMemory(t *testing.T) {
+	tests := []struct {
+		size   string
+		want   int
+		wantOK bool
+	}{
+		{"cpx31", 8192, true},
+		{"s-4vcpu-8gb", 8192, true},
+		{"vc2-1c-1gb", 1024, true},
+		{"e2-standard-2", 8192, true},
+		{"1-4G", 0, false},
+		{"medium", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, ok := instanceMemory(tt.size)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("instanceMemory() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	findings, err := File(filepath.Join("testdata", "server.yaml"))
+	if err != nil {
+		t.Fatalf("File() error = %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("expected one finding, got %v", findings)
+	}
+	got := findings[0]
+	if got.Rule != "java-version" || got.Line != 19 || got.Column != 16 {
+		t.Errorf("got %s", got)
+	}
+
+	if _, err := File(filepath.Join("testdata", "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,289 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dirien/minectl-sdk/model"
+	"github.com/dirien/minectl/pkg/provider"
+)
+
+// javaEditions are the editions which run the Java edition of Minecraft and
+// use its version scheme.
+var javaEditions = []string{"java", "papermc", "purpur", "spigot", "craftbukkit", "fabric", "forge"}
+
+var versionRegex = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// parseVersion parses the leading major.minor.patch of versions like 1.21.11-117.
+func parseVersion(version string) ([3]int, bool) {
+	var v [3]int
+	match := versionRegex.FindStringSubmatch(version)
+	if match == nil {
+		return v, false
+	}
+	for i := range v {
+		v[i], _ = strconv.Atoi(match[i+1])
+	}
+	return v, true
+}
+
+func versionAtLeast(v [3]int, major, minor, patch int) bool {
+	other := [3]int{major, minor, patch}
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] > other[i]
+		}
+	}
+	return true
+}
+
+// requiredJava returns the minimum Java version of a Minecraft version.
+func requiredJava(v [3]int) int {
+	switch {
+	case versionAtLeast(v, 26, 1, 0):
+		return 25
+	case versionAtLeast(v, 1, 20, 5):
+		return 21
+	case versionAtLeast(v, 1, 18, 0):
+		return 17
+	case versionAtLeast(v, 1, 17, 0):
+		return 16
+	default:
+		return 8
+	}
+}
+
+func checkJavaVersion(resource *model.MinecraftResource) []Finding {
+	if resource.IsProxyServer() {
+		proxy := resource.Spec.Proxy
+		v, ok := parseVersion(proxy.Version)
+		if proxy.Type != "velocity" || !ok || !versionAtLeast(v, 3, 3, 0) || proxy.Java.OpenJDK >= 17 {
+			return nil
+		}
+		return []Finding{{
+			Severity: SeverityError,
+			Field:    "spec.proxy.java.openjdk",
+			Message:  fmt.Sprintf("velocity %s requires at least Java 17, got %d", proxy.Version, proxy.Java.OpenJDK),
+		}}
+	}
+	if !isJavaEdition(resource) {
+		return nil
+	}
+	v, ok := parseVersion(resource.GetVersion())
+	if !ok {
+		return []Finding{{
+			Severity: SeverityWarning,
+			Field:    "spec.minecraft.version",
+			Message:  fmt.Sprintf("unknown version %q, the Java version can not be checked", resource.GetVersion()),
+		}}
+	}
+	if required := requiredJava(v); resource.GetJDKVersion() < required {
+		return []Finding{{
+			Severity: SeverityError,
+			Field:    "spec.minecraft.java.openjdk",
+			Message:  fmt.Sprintf("Minecraft %s requires at least Java %d, got %d", resource.GetVersion(), required, resource.GetJDKVersion()),
+		}}
+	}
+	return nil
+}
+
+func isJavaEdition(resource *model.MinecraftResource) bool {
+	return slices.Contains(javaEditions, resource.GetEdition())
+}
+
+// java returns the java settings and their field path of a server or proxy.
+func java(resource *model.MinecraftResource) (model.Java, string) {
+	if resource.IsProxyServer() {
+		return resource.Spec.Proxy.Java, "spec.proxy.java"
+	}
+	return resource.Spec.Minecraft.Java, "spec.minecraft.java"
+}
+
+var heapRegex = regexp.MustCompile(`^(\d+)([kKmMgG]?)$`)
+
+// parseHeap converts a JVM heap size like 2G or 512M to megabytes.
+func parseHeap(value string) (int, error) {
+	match := heapRegex.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("invalid heap size %q, use a value like 512M or 2G", value)
+	}
+	size, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(match[2]) {
+	case "k":
+		return size / 1024, nil
+	case "g":
+		return size * 1024, nil
+	case "m":
+		return size, nil
+	default:
+		return size / (1024 * 1024), nil
+	}
+}
+
+func checkHeap(resource *model.MinecraftResource) []Finding {
+	j, path := java(resource)
+	if j.Xmx == "" || j.Xms == "" {
+		return nil
+	}
+	var findings []Finding
+	xmx, err := parseHeap(j.Xmx)
+	if err != nil {
+		findings = append(findings, Finding{Severity: SeverityError, Field: path + ".xmx", Message: err.Error()})
+	}
+	xms, err := parseHeap(j.Xms)
+	if err != nil {
+		findings = append(findings, Finding{Severity: SeverityError, Field: path + ".xms", Message: err.Error()})
+	}
+	if len(findings) == 0 && xms > xmx {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Field:    path + ".xms",
+			Message:  fmt.Sprintf("xms (%s) must not be larger than xmx (%s)", j.Xms, j.Xmx),
+		})
+	}
+	return findings
+}
+
+// osHeadroom is the memory in megabytes left for the operating system.
+const osHeadroom = 1024
+
+func checkHeapSize(resource *model.MinecraftResource) []Finding {
+	j, path := java(resource)
+	if j.Xmx == "" {
+		return nil
+	}
+	xmx, err := parseHeap(j.Xmx)
+	if err != nil {
+		return nil
+	}
+	memory, ok := instanceMemory(resource.GetSize())
+	if !ok {
+		return nil
+	}
+	switch {
+	case xmx > memory:
+		return []Finding{{
+			Severity: SeverityError,
+			Field:    path + ".xmx",
+			Message:  fmt.Sprintf("xmx (%s) exceeds the memory of the instance %s (%dM)", j.Xmx, resource.GetSize(), memory),
+		}}
+	case xmx > memory-osHeadroom:
+		return []Finding{{
+			Severity: SeverityWarning,
+			Field:    path + ".xmx",
+			Message:  fmt.Sprintf("xmx (%s) leaves less than %dM of the instance %s (%dM) for the operating system", j.Xmx, osHeadroom, resource.GetSize(), memory),
+		}}
+	}
+	return nil
+}
+
+func checkRCONPassword(resource *model.MinecraftResource) []Finding {
+	if !resource.HasRCON() || resource.GetRCONPassword() != "" {
+		return nil
+	}
+	_, path := java(resource)
+	return []Finding{{
+		Severity: SeverityError,
+		Field:    path + ".rcon.password",
+		Message:  "RCON is enabled without a password",
+	}}
+}
+
+func checkPortCollision(resource *model.MinecraftResource) []Finding {
+	type port struct {
+		field string
+		port  int
+	}
+	_, path := java(resource)
+	ports := []port{
+		{"spec.server.port", resource.GetPort()},
+		{"spec.server.ssh.port", resource.GetSSHPort()},
+	}
+	if resource.HasRCON() {
+		ports = append(ports, port{path + ".rcon.port", resource.GetRCONPort()})
+	}
+	var findings []Finding
+	seen := map[int]string{}
+	for _, p := range ports {
+		if p.port == 0 {
+			continue
+		}
+		if other, ok := seen[p.port]; ok {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Field:    p.field,
+				Message:  fmt.Sprintf("port %d is already used by %s", p.port, other),
+			})
+			continue
+		}
+		seen[p.port] = p.field
+	}
+	return findings
+}
+
+func checkProviderCapability(resource *model.MinecraftResource) []Finding {
+	p, err := provider.Get(resource.GetCloud())
+	if err != nil {
+		return []Finding{{Severity: SeverityError, Field: "spec.server.cloud", Message: err.Error()}}
+	}
+	var findings []Finding
+	if resource.IsSpot() && !p.Supports(provider.Spot) {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Field:    "spec.server.spot",
+			Message:  p.FullName + " does not support spot instances",
+		})
+	}
+	if resource.IsArm() && !p.Supports(provider.ARM) {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Field:    "spec.server.arm",
+			Message:  p.FullName + " does not support ARM instances",
+		})
+	}
+	return findings
+}
+
+func checkProperties(resource *model.MinecraftResource) []Finding {
+	var known map[string]bool
+	switch {
+	case resource.IsProxyServer():
+		return nil
+	case resource.GetEdition() == "bedrock":
+		known = bedrockProperties
+	case isJavaEdition(resource):
+		known = javaProperties
+	default:
+		return nil
+	}
+	var findings []Finding
+	for _, line := range strings.Split(resource.GetProperties(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, _, ok := strings.Cut(line, "=")
+		if !ok {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Field:    "spec.minecraft.properties",
+				Message:  fmt.Sprintf("property %q is not in the format key=value", line),
+			})
+			continue
+		}
+		if key = strings.TrimSpace(key); !known[key] {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Field:    "spec.minecraft.properties",
+				Message:  fmt.Sprintf("unknown property %q for the %s edition", key, resource.GetEdition()),
+			})
+		}
+	}
+	return findings
+}
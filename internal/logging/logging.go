@@ -2,6 +2,8 @@ package logging
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -12,9 +14,29 @@ import (
 
 type MinectlLogging struct {
 	headless bool
+	out      io.Writer
 }
 
-func NewLogging(verbose, logEncoding string, headless bool) (*MinectlLogging, error) {
+// Option configures the logging.
+type Option func(*MinectlLogging)
+
+// WithStderr writes all human-readable output and logs to stderr, keeping
+// stdout free for machine-readable output.
+func WithStderr() Option {
+	return func(l *MinectlLogging) {
+		l.out = os.Stderr
+	}
+}
+
+func NewLogging(verbose, logEncoding string, headless bool, opts ...Option) (*MinectlLogging, error) {
+	l := &MinectlLogging{
+		headless: headless,
+		out:      os.Stdout,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
 	var level zapcore.Level
 	err := level.Set(verbose)
 	if err != nil {
@@ -39,21 +61,27 @@ func NewLogging(verbose, logEncoding string, headless bool) (*MinectlLogging, er
 
 	if verbose != "" {
 		cfg.OutputPaths = []string{"stdout"}
+		if l.out == os.Stderr {
+			cfg.OutputPaths = []string{"stderr"}
+		}
 		cfg.ErrorOutputPaths = []string{"stderr"}
 	}
 
 	logger, _ := cfg.Build()
 	zap.ReplaceGlobals(logger)
-	return &MinectlLogging{
-		headless: headless,
-	}, nil
+	return l, nil
+}
+
+// Writer returns the writer for human-readable output.
+func (l *MinectlLogging) Writer() io.Writer {
+	return l.out
 }
 
 func (l *MinectlLogging) Error(msg error) {
 	if l.headless {
 		zap.S().Error(msg)
 	} else {
-		fmt.Println(msg)
+		fmt.Fprintln(l.out, msg)
 	}
 }
 
@@ -61,7 +89,7 @@ func (l *MinectlLogging) RawMessage(msg string) {
 	if l.headless {
 		zap.S().Infow(strings.ReplaceAll(msg, "\n", ""))
 	} else {
-		fmt.Println(msg)
+		fmt.Fprintln(l.out, msg)
 	}
 }
 
@@ -70,7 +98,7 @@ func (l *MinectlLogging) PrintMixedGreen(format, value string) {
 		zap.S().Infow(strings.ReplaceAll(fmt.Sprintf(format, value), "\n", ""))
 	} else {
 		greenStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
-		fmt.Printf(format+"\n", greenStyle.Render(value))
+		fmt.Fprintf(l.out, format+"\n", greenStyle.Render(value))
 	}
 }
 
@@ -0,0 +1,79 @@
+package manifest
+
+import (
+	"encoding/json"
+	"time"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Actions of the auto-shutdown, once a server is idle.
+const (
+	ShutdownStop   = "stop"
+	ShutdownDelete = "delete"
+)
+
+// defaultWarningMinutes is the time the players are warned before the
+// shutdown, unless the idle time is shorter, see DefaultWarning.
+const defaultWarningMinutes = 5
+
+// AutoShutdown is the `spec.autoShutdown` section of a manifest. The
+// watchdog shuts the server down once no players were online for IdleMinutes.
+type AutoShutdown struct {
+	IdleMinutes int `json:"idleMinutes"`
+	// WarningMinutes before the shutdown, the players are warned via RCON.
+	// Defaults to 5, at most half of IdleMinutes, 0 disables the warning.
+	WarningMinutes *int `json:"warningMinutes,omitempty"`
+	// Action defaults to stop.
+	Action string `json:"action,omitempty"`
+}
+
+// Idle returns the time without players after which the server is shut down.
+func (a *AutoShutdown) Idle() time.Duration {
+	return time.Duration(a.IdleMinutes) * time.Minute
+}
+
+// Warning returns the time before the shutdown the players are warned.
+func (a *AutoShutdown) Warning() time.Duration {
+	if a.WarningMinutes == nil {
+		return DefaultWarning(a.Idle())
+	}
+	return time.Duration(*a.WarningMinutes) * time.Minute
+}
+
+// DefaultWarning returns the warning of an idle time without an explicit
+// warning: 5 minutes, but at most half of the idle time, so the warning is
+// always sent before the shutdown.
+func DefaultWarning(idle time.Duration) time.Duration {
+	return min(defaultWarningMinutes*time.Minute, idle/2)
+}
+
+// ShutdownAction returns the action, stop by default.
+func (a *AutoShutdown) ShutdownAction() string {
+	if a.Action == "" {
+		return ShutdownStop
+	}
+	return a.Action
+}
+
+// decodeAutoShutdown reads the auto-shutdown section of a manifest. It returns
+// nil when the manifest has none.
+func decodeAutoShutdown(document []byte) (*AutoShutdown, error) {
+	var manifest struct {
+		Spec struct {
+			AutoShutdown *AutoShutdown `json:"autoShutdown"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(document, &manifest); err != nil {
+		return nil, err
+	}
+	return manifest.Spec.AutoShutdown, nil
+}
+
+// checkAutoShutdown validates that the warning is sent before the shutdown.
+func checkAutoShutdown(node *yamlv3.Node, autoShutdown *AutoShutdown) []ValidationError {
+	if autoShutdown == nil || autoShutdown.WarningMinutes == nil || *autoShutdown.WarningMinutes < autoShutdown.IdleMinutes {
+		return nil
+	}
+	return []ValidationError{newValidationError(node, "spec.autoShutdown.warningMinutes", "must be less than idleMinutes")}
+}
@@ -0,0 +1,284 @@
+package manifest
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	fileReference = "file://"
+	envReference  = "env://"
+)
+
+// secretFields are the fields which can reference a file or an environment variable.
+var secretFields = []string{
+	"spec.minecraft.java.rcon.password",
+	"spec.proxy.java.rcon.password",
+	"spec.proxy.network.secret",
+	"spec.server.ssh.publickey",
+}
+
+// variableRegex matches ${VAR} and ${VAR:-default}. $${VAR} escapes the expansion.
+var variableRegex = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate expands the environment variables of the manifest and resolves
+// the references of the secret fields. Relative file references are resolved
+// against the directory of the manifest.
+func interpolate(file string, manifest []byte) ([]byte, error) {
+	verrs := &ValidationErrors{File: file}
+	expanded, err := expandVariables(manifest, verrs)
+	if err != nil {
+		return nil, err
+	}
+	if len(verrs.Errors) > 0 {
+		return nil, verrs
+	}
+	resolved, err := resolveReferences(filepath.Dir(file), expanded, verrs)
+	if err != nil {
+		return nil, err
+	}
+	if len(verrs.Errors) > 0 {
+		return nil, verrs
+	}
+	return resolved, nil
+}
+
+// expandVariables expands the variables in the scalar values of the
+// manifest, comments and keys are left alone. Values which would change the
+// structure of the manifest are substituted as double-quoted scalars.
+func expandVariables(manifest []byte, verrs *ValidationErrors) ([]byte, error) {
+	if !variableRegex.Match(manifest) {
+		return manifest, nil
+	}
+	documents, err := decodeDocuments(manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the manifest")
+	}
+	var scalars []*yamlv3.Node
+	for _, document := range documents {
+		scalars = appendValueScalars(scalars, document)
+	}
+	// replace from the end, so the columns of the scalars before stay valid
+	slices.SortFunc(scalars, func(a, b *yamlv3.Node) int {
+		return cmp.Or(cmp.Compare(b.Line, a.Line), cmp.Compare(b.Column, a.Column))
+	})
+	lines := strings.Split(string(manifest), "\n")
+	for _, node := range scalars {
+		if node.Style == yamlv3.LiteralStyle || node.Style == yamlv3.FoldedStyle {
+			expandBlock(lines, node, verrs)
+		} else {
+			expandScalar(lines, node, verrs)
+		}
+	}
+	slices.SortStableFunc(verrs.Errors, func(a, b ValidationError) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// appendValueScalars appends the scalars below node containing a variable,
+// skipping the keys of mappings.
+func appendValueScalars(scalars []*yamlv3.Node, node *yamlv3.Node) []*yamlv3.Node {
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		if variableRegex.MatchString(node.Value) {
+			scalars = append(scalars, node)
+		}
+	case yamlv3.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			scalars = appendValueScalars(scalars, node.Content[i])
+		}
+	case yamlv3.DocumentNode, yamlv3.SequenceNode:
+		for _, child := range node.Content {
+			scalars = appendValueScalars(scalars, child)
+		}
+	}
+	return scalars
+}
+
+// expandScalar expands a plain or quoted scalar in place.
+func expandScalar(lines []string, node *yamlv3.Node, verrs *ValidationErrors) {
+	offset := node.Column
+	if node.Style != 0 {
+		offset++ // the opening quote
+	}
+	value := expandString(node.Value, func(i int, message string) {
+		verrs.Errors = append(verrs.Errors, ValidationError{Message: message, Line: node.Line, Column: offset + i})
+	})
+	replacement := strconv.Quote(value)
+	if node.Style == 0 && isPlainScalar(value) {
+		replacement = value
+	}
+	if err := replaceToken(lines, node, replacement); err != nil {
+		verrs.Errors = append(verrs.Errors, ValidationError{Message: err.Error(), Line: node.Line, Column: node.Column})
+	}
+}
+
+// expandBlock expands a literal or folded block scalar line by line. The
+// lines of multi-line values are indented like the block.
+func expandBlock(lines []string, node *yamlv3.Node, verrs *ValidationErrors) {
+	indent := -1
+	for i := node.Line; i < len(lines); i++ {
+		content := strings.TrimLeft(lines[i], " ")
+		if content == "" {
+			continue
+		}
+		current := len(lines[i]) - len(content)
+		if indent == -1 {
+			indent = current
+		}
+		if current < indent {
+			return
+		}
+		lines[i] = expandString(lines[i], func(column int, message string) {
+			verrs.Errors = append(verrs.Errors, ValidationError{Message: message, Line: i + 1, Column: column + 1})
+		})
+		lines[i] = strings.ReplaceAll(lines[i], "\n", "\n"+strings.Repeat(" ", indent))
+	}
+}
+
+// expandString expands the variables of the text. Unset variables without a
+// default are reported with their byte offset in the text.
+func expandString(text string, report func(offset int, message string)) string {
+	var out strings.Builder
+	last := 0
+	for _, match := range variableRegex.FindAllStringSubmatchIndex(text, -1) {
+		out.WriteString(text[last:match[0]])
+		last = match[1]
+		if text[match[0]+1] == '$' {
+			// escaped: $${VAR} becomes ${VAR}
+			out.WriteString(text[match[0]+1 : match[1]])
+			continue
+		}
+		name := text[match[2]:match[3]]
+		value, ok := os.LookupEnv(name)
+		if match[4] != -1 && value == "" {
+			// ${VAR:-default} uses the default for unset and empty variables
+			out.WriteString(text[match[6]:match[7]])
+			continue
+		}
+		if ok {
+			out.WriteString(value)
+			continue
+		}
+		report(match[0], fmt.Sprintf("environment variable %s is not set and has no default", name))
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// isPlainScalar reports whether the value can be written as a plain scalar
+// without changing the structure of the manifest, also in flow collections.
+func isPlainScalar(value string) bool {
+	if value == "" || strings.ContainsAny(value, "\r\n,[]{}") {
+		return false
+	}
+	var node yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(value), &node); err != nil || len(node.Content) != 1 {
+		return false
+	}
+	scalar := node.Content[0]
+	return scalar.Kind == yamlv3.ScalarNode && scalar.Style == 0 && scalar.Value == value
+}
+
+// resolveReferences replaces file:// and env:// references of the secret
+// fields. The value is replaced in place as a double-quoted scalar, so the
+// line numbers of the manifest do not change.
+func resolveReferences(dir string, manifest []byte, verrs *ValidationErrors) ([]byte, error) {
+	documents, err := decodeDocuments(manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the manifest")
+	}
+	lines := strings.Split(string(manifest), "\n")
+	for _, document := range documents {
+		for _, field := range secretFields {
+			resolveField(dir, lines, document, field, verrs)
+		}
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func resolveField(dir string, lines []string, document *yamlv3.Node, field string, verrs *ValidationErrors) {
+	node, found := walkPath(document, field)
+	if !found || node.Kind != yamlv3.ScalarNode || !isReference(node.Value) {
+		return
+	}
+	value, err := resolveReference(dir, node.Value)
+	if err == nil {
+		err = replaceScalar(lines, node, value)
+	}
+	if err != nil {
+		verrs.Errors = append(verrs.Errors, ValidationError{
+			Field:   field,
+			Message: err.Error(),
+			Line:    node.Line,
+			Column:  node.Column,
+		})
+	}
+}
+
+func isReference(value string) bool {
+	return strings.HasPrefix(value, fileReference) || strings.HasPrefix(value, envReference)
+}
+
+func resolveReference(dir, ref string) (string, error) {
+	if name, ok := strings.CutPrefix(ref, envReference); ok {
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			return "", fmt.Errorf("environment variable %s of %s is not set", name, ref)
+		}
+		return value, nil
+	}
+	path, err := homedir.Expand(strings.TrimPrefix(ref, fileReference))
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// replaceScalar replaces the scalar of the node in the source lines.
+func replaceScalar(lines []string, node *yamlv3.Node, value string) error {
+	if node.Style != 0 && node.Style != yamlv3.DoubleQuotedStyle && node.Style != yamlv3.SingleQuotedStyle {
+		return fmt.Errorf("the reference %s has to be a plain or quoted scalar", node.Value)
+	}
+	return replaceToken(lines, node, strconv.Quote(value))
+}
+
+// replaceToken replaces the single-line plain or quoted scalar of the node
+// in the source lines with the replacement.
+func replaceToken(lines []string, node *yamlv3.Node, replacement string) error {
+	token := node.Value
+	switch node.Style {
+	case yamlv3.DoubleQuotedStyle:
+		token = `"` + token + `"`
+	case yamlv3.SingleQuotedStyle:
+		token = `'` + token + `'`
+	}
+	if node.Line < 1 || node.Line > len(lines) {
+		return fmt.Errorf("failed to locate the scalar %s", node.Value)
+	}
+	line := lines[node.Line-1]
+	start := node.Column - 1
+	if start < 0 || start > len(line) || !strings.HasPrefix(line[start:], token) {
+		return fmt.Errorf("failed to locate the scalar %s, it has to be on a single line", node.Value)
+	}
+	lines[node.Line-1] = line[:start] + replacement + line[start+len(token):]
+	return nil
+}
@@ -1,18 +1,25 @@
 package manifest
 
 import (
+	"bytes"
+	"crypto/sha256"
 	_ "embed"
-	"errors"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"regexp"
-	"strings"
+	"slices"
 
 	"github.com/dirien/minectl-sdk/common"
+	"github.com/pkg/errors"
 
 	"github.com/dirien/minectl-sdk/model"
+	"github.com/dirien/minectl/internal/plugins"
 	"github.com/xeipuuv/gojsonschema"
-	"sigs.k8s.io/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 type MinecraftServerManifest struct {
@@ -30,32 +37,66 @@ var server string
 //go:embed proxy.json
 var proxy string
 
-func validate(manifest []byte) error {
-	var schemaLoader gojsonschema.JSONLoader
-	if strings.Contains(string(manifest), MinecraftProxy) {
-		schemaLoader = gojsonschema.NewStringLoader(proxy)
-	} else if strings.Contains(string(manifest), MinecraftServer) {
-		schemaLoader = gojsonschema.NewStringLoader(server)
-	}
-	yaml, err := yaml.YAMLToJSON(manifest)
-	if err != nil {
-		return err
+var schemas = map[string]gojsonschema.JSONLoader{
+	MinecraftServer: gojsonschema.NewStringLoader(server),
+	MinecraftProxy:  gojsonschema.NewStringLoader(proxy),
+}
+
+// Document is a single manifest of a file. A file can contain several
+// manifests separated by ---.
+type Document struct {
+	// File is the manifest file the document was read from.
+	File string
+	// Index is the position of the document in the file, starting at 0.
+	Index    int
+	Resource *model.MinecraftResource
+	// Network wires a proxy to its backends, nil for servers and proxies without network.
+	Network *Network
+	// AutoShutdown configures the watchdog, nil when the manifest has none.
+	AutoShutdown *AutoShutdown
+	// Plugins are installed by plugins sync, nil when the manifest has no plugins section.
+	Plugins []plugins.Spec
+
+	node *yamlv3.Node
+	json []byte
+}
+
+// Hash returns the hex encoded sha256 checksum of the document.
+func (d *Document) Hash() string {
+	sum := sha256.Sum256(d.json)
+	return hex.EncodeToString(sum[:])
+}
+
+// Position returns the line and column of the dotted field path in the manifest file.
+func (d *Document) Position(field string) (int, int) {
+	node := lookupNode(d.node, field)
+	if node == nil {
+		return 0, 0
 	}
-	documentLoader := gojsonschema.NewStringLoader(string(yaml))
+	return node.Line, node.Column
+}
 
-	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+// validate checks the document against the schema of its kind.
+func validate(node *yamlv3.Node, document []byte) ([]ValidationError, error) {
+	kind, _ := walkPath(node, "kind")
+	schemaLoader, ok := schemas[kind.Value]
+	if kind.Kind != yamlv3.ScalarNode || !ok {
+		return []ValidationError{{
+			Field:   "kind",
+			Message: fmt.Sprintf("unknown kind %q, please use %s or %s", kind.Value, MinecraftServer, MinecraftProxy),
+			Line:    kind.Line,
+			Column:  kind.Column,
+		}}, nil
+	}
+	result, err := gojsonschema.Validate(schemaLoader, gojsonschema.NewBytesLoader(document))
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	if !result.Valid() {
-		fmt.Printf("The document is not valid. see errors :\n")
-		for _, desc := range result.Errors() {
-			fmt.Printf("- %s\n", desc)
-			return errors.New("validation error")
-		}
+	var verrs []ValidationError
+	for _, resultError := range result.Errors() {
+		verrs = append(verrs, newValidationError(node, resultError.Field(), resultError.Description()))
 	}
-	return nil
+	return verrs, nil
 }
 
 func checkNamePattern(serverName string) error {
@@ -73,27 +114,198 @@ func checkSSHPort(port int) error {
 	return nil
 }
 
-func NewMinecraftResource(manifestPath string) (*model.MinecraftResource, error) {
-	var server model.MinecraftResource
-	manifestFile, err := os.ReadFile(manifestPath)
+// decodeDocuments splits the YAML stream into its documents. Empty documents are skipped.
+func decodeDocuments(manifest []byte) ([]*yamlv3.Node, error) {
+	var nodes []*yamlv3.Node
+	decoder := yamlv3.NewDecoder(bytes.NewReader(manifest))
+	for {
+		node := &yamlv3.Node{}
+		err := decoder.Decode(node)
+		if errors.Is(err, io.EOF) {
+			return nodes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(node.Content) == 0 || node.Content[0].Tag == "!!null" {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+}
+
+// LoadFile reads all manifests of a file. Every document is validated against
+// the schema of its kind, all errors of the file are returned as ValidationErrors.
+func LoadFile(path string) ([]*Document, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	err = validate(manifestFile)
+	data, err = interpolate(path, data)
 	if err != nil {
 		return nil, err
 	}
-	err = yaml.Unmarshal(manifestFile, &server)
+	nodes, err := decodeDocuments(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the manifest %s", path)
+	}
+	if len(nodes) == 0 {
+		return nil, errors.Errorf("no manifest found in %s", path)
+	}
+
+	verrs := &ValidationErrors{File: path}
+	documents := make([]*Document, 0, len(nodes))
+	for i, node := range nodes {
+		document, errs, err := newDocument(path, i, node)
+		if err != nil {
+			return nil, err
+		}
+		verrs.Errors = append(verrs.Errors, errs...)
+		documents = append(documents, document)
+	}
+	if len(verrs.Errors) > 0 {
+		return nil, verrs
+	}
+	return documents, nil
+}
+
+func newDocument(path string, index int, node *yamlv3.Node) (*Document, []ValidationError, error) {
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse the manifest %s", path)
+	}
+	data, err := json.Marshal(value)
 	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to convert the manifest %s", path)
+	}
+	verrs, err := validate(node, data)
+	if err != nil || len(verrs) > 0 {
+		return nil, verrs, err
+	}
+
+	var resource model.MinecraftResource
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse the manifest %s", path)
+	}
+	if err := checkNamePattern(resource.GetName()); err != nil {
+		verrs = append(verrs, newValidationError(node, "metadata.name", err.Error()))
+	}
+	if err := checkSSHPort(resource.GetSSHPort()); err != nil {
+		verrs = append(verrs, newValidationError(node, "spec.server.ssh.port", err.Error()))
+	}
+	network, err := decodeNetwork(data)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse the network of the manifest %s", path)
+	}
+	verrs = append(verrs, checkNetwork(node, &resource, network)...)
+	autoShutdown, err := decodeAutoShutdown(data)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse the auto-shutdown of the manifest %s", path)
+	}
+	verrs = append(verrs, checkAutoShutdown(node, autoShutdown)...)
+	specs, err := decodePlugins(data)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse the plugins of the manifest %s", path)
+	}
+	verrs = append(verrs, checkPlugins(node, specs)...)
+	return &Document{
+		File:         path,
+		Index:        index,
+		Resource:     &resource,
+		Network:      network,
+		AutoShutdown: autoShutdown,
+		Plugins:      specs,
+		node:         node,
+		json:         data,
+	}, verrs, nil
+}
+
+// Files returns the manifest files of a path. For a directory, these are all
+// *.yaml and *.yml files in it, sorted by name.
+func Files(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("no manifest files found in %s", path)
+	}
+	return files, nil
+}
+
+// Load reads all manifests of a file or a directory. The names of the
+// manifests have to be unique per cloud provider and the backends of a network
+// have to be part of the manifests. The documents are returned in the order
+// they have to be created: proxies with a network after their backends.
+func Load(path string) ([]*Document, error) {
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+	var documents []*Document
+	for _, file := range files {
+		fileDocuments, err := LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, fileDocuments...)
+	}
+	seen := map[string]*Document{}
+	for _, document := range documents {
+		key := document.Resource.GetCloud() + "/" + document.Resource.GetName()
+		if other, ok := seen[key]; ok {
+			return nil, errors.Errorf("the name %s is used by several manifests (%s and %s)", document.Resource.GetName(), other.File, document.File)
+		}
+		seen[key] = document
+	}
+	if err := checkBackends(documents); err != nil {
 		return nil, err
 	}
-	err = checkNamePattern(server.GetName())
+	sortByNetwork(documents)
+	return documents, nil
+}
+
+// Select returns the document with the given name. Without a name, the path
+// has to contain a single manifest.
+func Select(documents []*Document, name string) (*Document, error) {
+	if name == "" {
+		if len(documents) != 1 {
+			return nil, errors.Errorf("found %d manifests, please select one via --name", len(documents))
+		}
+		return documents[0], nil
+	}
+	i := slices.IndexFunc(documents, func(d *Document) bool {
+		return d.Resource.GetName() == name
+	})
+	if i < 0 {
+		return nil, errors.Errorf("no manifest named %s found", name)
+	}
+	return documents[i], nil
+}
+
+// NewMinecraftResource reads the manifest at path, which has to contain a single manifest.
+func NewMinecraftResource(manifestPath string) (*model.MinecraftResource, error) {
+	documents, err := Load(manifestPath)
 	if err != nil {
 		return nil, err
 	}
-	err = checkSSHPort(server.GetSSHPort())
+	document, err := Select(documents, "")
 	if err != nil {
 		return nil, err
 	}
-	return &server, nil
+	return document.Resource, nil
 }
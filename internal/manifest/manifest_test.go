@@ -0,0 +1,351 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dirien/minectl/internal/plugins"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func TestNewMinecraftResource(t *testing.T) {
+	resource, err := NewMinecraftResource("testdata/server.yaml")
+	if err != nil {
+		t.Fatalf("NewMinecraftResource() error = %v", err)
+	}
+	if resource.GetName() != "minecraft-server" {
+		t.Errorf("got name %q, want %q", resource.GetName(), "minecraft-server")
+	}
+}
+
+func TestNewMinecraftResourceValidationErrors(t *testing.T) {
+	_, err := NewMinecraftResource("testdata/server-invalid.yaml")
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if verrs.File != "testdata/server-invalid.yaml" {
+		t.Errorf("got file %q", verrs.File)
+	}
+
+	want := map[string]ValidationError{
+		"spec.server.ssh":     {Line: 12, Column: 7},
+		"spec.server.port":    {Line: 10, Column: 11},
+		"spec.minecraft.java": {Line: 16, Column: 7},
+	}
+	if len(verrs.Errors) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(verrs.Errors), len(want), verrs)
+	}
+	for _, got := range verrs.Errors {
+		w, ok := want[got.Field]
+		if !ok {
+			t.Errorf("unexpected error %v", got)
+			continue
+		}
+		if got.Line != w.Line || got.Column != w.Column {
+			t.Errorf("%s: got line %d, column %d, want line %d, column %d", got.Field, got.Line, got.Column, w.Line, w.Column)
+		}
+		if got.Message == "" {
+			t.Errorf("%s: expected a message", got.Field)
+		}
+	}
+}
+
+func TestLookupNode(t *testing.T) {
+	var root yamlv3.Node
+	manifest := "apiVersion: v1\nkind: MinecraftServer\nspec:\n  server:\n    cloud: fake\n  plugins:\n    - name: a\n    - name: b\n"
+	if err := yamlv3.Unmarshal([]byte(manifest), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		field      string
+		wantLine   int
+		wantColumn int
+	}{
+		{"root", "(root)", 1, 1},
+		{"nested field", "spec.server.cloud", 5, 12},
+		{"sequence item", "spec.plugins.1.name", 8, 13},
+		{"missing field points to the parent", "spec.server.volume", 5, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := lookupNode(&root, tt.field)
+			if node == nil {
+				t.Fatal("expected a node")
+			}
+			if node.Line != tt.wantLine || node.Column != tt.wantColumn {
+				t.Errorf("got line %d, column %d, want line %d, column %d", node.Line, node.Column, tt.wantLine, tt.wantColumn)
+			}
+		})
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	t.Setenv("CLOUD", "fake")
+	t.Setenv("TEST_RCON_PASSWORD", `pa"ss`)
+	// values must not change the structure of the manifest
+	t.Setenv("REGION", "local: {evil} # comment")
+	t.Setenv("PROPERTIES", "difficulty=hard\nmax-players=5")
+
+	resource, err := NewMinecraftResource("testdata/server-interpolate.yaml")
+	if err != nil {
+		t.Fatalf("NewMinecraftResource() error = %v", err)
+	}
+	if resource.GetName() != "minecraft-server" {
+		t.Errorf("got name %q, want the default", resource.GetName())
+	}
+	if resource.GetCloud() != "fake" {
+		t.Errorf("got cloud %q, want %q", resource.GetCloud(), "fake")
+	}
+	if resource.GetRCONPassword() != `pa"ss` {
+		t.Errorf("got rcon password %q", resource.GetRCONPassword())
+	}
+	if want := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFakeKeyForTheFakeProvider minectl"; resource.GetSSHPublicKey() != want {
+		t.Errorf("got public key %q, want %q", resource.GetSSHPublicKey(), want)
+	}
+	if resource.GetRegion() != "local: {evil} # comment" {
+		t.Errorf("got region %q", resource.GetRegion())
+	}
+	if resource.GetProperties() != "motd=${NOT_EXPANDED}\ndifficulty=hard\nmax-players=5\n" {
+		t.Errorf("got properties %q", resource.GetProperties())
+	}
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	t.Setenv("CLOUD", "")
+	os.Unsetenv("CLOUD")
+	t.Setenv("TEST_RCON_PASSWORD", "")
+
+	_, err := NewMinecraftResource("testdata/server-interpolate.yaml")
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if len(verrs.Errors) != 1 || verrs.Errors[0].Line != 7 || verrs.Errors[0].Column != 12 {
+		t.Fatalf("unexpected errors %v", verrs)
+	}
+
+	t.Setenv("CLOUD", "fake")
+	_, err = NewMinecraftResource("testdata/server-interpolate.yaml")
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if len(verrs.Errors) != 1 || verrs.Errors[0].Field != "spec.minecraft.java.rcon.password" || verrs.Errors[0].Line != 23 {
+		t.Fatalf("unexpected errors %v", verrs)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		names []string
+	}{
+		{"single document", "testdata/server.yaml", []string{"minecraft-server"}},
+		{"multi document", "testdata/multi/servers.yaml", []string{"lobby", "survival"}},
+		{"directory", "testdata/multi", []string{"creative", "lobby", "survival"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documents, err := Load(tt.path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			var names []string
+			for _, document := range documents {
+				names = append(names, document.Resource.GetName())
+			}
+			if !slices.Equal(names, tt.names) {
+				t.Errorf("got names %v, want %v", names, tt.names)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load("testdata/duplicate.yaml")
+	if err == nil || !strings.Contains(err.Error(), "minecraft-server is used by several manifests") {
+		t.Errorf("expected duplicate name error, got %v", err)
+	}
+
+	_, err = Load("testdata/unknown-kind.yaml")
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if len(verrs.Errors) != 1 || verrs.Errors[0].Field != "kind" || verrs.Errors[0].Line != 38 {
+		t.Errorf("expected unknown kind error on line 38, got %v", verrs)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	documents, err := Load("testdata/multi/servers.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", "", true},
+		{"survival", "survival", false},
+		{"unknown", "", true},
+	}
+	for _, tt := range tests {
+		document, err := Select(documents, tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("Select(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && document.Resource.GetName() != tt.want {
+			t.Errorf("Select(%q) = %s, want %s", tt.name, document.Resource.GetName(), tt.want)
+		}
+	}
+	if documents[0].Hash() == documents[1].Hash() {
+		t.Error("expected different hashes for different documents")
+	}
+}
+
+func TestLoadNetwork(t *testing.T) {
+	t.Setenv("MINECTL_TEST_FORWARDING_SECRET", "s3cr3t")
+	documents, err := Load("testdata/network.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var names []string
+	for _, document := range documents {
+		names = append(names, document.Resource.GetName())
+	}
+	if want := []string{"lobby", "survival", "proxy"}; !slices.Equal(names, want) {
+		t.Fatalf("got order %v, want %v", names, want)
+	}
+	proxy := documents[2]
+	if proxy.Network == nil || proxy.Network.Secret != "s3cr3t" {
+		t.Fatalf("unexpected network %+v", proxy.Network)
+	}
+	if got := proxy.Network.ForwardingMode("velocity"); got != ForwardingModern {
+		t.Errorf("got forwarding %s, want %s", got, ForwardingModern)
+	}
+	if got := proxy.Network.TryOrder(); !slices.Equal(got, []string{"lobby"}) {
+		t.Errorf("got try order %v", got)
+	}
+	backends := Backends(documents, proxy)
+	if len(backends) != 2 || backends[0] != documents[0] || backends[1] != documents[1] {
+		t.Errorf("unexpected backends %v", backends)
+	}
+}
+
+func TestLoadNetworkErrors(t *testing.T) {
+	_, err := Load("testdata/network-invalid.yaml")
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	want := map[string]int{
+		"spec.proxy.network.try.0": 35,
+		"spec.proxy.network":       30,
+	}
+	if len(verrs.Errors) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(verrs.Errors), len(want), verrs)
+	}
+	for _, got := range verrs.Errors {
+		if line, ok := want[got.Field]; !ok || got.Line != line {
+			t.Errorf("unexpected error %v", got)
+		}
+	}
+
+	t.Setenv("MINECTL_TEST_FORWARDING_SECRET", "s3cr3t")
+	_, err = Load("testdata/network-missing.yaml")
+	if err == nil || !strings.Contains(err.Error(), "testdata/network-missing.yaml:32:11: backend survival") {
+		t.Errorf("expected missing backend error, got %v", err)
+	}
+}
+
+func TestLoadAutoShutdown(t *testing.T) {
+	documents, err := Load("testdata/autoshutdown.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	autoShutdown := documents[0].AutoShutdown
+	if autoShutdown == nil {
+		t.Fatal("expected an auto-shutdown")
+	}
+	if autoShutdown.Idle() != 30*time.Minute || autoShutdown.Warning() != 5*time.Minute || autoShutdown.ShutdownAction() != ShutdownDelete {
+		t.Errorf("unexpected auto-shutdown %+v", autoShutdown)
+	}
+
+	documents, err = Load("testdata/server.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if documents[0].AutoShutdown != nil {
+		t.Errorf("expected no auto-shutdown, got %+v", documents[0].AutoShutdown)
+	}
+
+	_, err = Load("testdata/autoshutdown-invalid.yaml")
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs.Errors) != 1 || verrs.Errors[0].Field != "spec.autoShutdown.warningMinutes" || verrs.Errors[0].Line != 38 {
+		t.Errorf("expected a warningMinutes error in line 38, got %v", err)
+	}
+}
+
+func TestAutoShutdownWarning(t *testing.T) {
+	zero, ten := 0, 10
+	tests := []struct {
+		name         string
+		autoShutdown AutoShutdown
+		want         time.Duration
+	}{
+		{"default", AutoShutdown{IdleMinutes: 30}, 5 * time.Minute},
+		{"default of a short idle time", AutoShutdown{IdleMinutes: 5}, 150 * time.Second},
+		{"default of one minute", AutoShutdown{IdleMinutes: 1}, 30 * time.Second},
+		{"disabled", AutoShutdown{IdleMinutes: 5, WarningMinutes: &zero}, 0},
+		{"explicit", AutoShutdown{IdleMinutes: 30, WarningMinutes: &ten}, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.autoShutdown.Warning(); got != tt.want {
+				t.Errorf("Warning() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPlugins(t *testing.T) {
+	documents, err := Load("testdata/plugins.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []plugins.Spec{
+		{Name: "fabric-api"},
+		{Name: "lithium", Source: "modrinth", Version: "mc1.21.4-0.14.3"},
+		{Name: "ledger", URL: "https://example.com/ledger-1.3.5.jar", SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	}
+	if !reflect.DeepEqual(documents[0].Plugins, want) {
+		t.Errorf("Plugins = %+v, want %+v", documents[0].Plugins, want)
+	}
+
+	documents, err = Load("testdata/server.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if documents[0].Plugins != nil {
+		t.Errorf("expected no plugins section, got %+v", documents[0].Plugins)
+	}
+
+	_, err = Load("testdata/plugins-invalid.yaml")
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs.Errors) != 2 {
+		t.Fatalf("expected 2 validation errors, got %v", err)
+	}
+	if verrs.Errors[0].Field != "spec.plugins.1.name" || verrs.Errors[0].Line != 38 || verrs.Errors[1].Field != "spec.plugins.2.url" || verrs.Errors[1].Line != 42 {
+		t.Errorf("unexpected validation errors %v", verrs.Errors)
+	}
+}
@@ -0,0 +1,154 @@
+package manifest
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dirien/minectl-sdk/model"
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Forwarding modes of the player information from the proxy to the backends.
+const (
+	ForwardingNone   = "none"
+	ForwardingLegacy = "legacy"
+	ForwardingModern = "modern"
+)
+
+// Network is the `spec.proxy.network` section of a proxy manifest. It
+// references the backend servers by the name of their manifests.
+type Network struct {
+	Backends []string `json:"backends"`
+	// Try is the order in which the proxy connects players to the backends.
+	// Defaults to the order of Backends.
+	Try []string `json:"try,omitempty"`
+	// Forwarding defaults to modern for Velocity and legacy for BungeeCord and Waterfall.
+	Forwarding string `json:"forwarding,omitempty"`
+	// Secret is the forwarding secret, required for modern forwarding.
+	Secret string `json:"secret,omitempty"`
+}
+
+// TryOrder returns the backends in the order the proxy tries them.
+func (n *Network) TryOrder() []string {
+	if len(n.Try) > 0 {
+		return n.Try
+	}
+	return n.Backends
+}
+
+// ForwardingMode returns the forwarding mode for the proxy type.
+func (n *Network) ForwardingMode(proxyType string) string {
+	if n.Forwarding != "" {
+		return n.Forwarding
+	}
+	if proxyType == "velocity" {
+		return ForwardingModern
+	}
+	return ForwardingLegacy
+}
+
+// decodeNetwork reads the network section of a proxy manifest. It returns nil
+// when the manifest has none.
+func decodeNetwork(document []byte) (*Network, error) {
+	var manifest struct {
+		Spec struct {
+			Proxy struct {
+				Network *Network `json:"network"`
+			} `json:"proxy"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(document, &manifest); err != nil {
+		return nil, err
+	}
+	return manifest.Spec.Proxy.Network, nil
+}
+
+// checkNetwork validates the network section against the proxy type.
+func checkNetwork(node *yamlv3.Node, resource *model.MinecraftResource, network *Network) []ValidationError {
+	if network == nil {
+		return nil
+	}
+	var verrs []ValidationError
+	for i, name := range network.Try {
+		if !slices.Contains(network.Backends, name) {
+			field := fmt.Sprintf("spec.proxy.network.try.%d", i)
+			verrs = append(verrs, newValidationError(node, field, fmt.Sprintf("%s is not one of the backends", name)))
+		}
+	}
+	proxyType := resource.Spec.Proxy.Type
+	switch mode := network.ForwardingMode(proxyType); {
+	case mode == ForwardingModern && proxyType != "velocity":
+		verrs = append(verrs, newValidationError(node, "spec.proxy.network.forwarding", fmt.Sprintf("modern forwarding is only supported by velocity, not %s", proxyType)))
+	case mode == ForwardingModern && network.Secret == "":
+		verrs = append(verrs, newValidationError(node, "spec.proxy.network", "modern forwarding requires a secret"))
+	}
+	return verrs
+}
+
+// checkBackends checks that the backends of every network reference a single
+// MinecraftServer manifest of the documents.
+func checkBackends(documents []*Document) error {
+	var problems []string
+	for _, document := range documents {
+		if document.Network == nil {
+			continue
+		}
+		for i, name := range document.Network.Backends {
+			var found []*Document
+			for _, other := range documents {
+				if other.Resource.GetName() == name && !other.Resource.IsProxyServer() {
+					found = append(found, other)
+				}
+			}
+			if len(found) == 1 {
+				continue
+			}
+			line, column := document.Position(fmt.Sprintf("spec.proxy.network.backends.%d", i))
+			reason := "no MinecraftServer manifest with this name found"
+			if len(found) > 1 {
+				reason = fmt.Sprintf("%d MinecraftServer manifests with this name found", len(found))
+			}
+			problems = append(problems, fmt.Sprintf("%s:%d:%d: backend %s: %s", document.File, line, column, name, reason))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("invalid network backends:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Backends returns the backend documents of the proxy document, in the order of its network.
+func Backends(documents []*Document, proxy *Document) []*Document {
+	if proxy.Network == nil {
+		return nil
+	}
+	backends := make([]*Document, 0, len(proxy.Network.Backends))
+	for _, name := range proxy.Network.Backends {
+		i := slices.IndexFunc(documents, func(d *Document) bool {
+			return d.Resource.GetName() == name && !d.Resource.IsProxyServer()
+		})
+		if i >= 0 {
+			backends = append(backends, documents[i])
+		}
+	}
+	return backends
+}
+
+// sortByNetwork moves the proxies with a network behind all other manifests,
+// so their backends are created first.
+func sortByNetwork(documents []*Document) {
+	slices.SortStableFunc(documents, func(a, b *Document) int {
+		return cmp.Compare(networkRank(a), networkRank(b))
+	})
+}
+
+func networkRank(document *Document) int {
+	if document.Network != nil {
+		return 1
+	}
+	return 0
+}
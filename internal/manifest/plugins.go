@@ -0,0 +1,44 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dirien/minectl/internal/plugins"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// decodePlugins reads the `spec.plugins` section of a manifest. It returns
+// nil when the manifest has none, and an empty slice for `plugins: []`.
+func decodePlugins(document []byte) ([]plugins.Spec, error) {
+	var manifest struct {
+		Spec struct {
+			Plugins []plugins.Spec `json:"plugins"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(document, &manifest); err != nil {
+		return nil, err
+	}
+	return manifest.Spec.Plugins, nil
+}
+
+// checkPlugins validates that every plugin is listed once and that plugins
+// are downloaded from a url exactly when they have one.
+func checkPlugins(node *yamlv3.Node, specs []plugins.Spec) []ValidationError {
+	var verrs []ValidationError
+	seen := map[string]bool{}
+	for i, spec := range specs {
+		field := fmt.Sprintf("spec.plugins.%d", i)
+		if seen[spec.Name] {
+			verrs = append(verrs, newValidationError(node, field+".name", fmt.Sprintf("the plugin %s is listed more than once", spec.Name)))
+		}
+		seen[spec.Name] = true
+		switch {
+		case spec.URL != "" && spec.Source != "" && spec.Source != plugins.SourceURL:
+			verrs = append(verrs, newValidationError(node, field+".url", fmt.Sprintf("plugins of the %s source have no url", spec.Source)))
+		case spec.URL == "" && spec.Source == plugins.SourceURL:
+			verrs = append(verrs, newValidationError(node, field, "plugins of the url source require a url"))
+		}
+	}
+	return verrs
+}
@@ -0,0 +1,112 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ValidationError is a single schema violation of a manifest.
+type ValidationError struct {
+	// Field is the dotted path of the invalid field, e.g. spec.server.port.
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	// Line and Column point to the field in the manifest file, 0 when unknown.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+func (e ValidationError) Error() string {
+	msg := e.Message
+	if e.Field != "" {
+		msg = e.Field + ": " + msg
+	}
+	if e.Line == 0 {
+		return msg
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, msg)
+}
+
+// ValidationErrors holds all schema violations of a manifest file.
+type ValidationErrors struct {
+	File   string            `json:"file"`
+	Errors []ValidationError `json:"errors"`
+}
+
+func (e *ValidationErrors) Error() string {
+	return e.Summary() + ":\n" + strings.Join(e.Details(), "\n")
+}
+
+// Summary returns a one line description of the errors.
+func (e *ValidationErrors) Summary() string {
+	return fmt.Sprintf("the manifest %s is not valid, found %d error(s)", e.File, len(e.Errors))
+}
+
+// Details returns one message per schema violation.
+func (e *ValidationErrors) Details() []string {
+	details := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		details[i] = err.Error()
+	}
+	return details
+}
+
+// newValidationError looks up the position of the field in the document.
+func newValidationError(document *yamlv3.Node, field, message string) ValidationError {
+	verr := ValidationError{Field: field, Message: message}
+	if node := lookupNode(document, field); node != nil {
+		verr.Line = node.Line
+		verr.Column = node.Column
+	}
+	return verr
+}
+
+// lookupNode returns the node of the dotted field path gojsonschema reports,
+// or the closest existing parent when the field itself is missing.
+func lookupNode(root *yamlv3.Node, field string) *yamlv3.Node {
+	node, _ := walkPath(root, field)
+	return node
+}
+
+// walkPath follows the dotted field path as far as possible. found is false
+// when the path ends before the field.
+func walkPath(root *yamlv3.Node, field string) (node *yamlv3.Node, found bool) {
+	node = root
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind == 0 {
+		return nil, false
+	}
+	if field == "" || field == gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+		return node, true
+	}
+	for _, part := range strings.Split(field, ".") {
+		next := childNode(node, part)
+		if next == nil {
+			return node, false
+		}
+		node = next
+	}
+	return node, true
+}
+
+func childNode(node *yamlv3.Node, key string) *yamlv3.Node {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case yamlv3.SequenceNode:
+		index, err := strconv.Atoi(key)
+		if err == nil && index >= 0 && index < len(node.Content) {
+			return node.Content[index]
+		}
+	}
+	return nil
+}
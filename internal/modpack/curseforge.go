@@ -0,0 +1,111 @@
+package modpack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CurseForgeURL is the base URL of the CurseForge API.
+	CurseForgeURL = "https://api.curseforge.com"
+	// CurseForgeAPIKeyEnv is the environment variable with the key of the CurseForge API.
+	CurseForgeAPIKeyEnv = "CURSEFORGE_API_KEY"
+)
+
+// hash algorithms of the CurseForge API
+const (
+	curseForgeSHA1 = 1
+	curseForgeMD5  = 2
+)
+
+// CurseForgeResolver looks up the files of CurseForge packs via the API of
+// curseforge.com, which requires a key, see https://docs.curseforge.com/
+type CurseForgeResolver struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewCurseForgeResolver returns a resolver. An empty baseURL uses CurseForgeURL.
+func NewCurseForgeResolver(client *http.Client, baseURL, apiKey string) *CurseForgeResolver {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = CurseForgeURL
+	}
+	return &CurseForgeResolver{client: client, baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey}
+}
+
+type curseForgeFile struct {
+	Data struct {
+		FileName    string `json:"fileName"`
+		DownloadURL string `json:"downloadUrl"`
+		FileLength  int64  `json:"fileLength"`
+		Hashes      []struct {
+			Value string `json:"value"`
+			Algo  int    `json:"algo"`
+		} `json:"hashes"`
+	} `json:"data"`
+}
+
+// Resolve adds the download URL and checksums of the projects of a
+// CurseForge pack to its files. Projects whose authors forbid third-party
+// downloads can not be resolved.
+func (r *CurseForgeResolver) Resolve(ctx context.Context, pack *Pack) error {
+	if len(pack.Projects) == 0 {
+		return nil
+	}
+	if r.apiKey == "" {
+		return errors.Errorf("the files of CurseForge modpacks are looked up via the CurseForge API, please set %s", CurseForgeAPIKeyEnv)
+	}
+	for _, project := range pack.Projects {
+		file, err := r.file(ctx, project)
+		if err != nil {
+			return errors.Wrapf(err, "failed to look up the file %d of the CurseForge project %d", project.FileID, project.ProjectID)
+		}
+		if file.Data.DownloadURL == "" {
+			return errors.Errorf("the author of %s forbids its download by other apps, please download it from https://www.curseforge.com/projects/%d", file.Data.FileName, project.ProjectID)
+		}
+		resolved := File{Path: path.Join("mods", path.Base(file.Data.FileName)), URLs: []string{file.Data.DownloadURL}, Size: file.Data.FileLength}
+		for _, hash := range file.Data.Hashes {
+			switch hash.Algo {
+			case curseForgeSHA1:
+				resolved.SHA1 = hash.Value
+			case curseForgeMD5:
+				resolved.MD5 = hash.Value
+			}
+		}
+		pack.Files = append(pack.Files, resolved)
+	}
+	return nil
+}
+
+func (r *CurseForgeResolver) file(ctx context.Context, project Project) (*curseForgeFile, error) {
+	u := fmt.Sprintf("%s/v1/mods/%d/files/%d", r.baseURL, project.ProjectID, project.FileID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", r.apiKey)
+	req.Header.Set("Accept", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", resp.Status)
+	}
+	var file curseForgeFile
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
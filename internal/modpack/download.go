@@ -0,0 +1,104 @@
+package modpack
+
+import (
+	"context"
+	"crypto/md5"  //nolint:gosec // CurseForge publishes MD5 checksums
+	"crypto/sha1" //nolint:gosec // Modrinth and CurseForge publish SHA-1 checksums
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Size returns the size of the files of the pack, 0 when unknown.
+func (p *Pack) Size() int64 {
+	var size int64
+	for _, file := range p.Files {
+		size += file.Size
+	}
+	return size
+}
+
+// Download stores the files of the pack below dir and verifies their
+// checksums. The URLs of a file are tried in order. progress receives the
+// downloaded bytes.
+func (p *Pack) Download(ctx context.Context, client *http.Client, dir string, progress io.Writer) error {
+	for _, file := range p.Files {
+		target := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		var err error
+		for _, url := range file.URLs {
+			if err = download(ctx, client, file, url, target, progress); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func download(ctx context.Context, client *http.Client, file File, url, target string, progress io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %s", file.Path)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to download %s from %s: %s", file.Path, url, resp.Status)
+	}
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	checksums := map[string]struct {
+		hash hash.Hash
+		want string
+	}{
+		"SHA-1":   {sha1.New(), file.SHA1}, //nolint:gosec
+		"SHA-512": {sha512.New(), file.SHA512},
+		"MD5":     {md5.New(), file.MD5}, //nolint:gosec
+	}
+	writers := []io.Writer{out}
+	for _, checksum := range checksums {
+		writers = append(writers, checksum.hash)
+	}
+	var body io.Reader = resp.Body
+	if progress != nil {
+		body = io.TeeReader(body, progress)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), body); err != nil {
+		_ = out.Close()
+		return errors.Wrapf(err, "failed to download %s", file.Path)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	verified := false
+	for name, checksum := range checksums {
+		if checksum.want == "" {
+			continue
+		}
+		if sum := hex.EncodeToString(checksum.hash.Sum(nil)); sum != checksum.want {
+			return errors.Errorf("the %s checksum of %s is %s, want %s", name, file.Path, sum, checksum.want)
+		}
+		verified = true
+	}
+	if !verified {
+		return errors.Errorf("the modpack has no checksum of %s", file.Path)
+	}
+	return nil
+}
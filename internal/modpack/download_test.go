@@ -0,0 +1,100 @@
+package modpack
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha512"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDownload(t *testing.T) {
+	content := []byte("lithium")
+	sha1sum := sha1.Sum(content) //nolint:gosec
+	sha512sum := sha512.Sum512(content)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/lithium.jar" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		file    File
+		wantErr bool
+	}{
+		{name: "verified", file: File{Path: "mods/lithium.jar", URLs: []string{server.URL + "/lithium.jar"}, SHA1: hex.EncodeToString(sha1sum[:]), SHA512: hex.EncodeToString(sha512sum[:])}},
+		{name: "second URL", file: File{Path: "mods/lithium.jar", URLs: []string{server.URL + "/missing.jar", server.URL + "/lithium.jar"}, SHA1: hex.EncodeToString(sha1sum[:])}},
+		{name: "checksum mismatch", file: File{Path: "mods/lithium.jar", URLs: []string{server.URL + "/lithium.jar"}, SHA512: "0000"}, wantErr: true},
+		{name: "without checksum", file: File{Path: "mods/lithium.jar", URLs: []string{server.URL + "/lithium.jar"}}, wantErr: true},
+		{name: "not found", file: File{Path: "mods/lithium.jar", URLs: []string{server.URL + "/missing.jar"}, SHA1: hex.EncodeToString(sha1sum[:])}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			pack := &Pack{Files: []File{tt.file}}
+			err := pack.Download(t.Context(), server.Client(), dir, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Download() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got, err := os.ReadFile(filepath.Join(dir, "mods", "lithium.jar")); !tt.wantErr && (err != nil || string(got) != string(content)) {
+				t.Errorf("downloaded %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestCurseForgeResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/mods/238222/files/4712866":
+			_, _ = w.Write([]byte(`{"data": {"fileName": "jei-1.20.1.jar", "downloadUrl": "https://edge.forgecdn.net/jei-1.20.1.jar", "fileLength": 42,
+				"hashes": [{"value": "abc", "algo": 1}, {"value": "def", "algo": 2}]}}`))
+		case "/v1/mods/1/files/2":
+			_, _ = w.Write([]byte(`{"data": {"fileName": "private.jar", "downloadUrl": null}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		apiKey   string
+		projects []Project
+		want     []File
+		wantErr  bool
+	}{
+		{
+			name: "resolved", apiKey: "key", projects: []Project{{ProjectID: 238222, FileID: 4712866, Required: true}},
+			want: []File{{Path: "mods/jei-1.20.1.jar", URLs: []string{"https://edge.forgecdn.net/jei-1.20.1.jar"}, SHA1: "abc", MD5: "def", Size: 42}},
+		},
+		{name: "third-party downloads forbidden", apiKey: "key", projects: []Project{{ProjectID: 1, FileID: 2}}, wantErr: true},
+		{name: "unknown file", apiKey: "key", projects: []Project{{ProjectID: 1, FileID: 3}}, wantErr: true},
+		{name: "without api key", projects: []Project{{ProjectID: 238222, FileID: 4712866}}, wantErr: true},
+		{name: "without projects"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack := &Pack{Projects: tt.projects}
+			err := NewCurseForgeResolver(server.Client(), server.URL, tt.apiKey).Resolve(t.Context(), pack)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(pack.Files, tt.want) {
+				t.Errorf("Resolve() files = %+v, want %+v", pack.Files, tt.want)
+			}
+		})
+	}
+}
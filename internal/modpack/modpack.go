@@ -0,0 +1,289 @@
+// Package modpack reads Modrinth (.mrpack) and CurseForge modpacks and stages
+// the files a server needs for the upload.
+package modpack
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Format is the format of a modpack.
+type Format string
+
+const (
+	Modrinth   Format = "modrinth"
+	CurseForge Format = "curseforge"
+)
+
+const (
+	modrinthIndex      = "modrinth.index.json"
+	curseForgeManifest = "manifest.json"
+)
+
+// Pack is a modpack with the files a server needs.
+type Pack struct {
+	Name    string
+	Version string
+	Format  Format
+	// Minecraft is the Minecraft version the pack is built for.
+	Minecraft string
+	// Loader is forge, neoforge, fabric or quilt.
+	Loader        string
+	LoaderVersion string
+	// Files are downloaded into the server folder. The files of CurseForge
+	// packs are known after Resolve.
+	Files []File
+	// Projects are the files of a CurseForge pack.
+	Projects []Project
+
+	archive   *zip.ReadCloser
+	overrides []string
+}
+
+// File is a file of the pack, downloaded into the server folder.
+type File struct {
+	// Path is relative to the server folder, e.g. mods/lithium.jar.
+	Path   string
+	URLs   []string
+	SHA1   string
+	SHA512 string
+	MD5    string
+	Size   int64
+}
+
+// Project is a file of a CurseForge pack.
+type Project struct {
+	ProjectID int  `json:"projectID"`
+	FileID    int  `json:"fileID"`
+	Required  bool `json:"required"`
+}
+
+type modrinthPack struct {
+	FormatVersion int    `json:"formatVersion"`
+	Game          string `json:"game"`
+	Name          string `json:"name"`
+	VersionID     string `json:"versionId"`
+	Files         []struct {
+		Path   string `json:"path"`
+		Hashes struct {
+			SHA1   string `json:"sha1"`
+			SHA512 string `json:"sha512"`
+		} `json:"hashes"`
+		Env struct {
+			Server string `json:"server"`
+		} `json:"env"`
+		Downloads []string `json:"downloads"`
+		FileSize  int64    `json:"fileSize"`
+	} `json:"files"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+type curseForgePack struct {
+	ManifestType string `json:"manifestType"`
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Minecraft    struct {
+		Version    string `json:"version"`
+		ModLoaders []struct {
+			ID      string `json:"id"`
+			Primary bool   `json:"primary"`
+		} `json:"modLoaders"`
+	} `json:"minecraft"`
+	Files     []Project `json:"files"`
+	Overrides string    `json:"overrides"`
+}
+
+// modrinthLoaders maps the dependencies of a Modrinth pack to the loaders.
+var modrinthLoaders = map[string]string{
+	"forge":         "forge",
+	"neoforge":      "neoforge",
+	"fabric-loader": "fabric",
+	"quilt-loader":  "quilt",
+}
+
+// Open reads the modpack: a Modrinth .mrpack or a CurseForge zip with a
+// manifest.json. The pack has to be closed.
+func Open(name string) (*Pack, error) {
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s is not a modpack", filepath.Base(name))
+	}
+	pack := &Pack{archive: archive}
+	switch {
+	case hasFile(&archive.Reader, modrinthIndex):
+		err = pack.readModrinth()
+	case hasFile(&archive.Reader, curseForgeManifest):
+		err = pack.readCurseForge()
+	default:
+		err = errors.Errorf("%s contains neither %s nor %s", filepath.Base(name), modrinthIndex, curseForgeManifest)
+	}
+	if err != nil {
+		_ = archive.Close()
+		return nil, err
+	}
+	return pack, nil
+}
+
+// Close closes the archive of the pack.
+func (p *Pack) Close() error {
+	return p.archive.Close()
+}
+
+func hasFile(archive *zip.Reader, name string) bool {
+	_, err := archive.Open(name)
+	return err == nil
+}
+
+func (p *Pack) readJSON(name string, v any) error {
+	f, err := p.archive.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(v); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", name)
+	}
+	return nil
+}
+
+func (p *Pack) readModrinth() error {
+	var index modrinthPack
+	if err := p.readJSON(modrinthIndex, &index); err != nil {
+		return err
+	}
+	if index.Game != "minecraft" {
+		return errors.Errorf("the modpack is made for %q, not minecraft", index.Game)
+	}
+	p.Format, p.Name, p.Version = Modrinth, index.Name, index.VersionID
+	p.Minecraft = index.Dependencies["minecraft"]
+	for dependency, version := range index.Dependencies {
+		if loader, ok := modrinthLoaders[dependency]; ok {
+			p.Loader, p.LoaderVersion = loader, version
+		}
+	}
+	for _, file := range index.Files {
+		if file.Env.Server == "unsupported" {
+			continue
+		}
+		if err := checkPath(file.Path); err != nil {
+			return err
+		}
+		if len(file.Downloads) == 0 {
+			return errors.Errorf("%s has no download URL", file.Path)
+		}
+		p.Files = append(p.Files, File{Path: file.Path, URLs: file.Downloads, SHA1: file.Hashes.SHA1, SHA512: file.Hashes.SHA512, Size: file.FileSize})
+	}
+	// the server overrides take precedence over the overrides of client and server
+	p.overrides = []string{"overrides", "server-overrides"}
+	return nil
+}
+
+func (p *Pack) readCurseForge() error {
+	var manifest curseForgePack
+	if err := p.readJSON(curseForgeManifest, &manifest); err != nil {
+		return err
+	}
+	if manifest.ManifestType != "minecraftModpack" {
+		return errors.Errorf("%s is not a CurseForge modpack manifest", curseForgeManifest)
+	}
+	p.Format, p.Name, p.Version = CurseForge, manifest.Name, manifest.Version
+	p.Minecraft = manifest.Minecraft.Version
+	for _, loader := range manifest.Minecraft.ModLoaders {
+		if loader.Primary || p.Loader == "" {
+			p.Loader, p.LoaderVersion, _ = strings.Cut(loader.ID, "-")
+		}
+	}
+	for _, project := range manifest.Files {
+		if project.Required {
+			p.Projects = append(p.Projects, project)
+		}
+	}
+	p.overrides = []string{manifest.Overrides}
+	if manifest.Overrides == "" {
+		p.overrides = []string{"overrides"}
+	}
+	return nil
+}
+
+// minecraftRegex matches the Minecraft part of the version of a manifest,
+// e.g. 1.21.4 of the Forge version 1.21.4-54.1.0.
+var minecraftRegex = regexp.MustCompile(`^\d+\.\d+(\.\d+)?`)
+
+// Check verifies that the pack runs on the edition and version of a
+// manifest: the Minecraft part of the version and, for Forge, the build of
+// Forge which follows it.
+func (p *Pack) Check(edition, version string) error {
+	minecraft := minecraftRegex.FindString(version)
+	if minecraft == "" {
+		minecraft = version
+	}
+	build := strings.TrimPrefix(strings.TrimPrefix(version, minecraft), "-")
+	switch {
+	case p.Loader == "":
+		return errors.New("the modpack declares no mod loader")
+	case p.Loader != edition:
+		return errors.Errorf("the modpack needs the %s mod loader, the server runs the %s edition", p.Loader, edition)
+	case p.Minecraft != minecraft:
+		return errors.Errorf("the modpack is built for Minecraft %s, the server runs %s", p.Minecraft, minecraft)
+	case p.Loader == "forge" && p.LoaderVersion != "" && build != "" && p.LoaderVersion != build:
+		return errors.Errorf("the modpack needs Forge %s, the server runs Forge %s", p.LoaderVersion, build)
+	}
+	return nil
+}
+
+// ExtractOverrides writes the overrides of the pack, the configs and other
+// files shipped in the archive, into dir.
+func (p *Pack) ExtractOverrides(dir string) error {
+	for _, prefix := range p.overrides {
+		for _, f := range p.archive.File {
+			name, ok := strings.CutPrefix(f.Name, prefix+"/")
+			if !ok || name == "" || f.FileInfo().IsDir() {
+				continue
+			}
+			if err := checkPath(name); err != nil {
+				return err
+			}
+			if err := extract(f, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func extract(f *zip.File, target string) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		_ = file.Close()
+		return errors.Wrapf(err, "failed to extract %s", f.Name)
+	}
+	return file.Close()
+}
+
+// checkPath rejects paths leaving the server folder.
+func checkPath(name string) error {
+	clean := path.Clean(name)
+	if name == "" || path.IsAbs(name) || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(name, `\`) {
+		return errors.Errorf("the modpack contains the invalid path %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package modpack
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writePack writes a zip with the files to a temporary file and returns its path.
+func writePack(t *testing.T, name string, files map[string]string) string {
+	t.Helper()
+	pack := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(pack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return pack
+}
+
+const testIndex = `{
+  "formatVersion": 1,
+  "game": "minecraft",
+  "versionId": "6.4.0",
+  "name": "Optimized",
+  "files": [
+    {"path": "mods/lithium.jar", "hashes": {"sha1": "a", "sha512": "b"}, "env": {"client": "required", "server": "required"}, "downloads": ["https://cdn.modrinth.com/lithium.jar"], "fileSize": 10},
+    {"path": "mods/iris.jar", "hashes": {"sha1": "c", "sha512": "d"}, "env": {"client": "required", "server": "unsupported"}, "downloads": ["https://cdn.modrinth.com/iris.jar"], "fileSize": 20}
+  ],
+  "dependencies": {"minecraft": "1.21.4", "fabric-loader": "0.16.9"}
+}`
+
+const testManifest = `{
+  "manifestType": "minecraftModpack",
+  "manifestVersion": 1,
+  "name": "All the Mods",
+  "version": "1.0",
+  "minecraft": {"version": "1.20.1", "modLoaders": [{"id": "forge-47.2.0", "primary": true}]},
+  "files": [{"projectID": 238222, "fileID": 4712866, "required": true}, {"projectID": 1, "fileID": 2, "required": false}],
+  "overrides": "overrides"
+}`
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    Pack
+		wantErr bool
+	}{
+		{
+			name:  "modrinth",
+			files: map[string]string{"modrinth.index.json": testIndex},
+			want: Pack{Name: "Optimized", Version: "6.4.0", Format: Modrinth, Minecraft: "1.21.4", Loader: "fabric", LoaderVersion: "0.16.9", Files: []File{
+				{Path: "mods/lithium.jar", URLs: []string{"https://cdn.modrinth.com/lithium.jar"}, SHA1: "a", SHA512: "b", Size: 10},
+			}},
+		},
+		{
+			name:  "curseforge",
+			files: map[string]string{"manifest.json": testManifest},
+			want:  Pack{Name: "All the Mods", Version: "1.0", Format: CurseForge, Minecraft: "1.20.1", Loader: "forge", LoaderVersion: "47.2.0", Projects: []Project{{ProjectID: 238222, FileID: 4712866, Required: true}}},
+		},
+		{name: "path outside the server folder", files: map[string]string{"modrinth.index.json": `{"game": "minecraft", "files": [{"path": "../etc/passwd", "downloads": ["https://example.com"]}]}`}, wantErr: true},
+		{name: "other game", files: map[string]string{"modrinth.index.json": `{"game": "terraria"}`}, wantErr: true},
+		{name: "other manifest", files: map[string]string{"manifest.json": `{"manifestType": "other"}`}, wantErr: true},
+		{name: "no modpack", files: map[string]string{"README.md": "hello"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack, err := Open(writePack(t, "pack.zip", tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Open() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer pack.Close()
+			got := *pack
+			got.archive, got.overrides = nil, nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Open() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	pack := &Pack{Minecraft: "1.21.4", Loader: "fabric"}
+	forge := &Pack{Minecraft: "1.20.1", Loader: "forge", LoaderVersion: "47.3.0"}
+	tests := []struct {
+		name      string
+		pack      *Pack
+		edition   string
+		minecraft string
+		wantErr   bool
+	}{
+		{name: "matching", pack: pack, edition: "fabric", minecraft: "1.21.4"},
+		{name: "other loader", pack: pack, edition: "forge", minecraft: "1.21.4", wantErr: true},
+		{name: "plugin server", pack: pack, edition: "papermc", minecraft: "1.21.4", wantErr: true},
+		{name: "other minecraft version", pack: pack, edition: "fabric", minecraft: "1.21.5", wantErr: true},
+		{name: "without loader", pack: &Pack{Minecraft: "1.21.4"}, edition: "fabric", minecraft: "1.21.4", wantErr: true},
+		{name: "forge build", pack: forge, edition: "forge", minecraft: "1.20.1-47.3.0"},
+		{name: "other forge build", pack: forge, edition: "forge", minecraft: "1.20.1-47.2.0", wantErr: true},
+		{name: "other minecraft version of forge", pack: forge, edition: "forge", minecraft: "1.20.2-48.1.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.pack.Check(tt.edition, tt.minecraft); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractOverrides(t *testing.T) {
+	pack, err := Open(writePack(t, "pack.mrpack", map[string]string{
+		"modrinth.index.json":                        testIndex,
+		"overrides/config/lithium.properties":        "client",
+		"overrides/options.txt":                      "options",
+		"server-overrides/config/lithium.properties": "server",
+	}))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer pack.Close()
+	dir := t.TempDir()
+	if err := pack.ExtractOverrides(dir); err != nil {
+		t.Fatalf("ExtractOverrides() error = %v", err)
+	}
+	for name, want := range map[string]string{"config/lithium.properties": "server", "options.txt": "options"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
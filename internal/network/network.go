@@ -0,0 +1,149 @@
+// Package network renders the configuration of a proxy, so it forwards the
+// players to the backend servers of its network.
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dirien/minectl-sdk/model"
+	"github.com/dirien/minectl/internal/manifest"
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Dir is the directory of the proxy on the server.
+const Dir = "/minecraft"
+
+const header = "Generated by minectl from the manifest %s, changes are overwritten by minectl create and apply."
+
+// Backend is a server the proxy forwards players to.
+type Backend struct {
+	Name string
+	// Address is the host:port of the server.
+	Address string
+}
+
+// NewBackend returns the backend of a server manifest with the IP the server got at the cloud provider.
+func NewBackend(resource *model.MinecraftResource, ip string) Backend {
+	return Backend{Name: resource.GetName(), Address: net.JoinHostPort(ip, strconv.Itoa(resource.GetPort()))}
+}
+
+// File is a configuration file of the proxy.
+type File struct {
+	Path    string
+	Content []byte
+	Mode    os.FileMode
+}
+
+// Render returns the configuration files of the proxy.
+func Render(resource *model.MinecraftResource, network *manifest.Network, backends []Backend) ([]File, error) {
+	if len(backends) == 0 {
+		return nil, errors.Errorf("the network of %s has no backends", resource.GetName())
+	}
+	switch resource.Spec.Proxy.Type {
+	case "velocity":
+		return renderVelocity(resource, network, backends), nil
+	case "bungeecord", "waterfall":
+		file, err := renderBungeeCord(resource, network, backends)
+		if err != nil {
+			return nil, err
+		}
+		return []File{file}, nil
+	default:
+		return nil, errors.Errorf("networks are not supported for the proxy type %s", resource.Spec.Proxy.Type)
+	}
+}
+
+func renderVelocity(resource *model.MinecraftResource, network *manifest.Network, backends []Backend) []File {
+	mode := network.ForwardingMode(resource.Spec.Proxy.Type)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# "+header+"\n", resource.GetName())
+	b.WriteString("config-version = \"2.7\"\n")
+	fmt.Fprintf(&b, "bind = %s\n", strconv.Quote(net.JoinHostPort("0.0.0.0", strconv.Itoa(resource.GetPort()))))
+	b.WriteString("online-mode = true\n")
+	fmt.Fprintf(&b, "player-info-forwarding-mode = %s\n", strconv.Quote(mode))
+	b.WriteString("forwarding-secret-file = \"forwarding.secret\"\n")
+	b.WriteString("\n[servers]\n")
+	for _, backend := range backends {
+		fmt.Fprintf(&b, "%s = %s\n", backend.Name, strconv.Quote(backend.Address))
+	}
+	fmt.Fprintf(&b, "try = [%s]\n", quoteAll(network.TryOrder()))
+	b.WriteString("\n[forced-hosts]\n")
+
+	files := []File{{Path: Dir + "/velocity.toml", Content: []byte(b.String()), Mode: 0o644}}
+	if mode == manifest.ForwardingModern {
+		files = append(files, File{Path: Dir + "/forwarding.secret", Content: []byte(network.Secret), Mode: 0o600})
+	}
+	return files
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+type bungeeCordConfig struct {
+	IPForward  bool                        `yaml:"ip_forward"`
+	OnlineMode bool                        `yaml:"online_mode"`
+	Listeners  []bungeeCordListener        `yaml:"listeners"`
+	Servers    map[string]bungeeCordServer `yaml:"servers"`
+}
+
+type bungeeCordListener struct {
+	Host                 string            `yaml:"host"`
+	QueryPort            int               `yaml:"query_port"`
+	MaxPlayers           int               `yaml:"max_players"`
+	Priorities           []string          `yaml:"priorities"`
+	ForceDefaultServer   bool              `yaml:"force_default_server"`
+	ForcedHosts          map[string]string `yaml:"forced_hosts"`
+	TabList              string            `yaml:"tab_list"`
+	PingPassthrough      bool              `yaml:"ping_passthrough"`
+	BindLocalAddress     bool              `yaml:"bind_local_address"`
+	ProxyProtocolEnabled bool              `yaml:"proxy_protocol"`
+}
+
+type bungeeCordServer struct {
+	Address    string `yaml:"address"`
+	Motd       string `yaml:"motd"`
+	Restricted bool   `yaml:"restricted"`
+}
+
+func renderBungeeCord(resource *model.MinecraftResource, network *manifest.Network, backends []Backend) (File, error) {
+	config := bungeeCordConfig{
+		IPForward:  network.ForwardingMode(resource.Spec.Proxy.Type) == manifest.ForwardingLegacy,
+		OnlineMode: true,
+		Listeners: []bungeeCordListener{{
+			Host:             net.JoinHostPort("0.0.0.0", strconv.Itoa(resource.GetPort())),
+			QueryPort:        resource.GetPort(),
+			MaxPlayers:       100,
+			Priorities:       network.TryOrder(),
+			ForcedHosts:      map[string]string{},
+			TabList:          "GLOBAL_PING",
+			BindLocalAddress: true,
+		}},
+		Servers: map[string]bungeeCordServer{},
+	}
+	for _, backend := range backends {
+		config.Servers[backend.Name] = bungeeCordServer{Address: backend.Address, Motd: backend.Name}
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# "+header+"\n", resource.GetName())
+	encoder := yamlv3.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return File{}, err
+	}
+	if err := encoder.Close(); err != nil {
+		return File{}, err
+	}
+	return File{Path: Dir + "/config.yml", Content: b.Bytes(), Mode: 0o644}, nil
+}
@@ -0,0 +1,88 @@
+package network
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dirien/minectl-sdk/model"
+	"github.com/dirien/minectl/internal/manifest"
+)
+
+func proxy(proxyType string) *model.MinecraftResource {
+	resource := &model.MinecraftResource{}
+	resource.Metadata.Name = "proxy"
+	resource.Spec.Server.Port = 25577
+	resource.Spec.Proxy.Type = proxyType
+	return resource
+}
+
+var backends = []Backend{
+	{Name: "lobby", Address: "198.51.100.2:25565"},
+	{Name: "survival", Address: "198.51.100.3:25566"},
+}
+
+func TestRenderVelocity(t *testing.T) {
+	network := &manifest.Network{Backends: []string{"lobby", "survival"}, Try: []string{"survival", "lobby"}, Secret: "s3cr3t"}
+	files, err := Render(proxy("velocity"), network, backends)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+	config := string(files[0].Content)
+	for _, want := range []string{
+		`bind = "0.0.0.0:25577"`,
+		`player-info-forwarding-mode = "modern"`,
+		`lobby = "198.51.100.2:25565"`,
+		`survival = "198.51.100.3:25566"`,
+		`try = ["survival", "lobby"]`,
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("velocity.toml does not contain %s:\n%s", want, config)
+		}
+	}
+	if files[1].Path != "/minecraft/forwarding.secret" || string(files[1].Content) != "s3cr3t" || files[1].Mode != 0o600 {
+		t.Errorf("unexpected secret file %+v", files[1])
+	}
+
+	network.Forwarding = manifest.ForwardingNone
+	files, err = Render(proxy("velocity"), network, backends)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("got %d files without modern forwarding, want 1", len(files))
+	}
+}
+
+func TestRenderBungeeCord(t *testing.T) {
+	network := &manifest.Network{Backends: []string{"lobby", "survival"}}
+	files, err := Render(proxy("waterfall"), network, backends)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "/minecraft/config.yml" {
+		t.Fatalf("unexpected files %+v", files)
+	}
+	config := string(files[0].Content)
+	for _, want := range []string{
+		"ip_forward: true",
+		"host: 0.0.0.0:25577",
+		"priorities:\n      - lobby\n      - survival",
+		"lobby:\n    address: 198.51.100.2:25565",
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config.yml does not contain %q:\n%s", want, config)
+		}
+	}
+}
+
+func TestRenderUnsupported(t *testing.T) {
+	if _, err := Render(proxy("velocity"), &manifest.Network{}, nil); err == nil {
+		t.Error("expected error without backends")
+	}
+	if _, err := Render(proxy("unknown"), &manifest.Network{}, backends); err == nil {
+		t.Error("expected error for unknown proxy type")
+	}
+}
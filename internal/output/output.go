@@ -0,0 +1,207 @@
+// Package output renders command results in machine-readable formats.
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/internal/plugins"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Format is the requested output format. The empty format renders human-readable tables.
+type Format string
+
+const (
+	Table Format = ""
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+)
+
+// FlagUsage is the help text of the --output flag.
+const FlagUsage = "Output format: json|yaml (default: human-readable table)"
+
+// ParseFormat validates the value of the --output flag.
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case Table, JSON, YAML:
+		return Format(value), nil
+	default:
+		return "", errors.Errorf("unknown output format %q, please use json or yaml", value)
+	}
+}
+
+// IsMachineReadable returns true for json and yaml.
+func (f Format) IsMachineReadable() bool {
+	return f == JSON || f == YAML
+}
+
+// Server is the stable, documented schema of a server in machine-readable output.
+type Server struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Cloud    string `json:"cloud,omitempty"`
+	Region   string `json:"region"`
+	PublicIP string `json:"public_ip"`
+	Tags     string `json:"tags"`
+	// PowerState is only set by list: running, stopped, pending or unknown.
+	PowerState string `json:"power_state,omitempty"`
+}
+
+// ServerList is the schema of a list of servers.
+type ServerList struct {
+	Servers []Server `json:"servers"`
+}
+
+// ServerStatus is the schema of the live health of a server.
+type ServerStatus struct {
+	Server
+	Edition    string   `json:"edition"`
+	Online     bool     `json:"online"`
+	MOTD       string   `json:"motd,omitempty"`
+	Version    string   `json:"version,omitempty"`
+	Protocol   int      `json:"protocol,omitempty"`
+	Players    int      `json:"players"`
+	MaxPlayers int      `json:"max_players"`
+	Sample     []string `json:"sample,omitempty"`
+	LatencyMS  int64    `json:"latency_ms"`
+	// RCON is disabled, reachable or unreachable.
+	RCON string `json:"rcon"`
+	// Error is the reason the server is offline.
+	Error string `json:"error,omitempty"`
+}
+
+// ServerStatusList is the schema of the health of several servers.
+type ServerStatusList struct {
+	Servers []ServerStatus `json:"servers"`
+}
+
+// Backup is the schema of a world backup.
+type Backup struct {
+	ID      string    `json:"id"`
+	Server  string    `json:"server"`
+	Edition string    `json:"edition,omitempty"`
+	Version string    `json:"version,omitempty"`
+	Worlds  []string  `json:"worlds"`
+	Created time.Time `json:"created"`
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256"`
+	Reason  string    `json:"reason,omitempty"`
+	// Location is the archive in the backend, e.g. s3://bucket/server/id.tar.zst.
+	Location string `json:"location"`
+}
+
+// BackupList is the schema of a list of backups.
+type BackupList struct {
+	Backups []Backup `json:"backups"`
+}
+
+// Plugin is the schema of a plugin found by plugins search.
+type Plugin struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Downloads   int64  `json:"downloads"`
+	Source      string `json:"source"`
+}
+
+// PluginList is the schema of a list of plugins.
+type PluginList struct {
+	Plugins []Plugin `json:"plugins"`
+}
+
+// NewPluginList converts the projects found in a plugin source into the output schema.
+func NewPluginList(projects []plugins.Project) PluginList {
+	list := PluginList{Plugins: make([]Plugin, 0, len(projects))}
+	for _, p := range projects {
+		list.Plugins = append(list.Plugins, Plugin(p))
+	}
+	return list
+}
+
+// InstalledPlugin is the schema of a jar on a server listed by plugins list.
+// Name and version are empty when the jar declares no metadata.
+type InstalledPlugin struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Loader  string `json:"loader"`
+	File    string `json:"file"`
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256"`
+}
+
+// InstalledPluginList is the schema of a list of jars on a server.
+type InstalledPluginList struct {
+	Plugins []InstalledPlugin `json:"plugins"`
+}
+
+// NewInstalledPlugin converts a jar on a server and its metadata into the output schema.
+func NewInstalledPlugin(jar, sha256 string, metadata *plugins.Metadata) InstalledPlugin {
+	plugin := InstalledPlugin{File: path.Base(jar), Path: jar, SHA256: sha256}
+	if metadata != nil {
+		plugin.Name, plugin.Version, plugin.Loader = metadata.Name, metadata.Version, string(metadata.Mod)
+	}
+	return plugin
+}
+
+// NewBackup converts the metadata of a backup in the backend into the output schema.
+func NewBackup(info *backup.Info, backend backup.Backend) Backup {
+	return Backup{
+		ID:       info.ID,
+		Server:   info.Server,
+		Edition:  info.Edition,
+		Version:  info.Version,
+		Worlds:   info.Worlds,
+		Created:  info.Created,
+		Size:     info.Size,
+		SHA256:   info.SHA256,
+		Reason:   info.Reason,
+		Location: strings.TrimSuffix(backend.String(), "/") + "/" + info.Archive(),
+	}
+}
+
+// NewServer converts the result of a cloud provider into the output schema.
+func NewServer(res *automation.ResourceResults, cloud string) Server {
+	return Server{
+		ID:       res.ID,
+		Name:     res.Name,
+		Cloud:    cloud,
+		Region:   res.Region,
+		PublicIP: res.PublicIP,
+		Tags:     res.Tags,
+	}
+}
+
+// NewServerList converts the results of a cloud provider into the output schema.
+func NewServerList(results []automation.ResourceResults, cloud string) ServerList {
+	list := ServerList{Servers: make([]Server, 0, len(results))}
+	for i := range results {
+		list.Servers = append(list.Servers, NewServer(&results[i], cloud))
+	}
+	return list
+}
+
+// Write serializes v in the given format to w.
+func Write(w io.Writer, format Format, v any) error {
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case YAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		return errors.Errorf("format %q is not machine-readable", format)
+	}
+}
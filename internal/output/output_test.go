@@ -0,0 +1,76 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dirien/minectl-sdk/automation"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Format
+		wantErr bool
+	}{
+		{"", Table, false},
+		{"json", JSON, false},
+		{"yaml", YAML, false},
+		{"xml", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseFormat(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	list := NewServerList([]automation.ResourceResults{
+		{ID: "123", Name: "minecraft-server", Region: "fra1", PublicIP: "1.2.3.4", Tags: "minectl"},
+	}, "do")
+
+	tests := []struct {
+		name    string
+		format  Format
+		wantAll []string
+		wantErr bool
+	}{
+		{
+			name:    "json",
+			format:  JSON,
+			wantAll: []string{`"servers": [`, `"id": "123"`, `"cloud": "do"`, `"public_ip": "1.2.3.4"`},
+		},
+		{
+			name:    "yaml",
+			format:  YAML,
+			wantAll: []string{"servers:", "id: \"123\"", "cloud: do", "public_ip: 1.2.3.4"},
+		},
+		{
+			name:    "table is not machine-readable",
+			format:  Table,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, tt.format, list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.wantAll {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q\ngot: %s", want, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Package ping queries Minecraft servers the way the server list of the game
+// does: Java edition servers and proxies via the Server List Ping, Bedrock
+// edition servers via an unconnected RakNet ping.
+package ping
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/dirien/minectl-sdk/model"
+	"github.com/pkg/errors"
+)
+
+// bedrockEditions answer the RakNet ping instead of the Server List Ping.
+var bedrockEditions = []string{"bedrock", "nukkit", "powernukkit"}
+
+// IsBedrock returns true when the servers of the edition are pinged via RakNet.
+func IsBedrock(edition string) bool {
+	return slices.Contains(bedrockEditions, edition)
+}
+
+// DefaultPort returns the port the servers of the edition listen on by default.
+func DefaultPort(edition string) int {
+	if IsBedrock(edition) {
+		return 19132
+	}
+	return 25565
+}
+
+// Status is the answer of a server to a ping.
+type Status struct {
+	Version    string        `json:"version"`
+	Protocol   int           `json:"protocol"`
+	MOTD       string        `json:"motd"`
+	Players    int           `json:"players"`
+	MaxPlayers int           `json:"max_players"`
+	Sample     []string      `json:"sample,omitempty"`
+	Latency    time.Duration `json:"latency"`
+}
+
+// slpResponse is the JSON response of the Server List Ping, see
+// https://minecraft.wiki/w/Java_Edition_protocol/Server_List_Ping
+type slpResponse struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+		Sample []struct {
+			Name string `json:"name"`
+		} `json:"sample"`
+	} `json:"players"`
+	Description chat.Message `json:"description"`
+}
+
+// Java pings a Java edition server or a proxy via the Server List Ping.
+func Java(ctx context.Context, addr string) (*Status, error) {
+	data, latency, err := bot.PingAndListContext(ctx, addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to ping %s", addr)
+	}
+	var response slpResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, errors.Wrapf(err, "invalid server list ping response of %s", addr)
+	}
+	status := &Status{
+		Version:    response.Version.Name,
+		Protocol:   response.Version.Protocol,
+		MOTD:       response.Description.ClearString(),
+		Players:    response.Players.Online,
+		MaxPlayers: response.Players.Max,
+		Latency:    latency,
+	}
+	for _, player := range response.Players.Sample {
+		status.Sample = append(status.Sample, player.Name)
+	}
+	return status, nil
+}
+
+// Server pings the server of the manifest at host.
+func Server(ctx context.Context, resource *model.MinecraftResource, host string) (*Status, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(resource.GetPort()))
+	if !resource.IsProxyServer() && IsBedrock(resource.GetEdition()) {
+		return Bedrock(ctx, addr)
+	}
+	return Java(ctx, addr)
+}
+
+var versionRegex = regexp.MustCompile(`^\d+\.\d+(\.\d+)?`)
+
+// Verify checks that the server runs the Minecraft version of the manifest.
+// Proxies and Nukkit report the versions of the clients they support, so they
+// are not checked.
+func Verify(resource *model.MinecraftResource, status *Status) error {
+	if resource.IsProxyServer() || resource.GetEdition() == "nukkit" || resource.GetEdition() == "powernukkit" {
+		return nil
+	}
+	want := versionRegex.FindString(resource.GetVersion())
+	if want == "" {
+		return nil
+	}
+	if !containsVersion(status.Version, want) {
+		return errors.Errorf("the server reports version %q (protocol %d), the manifest requires %s", status.Version, status.Protocol, want)
+	}
+	return nil
+}
+
+// containsVersion checks that version is contained in reported, without
+// matching 1.21.1 in 1.21.11.
+func containsVersion(reported, version string) bool {
+	for i := strings.Index(reported, version); i >= 0; {
+		end := i + len(version)
+		if end == len(reported) || !isVersionChar(reported[end]) {
+			return true
+		}
+		next := strings.Index(reported[i+1:], version)
+		if next < 0 {
+			return false
+		}
+		i += next + 1
+	}
+	return false
+}
+
+func isVersionChar(c byte) bool {
+	return c == '.' || (c >= '0' && c <= '9')
+}
+
+// Wait pings the server until it answers or the context is done. It fails
+// right away when the server answers with another version than the manifest.
+// The error of a done context is not wrapped, callers check the context.
+func Wait(ctx context.Context, resource *model.MinecraftResource, host string, interval time.Duration) (*Status, error) {
+	var lastErr error
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, interval)
+		status, err := Server(attemptCtx, resource, host)
+		cancel()
+		if err == nil {
+			return status, Verify(resource, status)
+		}
+		lastErr = err
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Errorf("the server did not answer: %v", lastErr)
+		case <-timer.C:
+		}
+	}
+}
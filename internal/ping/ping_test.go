@@ -0,0 +1,201 @@
+package ping
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	mcnet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/dirien/minectl-sdk/model"
+)
+
+const slpResponseJSON = `{
+  "version": {"name": "Paper 1.21.4", "protocol": 769},
+  "players": {"max": 20, "online": 2, "sample": [{"name": "alex", "id": "1"}, {"name": "steve", "id": "2"}]},
+  "description": {"text": "A ", "extra": [{"text": "minectl", "color": "green"}, {"text": " server"}]}
+}`
+
+func server(edition, version string) *model.MinecraftResource {
+	resource := &model.MinecraftResource{}
+	resource.Metadata.Name = "test"
+	resource.Spec.Minecraft.Edition = edition
+	resource.Spec.Minecraft.Version = version
+	return resource
+}
+
+// serveJava answers a single Server List Ping with the response.
+func serveJava(t *testing.T, response string) string {
+	t.Helper()
+	listener, err := mcnet.ListenMC("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var p pk.Packet
+		// handshake and status request
+		for range 2 {
+			if err := conn.ReadPacket(&p); err != nil {
+				return
+			}
+		}
+		if err := conn.WritePacket(pk.Marshal(0x00, pk.String(response))); err != nil {
+			return
+		}
+		// the pong echoes the ping
+		if err := conn.ReadPacket(&p); err != nil {
+			return
+		}
+		_ = conn.WritePacket(p)
+	}()
+	return listener.Addr().String()
+}
+
+// serveBedrock answers unconnected RakNet pings with the server ID, ignoring
+// the first drop pings to simulate lost packets.
+func serveBedrock(t *testing.T, serverID string, drop int) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n != 33 || buf[0] != unconnectedPing {
+				continue
+			}
+			if drop > 0 {
+				drop--
+				continue
+			}
+			pong := []byte{unconnectedPong}
+			pong = append(pong, buf[1:9]...)
+			pong = binary.BigEndian.AppendUint64(pong, 42)
+			pong = append(pong, offlineMessage...)
+			pong = binary.BigEndian.AppendUint16(pong, uint16(len(serverID))) //nolint:gosec
+			pong = append(pong, serverID...)
+			_, _ = conn.WriteTo(pong, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestJava(t *testing.T) {
+	addr := serveJava(t, slpResponseJSON)
+	status, err := Java(t.Context(), addr)
+	if err != nil {
+		t.Fatalf("Java() error = %v", err)
+	}
+	if status.Version != "Paper 1.21.4" || status.Protocol != 769 {
+		t.Errorf("got version %q protocol %d", status.Version, status.Protocol)
+	}
+	if status.MOTD != "A minectl server" {
+		t.Errorf("got MOTD %q", status.MOTD)
+	}
+	if status.Players != 2 || status.MaxPlayers != 20 || strings.Join(status.Sample, ",") != "alex,steve" {
+		t.Errorf("got players %d/%d %v", status.Players, status.MaxPlayers, status.Sample)
+	}
+}
+
+func TestBedrock(t *testing.T) {
+	addr := serveBedrock(t, "MCPE;Dedicated Server;766;1.21.50;1;10;42;Bedrock level;Survival;1;19132;19133;", 1)
+	status, err := Bedrock(t.Context(), addr)
+	if err != nil {
+		t.Fatalf("Bedrock() error = %v", err)
+	}
+	if status.Version != "1.21.50" || status.Protocol != 766 || status.MOTD != "Dedicated Server" {
+		t.Errorf("got version %q protocol %d MOTD %q", status.Version, status.Protocol, status.MOTD)
+	}
+	if status.Players != 1 || status.MaxPlayers != 10 {
+		t.Errorf("got players %d/%d", status.Players, status.MaxPlayers)
+	}
+}
+
+func TestBedrockInvalidPong(t *testing.T) {
+	addr := serveBedrock(t, "MCPE;Dedicated Server", 0)
+	if _, err := Bedrock(t.Context(), addr); err == nil || !strings.Contains(err.Error(), "invalid RakNet pong") {
+		t.Errorf("Bedrock() error = %v, want an invalid pong", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource *model.MinecraftResource
+		reported string
+		wantErr  bool
+	}{
+		{"java", server("java", "1.21.4"), "1.21.4", false},
+		{"paper", server("papermc", "1.21.4-232"), "Paper 1.21.4", false},
+		{"fabric", server("fabric", "1.20.1"), "1.20.1", false},
+		{"bedrock", server("bedrock", "1.21.50.07"), "1.21.50", false},
+		{"mismatch", server("java", "1.21.4"), "1.20.4", true},
+		{"prefix", server("java", "1.21.1"), "1.21.11", true},
+		{"nukkit", server("nukkit", "1.0-SNAPSHOT"), "1.21.50", false},
+		{"unversioned", server("java", "latest"), "1.21.4", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.resource, &Status{Version: tt.reported})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWait(t *testing.T) {
+	resource := server("papermc", "1.21.4-232")
+	addr := serveJava(t, slpResponseJSON)
+	host, port, _ := net.SplitHostPort(addr)
+	resource.Spec.Server.Port, _ = strconv.Atoi(port)
+	status, err := Wait(t.Context(), resource, host, time.Second)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if status.Players != 2 {
+		t.Errorf("got %d players, want 2", status.Players)
+	}
+
+	resource.Spec.Minecraft.Version = "1.20.4"
+	addr = serveJava(t, slpResponseJSON)
+	_, port, _ = net.SplitHostPort(addr)
+	resource.Spec.Server.Port, _ = strconv.Atoi(port)
+	if _, err := Wait(t.Context(), resource, host, time.Second); err == nil || !strings.Contains(err.Error(), "requires 1.20.4") {
+		t.Errorf("Wait() error = %v, want a version mismatch", err)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	_ = listener.Close()
+
+	resource := server("java", "1.21.4")
+	resource.Spec.Server.Port = addr.Port
+	ctx, cancel := context.WithTimeout(t.Context(), 300*time.Millisecond)
+	defer cancel()
+	_, err = Wait(ctx, resource, "127.0.0.1", 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "did not answer") {
+		t.Errorf("Wait() error = %v, want the server did not answer", err)
+	}
+}
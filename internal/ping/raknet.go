@@ -0,0 +1,116 @@
+package ping
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RakNet packet IDs of the unconnected ping, see
+// https://minecraft.wiki/w/RakNet#Unconnected_Ping
+const (
+	unconnectedPing = 0x01
+	unconnectedPong = 0x1c
+)
+
+// offlineMessage is the magic every unconnected RakNet packet carries.
+var offlineMessage = []byte{0x00, 0xff, 0xff, 0x00, 0xfe, 0xfe, 0xfe, 0xfe, 0xfd, 0xfd, 0xfd, 0xfd, 0x12, 0x34, 0x56, 0x78}
+
+// resendInterval is the time after which a ping is sent again, as UDP
+// packets may get lost.
+const resendInterval = time.Second
+
+// Bedrock pings a Bedrock edition server via an unconnected RakNet ping.
+func Bedrock(ctx context.Context, addr string) (*Status, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to ping %s", addr)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	buf := make([]byte, 1500)
+	for {
+		start := time.Now()
+		if _, err := conn.Write(pingPacket(start)); err != nil {
+			return nil, errors.Wrapf(contextErr(ctx, err), "failed to ping %s", addr)
+		}
+		if ctx.Err() == nil {
+			_ = conn.SetReadDeadline(start.Add(resendInterval))
+		}
+		n, err := conn.Read(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+				continue
+			}
+			return nil, errors.Wrapf(contextErr(ctx, err), "failed to ping %s", addr)
+		}
+		status, err := parsePong(buf[:n])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid RakNet pong of %s", addr)
+		}
+		status.Latency = time.Since(start)
+		return status, nil
+	}
+}
+
+// contextErr prefers the error of a done context over the network error it caused.
+func contextErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func pingPacket(now time.Time) []byte {
+	packet := make([]byte, 0, 33)
+	packet = append(packet, unconnectedPing)
+	packet = binary.BigEndian.AppendUint64(packet, uint64(now.UnixMilli())) //nolint:gosec
+	packet = append(packet, offlineMessage...)
+	packet = binary.BigEndian.AppendUint64(packet, 0) // client GUID
+	return packet
+}
+
+// parsePong reads the server ID string of an unconnected pong, which looks like
+// MCPE;<motd>;<protocol>;<version>;<players>;<max players>;<server id>;<sub motd>;...
+func parsePong(packet []byte) (*Status, error) {
+	// ID, time, server GUID, magic and the length of the server ID string
+	const headerLength = 1 + 8 + 8 + 16 + 2
+	if len(packet) < headerLength || packet[0] != unconnectedPong {
+		return nil, errors.New("not an unconnected pong")
+	}
+	if !bytes.Equal(packet[17:33], offlineMessage) {
+		return nil, errors.New("wrong offline message")
+	}
+	length := int(binary.BigEndian.Uint16(packet[33:35]))
+	if len(packet) < headerLength+length {
+		return nil, errors.New("truncated server ID")
+	}
+	fields := strings.Split(string(packet[headerLength:headerLength+length]), ";")
+	if len(fields) < 6 {
+		return nil, errors.Errorf("server ID has %d fields, expected at least 6", len(fields))
+	}
+	status := &Status{MOTD: fields[1], Version: fields[3]}
+	var err error
+	if status.Protocol, err = strconv.Atoi(fields[2]); err != nil {
+		return nil, errors.Wrap(err, "invalid protocol")
+	}
+	if status.Players, err = strconv.Atoi(fields[4]); err != nil {
+		return nil, errors.Wrap(err, "invalid player count")
+	}
+	if status.MaxPlayers, err = strconv.Atoi(fields[5]); err != nil {
+		return nil, errors.Wrap(err, "invalid max player count")
+	}
+	return status, nil
+}
@@ -0,0 +1,228 @@
+// Package plugins resolves, downloads and verifies the plugins and mods
+// minectl installs on the servers.
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// ModType is the loader a plugin or mod is built for.
+type ModType string
+
+const (
+	Forge  ModType = "forge"
+	Fabric ModType = "fabric"
+	// Bukkit plugins run on CraftBukkit and its forks Spigot, Paper and Purpur.
+	Bukkit ModType = "bukkit"
+)
+
+// ModTypeOf returns the loader of the edition of a manifest.
+func ModTypeOf(edition string) (ModType, error) {
+	switch edition {
+	case "forge":
+		return Forge, nil
+	case "fabric":
+		return Fabric, nil
+	case "papermc", "purpur", "spigot", "craftbukkit":
+		return Bukkit, nil
+	}
+	return "", errors.Errorf("the %s edition does not support plugins or mods", edition)
+}
+
+// Destination returns the folder the loader reads the jars from.
+func (m ModType) Destination() string {
+	if m == Bukkit {
+		return "/minecraft/plugins"
+	}
+	return "/minecraft/mods"
+}
+
+// Plugin is an entry of the catalog.
+type Plugin struct {
+	Name string
+	Mod  ModType
+	// Version lists the releases, oldest first.
+	Version []Version
+	// DownloadURL is a text/template of the jar, rendered with .Version and .Minecraft.
+	DownloadURL string
+}
+
+// Version is a release of a plugin.
+type Version struct {
+	Version string
+	// Minecraft lists the supported Minecraft versions, a trailing .x matches all patch versions.
+	Minecraft []string
+	// SHA256 is the checksum of the jar. Versions without one are not resolved.
+	SHA256 string
+}
+
+// Release is a plugin resolved for a server.
+type Release struct {
+	// ID identifies the plugin in its source, Name is its human-readable name.
+	ID      string
+	Name    string
+	Mod     ModType
+	Version string
+	URL     string
+	// File is the name of the jar, by default the last element of URL.
+	File string
+	// SHA256 and SHA512 are the checksums of the jar, if the source knows them.
+	SHA256      string
+	SHA512      string
+	Destination string
+	// Dependencies are the IDs of the required plugins in the same source.
+	Dependencies []string
+	// Source and RequiredBy are set by ResolveSpecs.
+	Source     string
+	RequiredBy string
+}
+
+// FileName returns the name of the jar on the server.
+func (r *Release) FileName() string {
+	if r.File != "" {
+		return path.Base(r.File)
+	}
+	if u, err := url.Parse(r.URL); err == nil {
+		return path.Base(u.Path)
+	}
+	return path.Base(r.URL)
+}
+
+// Catalog is a list of plugins with templated download URLs.
+type Catalog []Plugin
+
+// DefaultCatalog holds the plugins minectl plugins install knows by name.
+// Every version has to pin the SHA256 of its jar, as the download URLs are
+// not verified otherwise. Versions whose SHA256 is not pinned yet are
+// listed, but Resolve rejects them.
+var DefaultCatalog = Catalog{
+	{
+		Name: "fabric-api",
+		Mod:  Fabric,
+		Version: []Version{
+			{Version: "0.37.1+1.16", Minecraft: []string{"1.16.5"}},
+			{Version: "0.37.1+1.17", Minecraft: []string{"1.17", "1.17.1"}},
+			{Version: "0.92.2+1.20.1", Minecraft: []string{"1.20.1"}},
+			{Version: "0.102.0+1.21", Minecraft: []string{"1.21"}},
+		},
+		DownloadURL: "https://github.com/FabricMC/fabric/releases/download/{{ .Version }}/fabric-api-{{ .Version }}.jar",
+	},
+	{
+		Name: "essentialsx",
+		Mod:  Bukkit,
+		Version: []Version{
+			{Version: "2.20.1", Minecraft: []string{"1.8.8", "1.9.x", "1.10.x", "1.11.x", "1.12.x", "1.13.x", "1.14.x", "1.15.x", "1.16.x", "1.17.x", "1.18.x", "1.19.x", "1.20.x"}},
+		},
+		DownloadURL: "https://github.com/EssentialsX/Essentials/releases/download/{{ .Version }}/EssentialsX-{{ .Version }}.jar",
+	},
+}
+
+// Find returns the plugin with the name.
+func (c Catalog) Find(name string) (*Plugin, error) {
+	i := slices.IndexFunc(c, func(p Plugin) bool {
+		return strings.EqualFold(p.Name, name)
+	})
+	if i < 0 && len(c) == 0 {
+		return nil, errors.Errorf("unknown plugin %q, the catalog is empty", name)
+	}
+	if i < 0 {
+		names := make([]string, len(c))
+		for i, p := range c {
+			names[i] = p.Name
+		}
+		return nil, errors.Errorf("unknown plugin %q, the catalog contains %s", name, strings.Join(names, ", "))
+	}
+	return &c[i], nil
+}
+
+func (c Catalog) String() string {
+	return "catalog"
+}
+
+// Search returns the plugins whose name contains the term and which support the target.
+func (c Catalog) Search(_ context.Context, term string, target Target) ([]Project, error) {
+	var projects []Project
+	for _, plugin := range c {
+		if plugin.Mod != target.Mod || !strings.Contains(strings.ToLower(plugin.Name), strings.ToLower(term)) {
+			continue
+		}
+		if !slices.ContainsFunc(plugin.Version, func(v Version) bool { return supports(v.Minecraft, target.Minecraft) }) {
+			continue
+		}
+		projects = append(projects, Project{Name: plugin.Name, Title: plugin.Name, Source: c.String()})
+	}
+	return projects, nil
+}
+
+// Resolve returns the newest release of the plugin which supports the target,
+// or the given version when it is not empty.
+func (c Catalog) Resolve(_ context.Context, name, version string, target Target) (*Release, error) {
+	plugin, err := c.Find(name)
+	if err != nil {
+		return nil, err
+	}
+	mod, minecraft := target.Mod, target.Minecraft
+	if plugin.Mod != mod {
+		return nil, errors.Errorf("%s is a %s plugin, the server runs %s plugins", plugin.Name, plugin.Mod, mod)
+	}
+	for i := len(plugin.Version) - 1; i >= 0; i-- {
+		v := plugin.Version[i]
+		if version != "" && v.Version != version {
+			continue
+		}
+		if !supports(v.Minecraft, minecraft) {
+			if version != "" {
+				return nil, errors.Errorf("%s %s does not support Minecraft %s", plugin.Name, version, minecraft)
+			}
+			continue
+		}
+		if v.SHA256 == "" {
+			return nil, errors.Errorf("%s %s has no SHA256 checksum in the catalog", plugin.Name, v.Version)
+		}
+		downloadURL, err := render(plugin.DownloadURL, v.Version, minecraft)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid download URL of %s", plugin.Name)
+		}
+		return &Release{
+			ID:          plugin.Name,
+			Name:        plugin.Name,
+			Mod:         plugin.Mod,
+			Version:     v.Version,
+			URL:         downloadURL,
+			SHA256:      v.SHA256,
+			Destination: mod.Destination(),
+		}, nil
+	}
+	if version != "" {
+		return nil, errors.Errorf("unknown version %s of %s", version, plugin.Name)
+	}
+	return nil, errors.Errorf("no version of %s supports Minecraft %s", plugin.Name, minecraft)
+}
+
+// supports returns true when one of the patterns matches the Minecraft version.
+func supports(patterns []string, minecraft string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		if prefix, ok := strings.CutSuffix(pattern, ".x"); ok {
+			return minecraft == prefix || strings.HasPrefix(minecraft, prefix+".")
+		}
+		return minecraft == pattern
+	})
+}
+
+func render(downloadURL, version, minecraft string) (string, error) {
+	tmpl, err := template.New("url").Parse(downloadURL)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, struct{ Version, Minecraft string }{version, minecraft})
+	return b.String(), err
+}
@@ -0,0 +1,139 @@
+package plugins
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	catalog := Catalog{
+		{
+			Name: "fabric-api",
+			Mod:  Fabric,
+			Version: []Version{
+				{Version: "0.92.2+1.20.1", Minecraft: []string{"1.20.1"}, SHA256: "abc"},
+				{Version: "0.100.0+1.21", Minecraft: []string{"1.21.x"}, SHA256: "def"},
+				{Version: "0.102.0+1.21", Minecraft: []string{"1.21.x"}, SHA256: "abc"},
+			},
+			DownloadURL: "https://example.com/{{ .Version }}/fabric-api-{{ .Version }}.jar?mc={{ .Minecraft }}",
+		},
+		{Name: "broken", Mod: Bukkit, Version: []Version{{Version: "1", Minecraft: []string{"1.21"}, SHA256: "abc"}}, DownloadURL: "{{ .Missing"},
+		{Name: "unpinned", Mod: Bukkit, Version: []Version{{Version: "1", Minecraft: []string{"1.21"}}}, DownloadURL: "https://example.com/unpinned.jar"},
+	}
+	tests := []struct {
+		name      string
+		plugin    string
+		version   string
+		edition   string
+		minecraft string
+		want      Release
+		wantErr   bool
+	}{
+		{
+			name: "newest matching", plugin: "fabric-api", edition: "fabric", minecraft: "1.21.4",
+			want: Release{ID: "fabric-api", Name: "fabric-api", Mod: Fabric, Version: "0.102.0+1.21", URL: "https://example.com/0.102.0+1.21/fabric-api-0.102.0+1.21.jar?mc=1.21.4", SHA256: "abc", Destination: "/minecraft/mods"},
+		},
+		{
+			name: "exact minecraft version", plugin: "Fabric-API", edition: "fabric", minecraft: "1.20.1",
+			want: Release{ID: "fabric-api", Name: "fabric-api", Mod: Fabric, Version: "0.92.2+1.20.1", URL: "https://example.com/0.92.2+1.20.1/fabric-api-0.92.2+1.20.1.jar?mc=1.20.1", SHA256: "abc", Destination: "/minecraft/mods"},
+		},
+		{
+			name: "pinned version", plugin: "fabric-api", version: "0.100.0+1.21", edition: "fabric", minecraft: "1.21",
+			want: Release{ID: "fabric-api", Name: "fabric-api", Mod: Fabric, Version: "0.100.0+1.21", URL: "https://example.com/0.100.0+1.21/fabric-api-0.100.0+1.21.jar?mc=1.21", SHA256: "def", Destination: "/minecraft/mods"},
+		},
+		{name: "pinned version of another minecraft version", plugin: "fabric-api", version: "0.92.2+1.20.1", edition: "fabric", minecraft: "1.21", wantErr: true},
+		{name: "unknown version", plugin: "fabric-api", version: "1.0.0", edition: "fabric", minecraft: "1.21", wantErr: true},
+		{name: "unsupported minecraft version", plugin: "fabric-api", edition: "fabric", minecraft: "1.20.4", wantErr: true},
+		{name: "wrong loader", plugin: "fabric-api", edition: "papermc", minecraft: "1.21", wantErr: true},
+		{name: "vanilla", plugin: "fabric-api", edition: "java", minecraft: "1.21", wantErr: true},
+		{name: "unknown plugin", plugin: "worldedit", edition: "fabric", minecraft: "1.21", wantErr: true},
+		{name: "invalid template", plugin: "broken", edition: "spigot", minecraft: "1.21", wantErr: true},
+		{name: "no checksum", plugin: "unpinned", edition: "spigot", minecraft: "1.21", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := TargetOf(tt.edition, tt.minecraft)
+			if err == nil {
+				var got *Release
+				got, err = catalog.Resolve(t.Context(), tt.plugin, tt.version, target)
+				if err == nil && !reflect.DeepEqual(*got, tt.want) {
+					t.Errorf("Resolve() = %+v, want %+v", *got, tt.want)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultCatalog(t *testing.T) {
+	for _, plugin := range DefaultCatalog {
+		for _, v := range plugin.Version {
+			t.Run(plugin.Name+" "+v.Version, func(t *testing.T) {
+				if v.SHA256 == "" {
+					t.Skipf("%s %s pins no SHA256 yet", plugin.Name, v.Version)
+				}
+				release, err := DefaultCatalog.Resolve(t.Context(), plugin.Name, v.Version, Target{Mod: plugin.Mod, Minecraft: v.Minecraft[0]})
+				if err != nil {
+					t.Fatalf("Resolve(%s, %s) error = %v", plugin.Name, v.Version, err)
+				}
+				if release.FileName() == "" || release.FileName() == "." {
+					t.Errorf("release %+v has no file name", release)
+				}
+			})
+		}
+	}
+}
+
+func TestDefaultCatalogFabricAPI(t *testing.T) {
+	target, err := TargetOf("fabric", "1.21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugin, err := DefaultCatalog.Find("fabric-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := plugin.Version[len(plugin.Version)-1]; v.SHA256 == "" {
+		t.Skipf("fabric-api %s pins no SHA256 yet", v.Version)
+	}
+	release, err := DefaultCatalog.Resolve(t.Context(), "fabric-api", "", target)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	want := "https://github.com/FabricMC/fabric/releases/download/0.102.0+1.21/fabric-api-0.102.0+1.21.jar"
+	if release.Version != "0.102.0+1.21" || release.URL != want || release.Destination != "/minecraft/mods" {
+		t.Errorf("Resolve() = %+v, want 0.102.0+1.21 from %s", release, want)
+	}
+	if release.FileName() != "fabric-api-0.102.0+1.21.jar" {
+		t.Errorf("FileName() = %s", release.FileName())
+	}
+}
+
+func TestCatalogSearch(t *testing.T) {
+	catalog := Catalog{
+		{Name: "fabric-api", Mod: Fabric, Version: []Version{{Version: "0.92.2+1.20.1", Minecraft: []string{"1.20.1"}}, {Version: "0.102.0+1.21", Minecraft: []string{"1.21"}}}},
+		{Name: "essentialsx", Mod: Bukkit, Version: []Version{{Version: "2.20.1", Minecraft: []string{"1.8.8", "1.20.x"}}}},
+	}
+	tests := []struct {
+		term      string
+		mod       ModType
+		minecraft string
+		want      int
+	}{
+		{term: "fabric", mod: Fabric, minecraft: "1.20.1", want: 1},
+		{term: "API", mod: Fabric, minecraft: "1.21", want: 1},
+		{term: "fabric", mod: Fabric, minecraft: "1.19.2"},
+		{term: "fabric", mod: Bukkit, minecraft: "1.20.1"},
+		{term: "essentials", mod: Bukkit, minecraft: "1.20.4", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.term+" "+tt.minecraft, func(t *testing.T) {
+			got, err := catalog.Search(t.Context(), tt.term, Target{Mod: tt.mod, Minecraft: tt.minecraft})
+			if err != nil || len(got) != tt.want {
+				t.Errorf("Search() = %v, %v, want %d projects", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package plugins
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// metadataFiles are the files a jar of the loader contains.
+var metadataFiles = map[ModType][]string{
+	Fabric: {"fabric.mod.json"},
+	Forge:  {"META-INF/mods.toml", "META-INF/neoforge.mods.toml", "mcmod.info"},
+	Bukkit: {"plugin.yml", "paper-plugin.yml"},
+}
+
+// Download stores the jar of the release in dir and verifies it: the
+// checksums, when the release has them, and that the jar is a plugin of its
+// loader. progress receives the downloaded bytes. It returns the path of the jar.
+func Download(ctx context.Context, client *http.Client, release *Release, dir string, progress io.Writer) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, release.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to download %s", release.Name)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("failed to download %s from %s: %s", release.Name, release.URL, resp.Status)
+	}
+
+	jar := filepath.Join(dir, release.FileName())
+	file, err := os.Create(jar)
+	if err != nil {
+		return "", err
+	}
+	sha256sum, sha512sum := sha256.New(), sha512.New()
+	var body io.Reader = resp.Body
+	if progress != nil {
+		body = io.TeeReader(body, progress)
+	}
+	if _, err := io.Copy(io.MultiWriter(file, sha256sum, sha512sum), body); err != nil {
+		_ = file.Close()
+		return "", errors.Wrapf(err, "failed to download %s", release.Name)
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	if sum := hex.EncodeToString(sha256sum.Sum(nil)); release.SHA256 != "" && sum != release.SHA256 {
+		return "", errors.Errorf("the SHA-256 checksum of %s is %s, want %s", release.FileName(), sum, release.SHA256)
+	}
+	if sum := hex.EncodeToString(sha512sum.Sum(nil)); release.SHA512 != "" && sum != release.SHA512 {
+		return "", errors.Errorf("the SHA-512 checksum of %s is %s, want %s", release.FileName(), sum, release.SHA512)
+	}
+	return jar, Verify(jar, release.Mod)
+}
+
+// Verify checks that the jar is a plugin of the loader.
+func Verify(jar string, mod ModType) error {
+	r, err := zip.OpenReader(jar)
+	if err != nil {
+		return errors.Wrapf(err, "%s is not a jar", filepath.Base(jar))
+	}
+	defer r.Close()
+	for _, name := range metadataFiles[mod] {
+		if _, err := r.Open(name); err == nil {
+			return nil
+		}
+	}
+	return errors.Errorf("%s is not a %s plugin, it contains none of %v", filepath.Base(jar), mod, metadataFiles[mod])
+}
@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testJar returns a jar containing the files.
+func testJar(t *testing.T, files ...string) []byte {
+	t.Helper()
+	content := make(map[string]string, len(files))
+	for _, name := range files {
+		content[name] = "{}"
+	}
+	return testJarContent(t, content)
+}
+
+// testJarContent returns a jar containing the files with their content.
+func testJarContent(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestDownload(t *testing.T) {
+	fabric := testJar(t, "fabric.mod.json")
+	sum := sha256.Sum256(fabric)
+	files := map[string][]byte{
+		"/fabric.jar": fabric,
+		"/bukkit.jar": testJar(t, "plugin.yml"),
+		"/text.jar":   []byte("not a jar"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		sha256  string
+		wantErr bool
+	}{
+		{name: "verified", path: "/fabric.jar", sha256: hex.EncodeToString(sum[:])},
+		{name: "without checksum", path: "/fabric.jar"},
+		{name: "checksum mismatch", path: "/fabric.jar", sha256: "0000", wantErr: true},
+		{name: "other loader", path: "/bukkit.jar", wantErr: true},
+		{name: "no jar", path: "/text.jar", wantErr: true},
+		{name: "not found", path: "/missing.jar", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var progress bytes.Buffer
+			release := &Release{Name: "test", Mod: Fabric, URL: server.URL + tt.path, SHA256: tt.sha256}
+			jar, err := Download(t.Context(), server.Client(), release, t.TempDir(), &progress)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Download() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && progress.Len() != len(files[tt.path]) {
+				t.Errorf("progress got %d bytes of %s, want %d", progress.Len(), jar, len(files[tt.path]))
+			}
+		})
+	}
+}
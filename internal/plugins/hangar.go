@@ -0,0 +1,145 @@
+package plugins
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HangarURL is the base URL of the Hangar API.
+const HangarURL = "https://hangar.papermc.io/api/v1"
+
+// hangarPlatform is the platform of Paper plugins in Hangar.
+const hangarPlatform = "PAPER"
+
+// Hangar resolves Paper plugins via the API of hangar.papermc.io, see
+// https://hangar.papermc.io/api-docs
+type Hangar struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewHangar returns a Hangar source. An empty baseURL uses HangarURL.
+func NewHangar(client *http.Client, baseURL string) *Hangar {
+	if baseURL == "" {
+		baseURL = HangarURL
+	}
+	return &Hangar{client: httpClient(client), baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (h *Hangar) String() string {
+	return "hangar"
+}
+
+type hangarProjects struct {
+	Result []struct {
+		Name      string `json:"name"`
+		Namespace struct {
+			Slug string `json:"slug"`
+		} `json:"namespace"`
+		Description string `json:"description"`
+		Stats       struct {
+			Downloads int64 `json:"downloads"`
+		} `json:"stats"`
+	} `json:"result"`
+}
+
+type hangarVersions struct {
+	Result []struct {
+		Name      string `json:"name"`
+		Downloads map[string]struct {
+			FileInfo *struct {
+				Name       string `json:"name"`
+				SHA256Hash string `json:"sha256Hash"`
+			} `json:"fileInfo"`
+			ExternalURL string `json:"externalUrl"`
+			DownloadURL string `json:"downloadUrl"`
+		} `json:"downloads"`
+		PluginDependencies map[string][]struct {
+			Name        string `json:"name"`
+			Required    bool   `json:"required"`
+			ExternalURL string `json:"externalUrl"`
+		} `json:"pluginDependencies"`
+	} `json:"result"`
+}
+
+// check fails for targets which do not run Paper plugins.
+func (h *Hangar) check(target Target) error {
+	if !slices.Contains(target.Loaders, "paper") {
+		return errors.Errorf("Hangar only hosts Paper plugins, the server runs %s", strings.Join(target.Loaders, ", "))
+	}
+	return nil
+}
+
+// Search returns the Paper plugins matching the term for the Minecraft version of the target.
+func (h *Hangar) Search(ctx context.Context, term string, target Target) ([]Project, error) {
+	if err := h.check(target); err != nil {
+		return nil, err
+	}
+	query := url.Values{"q": {term}, "platform": {hangarPlatform}, "version": {target.Minecraft}, "limit": {"20"}}
+	var result hangarProjects
+	if err := getJSON(ctx, h.client, h.baseURL+"/projects?"+query.Encode(), &result); err != nil {
+		return nil, errors.Wrap(err, "failed to search Hangar")
+	}
+	projects := make([]Project, 0, len(result.Result))
+	for _, p := range result.Result {
+		projects = append(projects, Project{Name: p.Namespace.Slug, Title: p.Name, Description: p.Description, Downloads: p.Stats.Downloads, Source: h.String()})
+	}
+	return projects, nil
+}
+
+// Resolve returns the newest version of the plugin for the Minecraft version of the target.
+func (h *Hangar) Resolve(ctx context.Context, name, version string, target Target) (*Release, error) {
+	if err := h.check(target); err != nil {
+		return nil, err
+	}
+	query := url.Values{"platform": {hangarPlatform}, "platformVersion": {target.Minecraft}, "limit": {"25"}}
+	var result hangarVersions
+	if err := getJSON(ctx, h.client, h.baseURL+"/projects/"+url.PathEscape(name)+"/versions?"+query.Encode(), &result); err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %s on Hangar", name)
+	}
+	// the versions are sorted by date, newest first
+	for _, v := range result.Result {
+		if version != "" && v.Name != version {
+			continue
+		}
+		download, ok := v.Downloads[hangarPlatform]
+		if !ok {
+			continue
+		}
+		release := &Release{
+			ID:          name,
+			Name:        name,
+			Mod:         target.Mod,
+			Version:     v.Name,
+			URL:         download.DownloadURL,
+			Destination: target.Mod.Destination(),
+		}
+		if release.URL == "" {
+			release.URL = download.ExternalURL
+		}
+		if download.FileInfo != nil {
+			release.File = download.FileInfo.Name
+			release.SHA256 = download.FileInfo.SHA256Hash
+		}
+		for _, dependency := range v.PluginDependencies[hangarPlatform] {
+			if !dependency.Required {
+				continue
+			}
+			// dependencies hosted outside Hangar can not be resolved
+			if dependency.ExternalURL != "" {
+				return nil, errors.Errorf("%s requires %s, which is not hosted on Hangar, please install it from %s", name, dependency.Name, dependency.ExternalURL)
+			}
+			release.Dependencies = append(release.Dependencies, dependency.Name)
+		}
+		return release, nil
+	}
+	if version != "" {
+		return nil, errors.Errorf("%s %s does not support Minecraft %s", name, version, target.Minecraft)
+	}
+	return nil, errors.Errorf("no version of %s supports Minecraft %s", name, target.Minecraft)
+}
@@ -0,0 +1,192 @@
+package plugins
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// LockFile is the name of the lockfile next to the manifest.
+	LockFile = "minectl.lock"
+	// SourceURL is the source of plugins downloaded from the URL of their spec.
+	SourceURL = "url"
+)
+
+const lockHeader = "# Generated by minectl plugins sync, do not edit.\n"
+
+// Spec is a plugin in the plugins section of a manifest.
+type Spec struct {
+	Name string `json:"name"`
+	// Source defaults to url when URL is set, otherwise to the catalog when it
+	// contains the plugin and to modrinth for all others.
+	Source  string `json:"source,omitempty"`
+	Version string `json:"version,omitempty"`
+	URL     string `json:"url,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+}
+
+// SourceFor returns the source of the spec.
+func SourceFor(sources map[string]Source, spec Spec) (Source, error) {
+	name := spec.Source
+	switch {
+	case name == "" && spec.URL != "":
+		return nil, nil
+	case name == "":
+		name = "modrinth"
+		if catalog, ok := sources["catalog"].(Catalog); ok {
+			if _, err := catalog.Find(spec.Name); err == nil {
+				name = "catalog"
+			}
+		}
+	case name == SourceURL:
+		return nil, nil
+	}
+	source, ok := sources[name]
+	if !ok {
+		return nil, errors.Errorf("unknown plugin source %q of %s, please use catalog, modrinth, hangar or url", name, spec.Name)
+	}
+	return source, nil
+}
+
+// Lock pins the plugins of the servers of the manifests in a folder.
+type Lock struct {
+	Servers map[string]*LockedServer `yaml:"servers"`
+}
+
+// LockedServer holds the resolved plugins of a server.
+type LockedServer struct {
+	Mod       ModType `yaml:"loader"`
+	Minecraft string  `yaml:"minecraft"`
+	// Specs is the checksum of the plugins section and the target the plugins were resolved for.
+	Specs   string         `yaml:"specs"`
+	Plugins []LockedPlugin `yaml:"plugins"`
+}
+
+// LockedPlugin is a jar pinned by its checksum.
+type LockedPlugin struct {
+	Name        string `yaml:"name"`
+	Source      string `yaml:"source"`
+	Version     string `yaml:"version,omitempty"`
+	URL         string `yaml:"url"`
+	File        string `yaml:"file"`
+	SHA256      string `yaml:"sha256"`
+	Destination string `yaml:"destination"`
+	// RequiredBy is the plugin of the manifest which requires this dependency.
+	RequiredBy string `yaml:"requiredBy,omitempty"`
+}
+
+// Path returns the path of the jar on the server.
+func (p *LockedPlugin) Path() string {
+	return path.Join(p.Destination, p.File)
+}
+
+// Release returns the release of the jar, verified by the pinned checksum.
+func (p *LockedPlugin) Release(mod ModType) *Release {
+	return &Release{ID: p.Name, Name: p.Name, Mod: mod, Version: p.Version, URL: p.URL, File: p.File, SHA256: p.SHA256, Destination: p.Destination}
+}
+
+// LockPath returns the path of the lockfile of a manifest file.
+func LockPath(manifest string) string {
+	return filepath.Join(filepath.Dir(manifest), LockFile)
+}
+
+// ReadLock reads the lockfile. A missing file results in an empty lock.
+func ReadLock(path string) (*Lock, error) {
+	lock := &Lock{Servers: map[string]*LockedServer{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lock, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the lockfile %s", path)
+	}
+	if lock.Servers == nil {
+		lock.Servers = map[string]*LockedServer{}
+	}
+	return lock, nil
+}
+
+// Write stores the lockfile.
+func (l *Lock) Write(path string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(lockHeader), data...), 0o644)
+}
+
+// SpecsHash returns the checksum of the specs and the target, which changes
+// when the plugins have to be resolved again.
+func SpecsHash(specs []Spec, target Target) string {
+	data, _ := json.Marshal(struct {
+		Specs     []Spec
+		Mod       ModType
+		Minecraft string
+	}{specs, target.Mod, target.Minecraft})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ResolveSpecs resolves the plugins of a manifest with their required
+// dependencies. Every plugin is returned once, with the source it was resolved from.
+func ResolveSpecs(ctx context.Context, sources map[string]Source, specs []Spec, target Target) ([]*Release, error) {
+	var releases []*Release
+	for _, spec := range specs {
+		source, err := SourceFor(sources, spec)
+		if err != nil {
+			return nil, err
+		}
+		if source == nil {
+			if spec.URL == "" {
+				return nil, errors.Errorf("the plugin %s has no url", spec.Name)
+			}
+			releases = append(releases, &Release{ID: spec.Name, Name: spec.Name, Source: SourceURL, Mod: target.Mod, Version: spec.Version,
+				URL: spec.URL, SHA256: spec.SHA256, Destination: target.Mod.Destination()})
+			continue
+		}
+		resolved, err := ResolveAll(ctx, source, spec.Name, spec.Version, target)
+		if err != nil {
+			return nil, err
+		}
+		for i, release := range resolved {
+			release.Source = source.String()
+			if i > 0 {
+				release.RequiredBy = spec.Name
+			}
+			if spec.SHA256 != "" && i == 0 {
+				release.SHA256 = spec.SHA256
+			}
+			if !slices.ContainsFunc(releases, func(r *Release) bool { return r.Source == release.Source && r.ID == release.ID }) {
+				releases = append(releases, release)
+			}
+		}
+	}
+	return releases, nil
+}
+
+// Checksum returns the hex encoded SHA-256 checksum of the file.
+func Checksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveSpecs(t *testing.T) {
+	sources := map[string]Source{"catalog": Catalog{
+		{Name: "fabric-api", Mod: Fabric, Version: []Version{{Version: "0.110.0", Minecraft: []string{"1.21.x"}, SHA256: "def"}}, DownloadURL: "https://example.com/fabric-api-{{ .Version }}.jar"},
+	}}
+	target, err := TargetOf("fabric", "1.21.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		specs   []Spec
+		want    []Release
+		wantErr bool
+	}{
+		{
+			name:  "catalog and url",
+			specs: []Spec{{Name: "fabric-api", SHA256: "abc"}, {Name: "chunky", URL: "https://example.com/chunky.jar"}},
+			want: []Release{
+				{ID: "fabric-api", Name: "fabric-api", Source: "catalog", Mod: Fabric, Version: "0.110.0", URL: "https://example.com/fabric-api-0.110.0.jar", SHA256: "abc", Destination: "/minecraft/mods"},
+				{ID: "chunky", Name: "chunky", Source: SourceURL, Mod: Fabric, URL: "https://example.com/chunky.jar", Destination: "/minecraft/mods"},
+			},
+		},
+		{name: "url source without url", specs: []Spec{{Name: "chunky", Source: SourceURL}}, wantErr: true},
+		{name: "unknown source", specs: []Spec{{Name: "chunky", Source: "curseforge"}}, wantErr: true},
+		{name: "unknown plugin", specs: []Spec{{Name: "worldedit", Source: "catalog"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releases, err := ResolveSpecs(t.Context(), sources, tt.specs, target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveSpecs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []Release
+			for _, release := range releases {
+				got = append(got, *release)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveSpecs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := LockPath(filepath.Join(t.TempDir(), "server.yaml"))
+	lock, err := ReadLock(path)
+	if err != nil || len(lock.Servers) != 0 {
+		t.Fatalf("ReadLock() of a missing file = %+v, %v, want an empty lock", lock, err)
+	}
+	target := Target{Mod: Fabric, Minecraft: "1.21.4"}
+	specs := []Spec{{Name: "lithium"}}
+	lock.Servers["fabric-server"] = &LockedServer{Mod: Fabric, Minecraft: "1.21.4", Specs: SpecsHash(specs, target), Plugins: []LockedPlugin{
+		{Name: "lithium", Source: "modrinth", Version: "0.14.0", URL: "https://example.com/lithium.jar", File: "lithium.jar", SHA256: "abc", Destination: "/minecraft/mods"},
+	}}
+	if err := lock.Write(path); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got, err := ReadLock(path)
+	if err != nil {
+		t.Fatalf("ReadLock() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, lock) {
+		t.Errorf("ReadLock() = %+v, want %+v", got, lock)
+	}
+	if path := got.Servers["fabric-server"].Plugins[0].Path(); path != "/minecraft/mods/lithium.jar" {
+		t.Errorf("Path() = %s", path)
+	}
+
+	hash := SpecsHash(specs, target)
+	for _, changed := range []string{
+		SpecsHash([]Spec{{Name: "lithium", Version: "0.15.0"}}, target),
+		SpecsHash(specs, Target{Mod: Fabric, Minecraft: "1.21.5"}),
+	} {
+		if changed == hash {
+			t.Error("SpecsHash() did not change with the specs or the target")
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package plugins
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// jarVersion is the placeholder of Forge for the version in the manifest of the jar.
+const jarVersion = "${file.jarVersion}"
+
+// Metadata is the name and version a jar declares for its loader.
+type Metadata struct {
+	// ID is the mod ID of Fabric and Forge mods, the name of Bukkit plugins.
+	ID      string
+	Name    string
+	Version string
+	Mod     ModType
+}
+
+// ReadMetadata reads the metadata of a plugin or mod from its jar:
+// plugin.yml, fabric.mod.json, mods.toml or mcmod.info.
+func ReadMetadata(r io.ReaderAt, size int64) (*Metadata, error) {
+	jar, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.Wrap(err, "not a jar")
+	}
+	readers := []struct {
+		file string
+		read func(data []byte) (*Metadata, error)
+	}{
+		{"fabric.mod.json", readFabric},
+		{"META-INF/mods.toml", readModsTOML},
+		{"META-INF/neoforge.mods.toml", readModsTOML},
+		{"mcmod.info", readMcmodInfo},
+		{"plugin.yml", readPluginYAML},
+		{"paper-plugin.yml", readPluginYAML},
+	}
+	for _, reader := range readers {
+		data, err := readZipFile(jar, reader.file)
+		if err != nil {
+			continue
+		}
+		metadata, err := reader.read(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", reader.file)
+		}
+		if metadata.Name == "" {
+			metadata.Name = metadata.ID
+		}
+		if metadata.Version == jarVersion {
+			metadata.Version = implementationVersion(jar)
+		}
+		return metadata, nil
+	}
+	return nil, errors.New("the jar contains no plugin.yml, fabric.mod.json, mods.toml or mcmod.info")
+}
+
+func readZipFile(jar *zip.Reader, name string) ([]byte, error) {
+	f, err := jar.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func readFabric(data []byte) (*Metadata, error) {
+	var mod struct {
+		ID      string `json:"id"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &mod); err != nil {
+		return nil, err
+	}
+	return &Metadata{ID: mod.ID, Name: mod.Name, Version: mod.Version, Mod: Fabric}, nil
+}
+
+func readMcmodInfo(data []byte) (*Metadata, error) {
+	var mods []struct {
+		ID      string `json:"modid"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &mods); err != nil {
+		return nil, err
+	}
+	if len(mods) == 0 {
+		return nil, errors.New("no mods declared")
+	}
+	return &Metadata{ID: mods[0].ID, Name: mods[0].Name, Version: mods[0].Version, Mod: Forge}, nil
+}
+
+func readPluginYAML(data []byte) (*Metadata, error) {
+	var plugin struct {
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &plugin); err != nil {
+		return nil, err
+	}
+	return &Metadata{ID: plugin.Name, Name: plugin.Name, Version: plugin.Version, Mod: Bukkit}, nil
+}
+
+// readModsTOML reads the first mod of the [[mods]] tables. Only the quoted
+// string keys minectl needs are parsed.
+func readModsTOML(data []byte) (*Metadata, error) {
+	metadata := &Metadata{Mod: Forge}
+	inMods := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			if inMods {
+				break
+			}
+			inMods = line == "[[mods]]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inMods || !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) < 2 || value[0] != '"' {
+			continue
+		}
+		value = value[1:]
+		if end := strings.IndexByte(value, '"'); end >= 0 {
+			value = value[:end]
+		}
+		switch strings.TrimSpace(key) {
+		case "modId":
+			metadata.ID = value
+		case "displayName":
+			metadata.Name = value
+		case "version":
+			metadata.Version = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if metadata.ID == "" {
+		return nil, errors.New("no mods declared")
+	}
+	return metadata, nil
+}
+
+// implementationVersion returns the Implementation-Version of the manifest of the jar.
+func implementationVersion(jar *zip.Reader) string {
+	data, err := readZipFile(jar, "META-INF/MANIFEST.MF")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "Implementation-Version:"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
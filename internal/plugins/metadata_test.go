@@ -0,0 +1,67 @@
+package plugins
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestReadMetadata(t *testing.T) {
+	modsTOML := `modLoader="javafml"
+loaderVersion="[47,)"
+
+[[mods]]
+modId="jei" # the ID
+version="${file.jarVersion}"
+displayName="Just Enough Items"
+
+[[dependencies.jei]]
+modId="forge"
+`
+	tests := []struct {
+		name    string
+		jar     []byte
+		want    *Metadata
+		wantErr bool
+	}{
+		{
+			name: "fabric",
+			jar:  testJarContent(t, map[string]string{"fabric.mod.json": `{"id": "lithium", "name": "Lithium", "version": "0.14.0"}`}),
+			want: &Metadata{ID: "lithium", Name: "Lithium", Version: "0.14.0", Mod: Fabric},
+		},
+		{
+			name: "forge with the version of the jar",
+			jar:  testJarContent(t, map[string]string{"META-INF/mods.toml": modsTOML, "META-INF/MANIFEST.MF": "Manifest-Version: 1.0\r\nImplementation-Version: 15.2.0\r\n"}),
+			want: &Metadata{ID: "jei", Name: "Just Enough Items", Version: "15.2.0", Mod: Forge},
+		},
+		{
+			name: "neoforge",
+			jar:  testJarContent(t, map[string]string{"META-INF/neoforge.mods.toml": "[[mods]]\nmodId = \"ferritecore\"\nversion = \"7.0.0\"\n"}),
+			want: &Metadata{ID: "ferritecore", Name: "ferritecore", Version: "7.0.0", Mod: Forge},
+		},
+		{
+			name: "legacy forge",
+			jar:  testJarContent(t, map[string]string{"mcmod.info": `[{"modid": "journeymap", "name": "JourneyMap", "version": "5.7.1"}]`}),
+			want: &Metadata{ID: "journeymap", Name: "JourneyMap", Version: "5.7.1", Mod: Forge},
+		},
+		{
+			name: "bukkit with a numeric version",
+			jar:  testJarContent(t, map[string]string{"plugin.yml": "name: Essentials\nversion: 2.2\nmain: com.earth2me.essentials.Essentials\n"}),
+			want: &Metadata{ID: "Essentials", Name: "Essentials", Version: "2.2", Mod: Bukkit},
+		},
+		{name: "without metadata", jar: testJarContent(t, map[string]string{"README.md": "hello"}), wantErr: true},
+		{name: "invalid metadata", jar: testJarContent(t, map[string]string{"fabric.mod.json": "{"}), wantErr: true},
+		{name: "not a jar", jar: []byte("not a jar"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadMetadata(bytes.NewReader(tt.jar), int64(len(tt.jar)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
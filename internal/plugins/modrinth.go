@@ -0,0 +1,167 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ModrinthURL is the base URL of the Modrinth API.
+const ModrinthURL = "https://api.modrinth.com/v2"
+
+// Modrinth resolves plugins and mods via the API of modrinth.com, see
+// https://docs.modrinth.com/api/
+type Modrinth struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewModrinth returns a Modrinth source. An empty baseURL uses ModrinthURL.
+func NewModrinth(client *http.Client, baseURL string) *Modrinth {
+	if baseURL == "" {
+		baseURL = ModrinthURL
+	}
+	return &Modrinth{client: httpClient(client), baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (m *Modrinth) String() string {
+	return "modrinth"
+}
+
+type modrinthSearch struct {
+	Hits []struct {
+		Slug        string `json:"slug"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Downloads   int64  `json:"downloads"`
+	} `json:"hits"`
+}
+
+type modrinthProject struct {
+	ID   string `json:"id"`
+	Slug string `json:"slug"`
+}
+
+type modrinthVersion struct {
+	VersionNumber string `json:"version_number"`
+	Files         []struct {
+		URL      string `json:"url"`
+		Filename string `json:"filename"`
+		Primary  bool   `json:"primary"`
+		Hashes   struct {
+			SHA512 string `json:"sha512"`
+		} `json:"hashes"`
+	} `json:"files"`
+	Dependencies []struct {
+		ProjectID      string `json:"project_id"`
+		DependencyType string `json:"dependency_type"`
+	} `json:"dependencies"`
+}
+
+// Search returns the projects matching the term for the loaders and Minecraft version of the target.
+func (m *Modrinth) Search(ctx context.Context, term string, target Target) ([]Project, error) {
+	loaders := make([]string, len(target.Loaders))
+	for i, loader := range target.Loaders {
+		loaders[i] = "categories:" + loader
+	}
+	facets, err := json.Marshal([][]string{loaders, {"versions:" + target.Minecraft}})
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"query": {term}, "facets": {string(facets)}, "limit": {"20"}}
+	var result modrinthSearch
+	if err := getJSON(ctx, m.client, m.baseURL+"/search?"+query.Encode(), &result); err != nil {
+		return nil, errors.Wrap(err, "failed to search Modrinth")
+	}
+	projects := make([]Project, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		projects = append(projects, Project{Name: hit.Slug, Title: hit.Title, Description: hit.Description, Downloads: hit.Downloads, Source: m.String()})
+	}
+	return projects, nil
+}
+
+// Resolve returns the newest version of the project, given by its slug or ID,
+// for the loaders and Minecraft version of the target.
+func (m *Modrinth) Resolve(ctx context.Context, name, version string, target Target) (*Release, error) {
+	loaders, err := json.Marshal(target.Loaders)
+	if err != nil {
+		return nil, err
+	}
+	gameVersions, err := json.Marshal([]string{target.Minecraft})
+	if err != nil {
+		return nil, err
+	}
+	// dependencies are given by the ID of the project, which is resolved to its slug
+	var project modrinthProject
+	if err := getJSON(ctx, m.client, m.baseURL+"/project/"+url.PathEscape(name), &project); err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %s on Modrinth", name)
+	}
+	name = project.Slug
+	query := url.Values{"loaders": {string(loaders)}, "game_versions": {string(gameVersions)}}
+	var versions []modrinthVersion
+	if err := getJSON(ctx, m.client, m.baseURL+"/project/"+url.PathEscape(project.ID)+"/version?"+query.Encode(), &versions); err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %s on Modrinth", name)
+	}
+	// the versions are sorted by date, newest first
+	for _, v := range versions {
+		if version != "" && v.VersionNumber != version {
+			continue
+		}
+		if len(v.Files) == 0 {
+			continue
+		}
+		file := v.Files[0]
+		for _, f := range v.Files {
+			if f.Primary {
+				file = f
+				break
+			}
+		}
+		release := &Release{
+			ID:          project.ID,
+			Name:        name,
+			Mod:         target.Mod,
+			Version:     v.VersionNumber,
+			URL:         file.URL,
+			File:        file.Filename,
+			SHA512:      file.Hashes.SHA512,
+			Destination: target.Mod.Destination(),
+		}
+		for _, dependency := range v.Dependencies {
+			if dependency.DependencyType == "required" && dependency.ProjectID != "" {
+				release.Dependencies = append(release.Dependencies, dependency.ProjectID)
+			}
+		}
+		return release, nil
+	}
+	if version != "" {
+		return nil, errors.Errorf("%s %s does not support %s on Minecraft %s", name, version, target.Mod, target.Minecraft)
+	}
+	return nil, errors.Errorf("no version of %s supports %s on Minecraft %s", name, target.Mod, target.Minecraft)
+}
+
+// getJSON decodes the JSON response of a GET request into v.
+func getJSON(ctx context.Context, client *http.Client, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.New("not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,100 @@
+package plugins
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// userAgent identifies minectl to the APIs of the sources, as Modrinth requires.
+const userAgent = "github.com/dirien/minectl"
+
+// Source resolves plugins by name, e.g. from the static catalog or the API
+// of a plugin index.
+type Source interface {
+	// Search returns the plugins matching the term which support the target.
+	Search(ctx context.Context, term string, target Target) ([]Project, error)
+	// Resolve returns the newest release of the plugin supporting the target,
+	// or the given version when it is not empty.
+	Resolve(ctx context.Context, name, version string, target Target) (*Release, error)
+	String() string
+}
+
+// Target is the loader and Minecraft version a plugin is resolved for.
+type Target struct {
+	Mod ModType
+	// Loaders lists the loader names of the plugin indexes the edition runs, e.g. paper, spigot and bukkit for Paper.
+	Loaders   []string
+	Minecraft string
+}
+
+// minecraftRegex matches the Minecraft part of the version of a manifest,
+// e.g. 1.21.4 of the Paper build 1.21.4-232.
+var minecraftRegex = regexp.MustCompile(`^\d+\.\d+(\.\d+)?`)
+
+// TargetOf returns the target of the edition and Minecraft version of a
+// manifest. The build of the version is stripped.
+func TargetOf(edition, minecraft string) (Target, error) {
+	mod, err := ModTypeOf(edition)
+	if err != nil {
+		return Target{}, err
+	}
+	loaders := map[string][]string{
+		"forge":       {"forge"},
+		"fabric":      {"fabric"},
+		"papermc":     {"paper", "spigot", "bukkit"},
+		"purpur":      {"purpur", "paper", "spigot", "bukkit"},
+		"spigot":      {"spigot", "bukkit"},
+		"craftbukkit": {"bukkit"},
+	}[edition]
+	if version := minecraftRegex.FindString(minecraft); version != "" {
+		minecraft = version
+	}
+	return Target{Mod: mod, Loaders: loaders, Minecraft: minecraft}, nil
+}
+
+// Project is a plugin found by Search.
+type Project struct {
+	// Name is passed to Resolve, e.g. the slug of a Modrinth project.
+	Name        string
+	Title       string
+	Description string
+	Downloads   int64
+	Source      string
+}
+
+// ResolveAll resolves the plugin and, recursively, its required
+// dependencies. The plugin comes first, every plugin is returned once.
+func ResolveAll(ctx context.Context, source Source, name, version string, target Target) ([]*Release, error) {
+	release, err := source.Resolve(ctx, name, version, target)
+	if err != nil {
+		return nil, err
+	}
+	releases := []*Release{release}
+	for i := 0; i < len(releases); i++ {
+		for _, dependency := range releases[i].Dependencies {
+			if slices.ContainsFunc(releases, func(r *Release) bool { return r.ID == dependency || r.Name == dependency }) {
+				continue
+			}
+			resolved, err := source.Resolve(ctx, dependency, "", target)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to resolve %s, a required dependency of %s", dependency, releases[i].Name)
+			}
+			if !slices.ContainsFunc(releases, func(r *Release) bool { return r.ID == resolved.ID }) {
+				releases = append(releases, resolved)
+			}
+		}
+	}
+	return releases, nil
+}
+
+// httpClient returns the client or the default client when it is nil.
+func httpClient(client *http.Client) *http.Client {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
@@ -0,0 +1,240 @@
+package plugins
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeModrinth is a stand-in for the Modrinth API with fabric-api, which
+// requires nothing, and a mod requiring fabric-api.
+func fakeModrinth(t *testing.T) *httptest.Server {
+	t.Helper()
+	projects := map[string]map[string]string{
+		"fabric-api": {"id": "P7dR8mSH", "slug": "fabric-api"},
+		"P7dR8mSH":   {"id": "P7dR8mSH", "slug": "fabric-api"},
+		"lithium":    {"id": "gvQqBUqZ", "slug": "lithium"},
+		"gvQqBUqZ":   {"id": "gvQqBUqZ", "slug": "lithium"},
+	}
+	versions := map[string][]map[string]any{
+		"P7dR8mSH": {
+			{"version_number": "0.110.0+1.21.4", "files": []map[string]any{{"url": "https://cdn.example.com/fabric-api.jar", "filename": "fabric-api-0.110.0.jar", "primary": true, "hashes": map[string]string{"sha512": "f00"}}}},
+			{"version_number": "0.100.0+1.21.4", "files": []map[string]any{{"url": "https://cdn.example.com/old.jar", "filename": "fabric-api-0.100.0.jar", "primary": true}}},
+		},
+		"gvQqBUqZ": {
+			{"version_number": "0.14.0", "files": []map[string]any{
+				{"url": "https://cdn.example.com/sources.jar", "filename": "lithium-sources.jar"},
+				{"url": "https://cdn.example.com/lithium.jar", "filename": "lithium-0.14.0.jar", "primary": true},
+			}, "dependencies": []map[string]string{
+				{"project_id": "P7dR8mSH", "dependency_type": "required"},
+				{"project_id": "optional", "dependency_type": "optional"},
+			}},
+		},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			http.Error(w, "missing user agent", http.StatusBadRequest)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v2")
+		var body any
+		switch {
+		case path == "/search":
+			facets := r.URL.Query().Get("facets")
+			if !strings.Contains(facets, `"categories:fabric"`) || !strings.Contains(facets, `"versions:1.21.4"`) {
+				body = map[string]any{"hits": []any{}}
+				break
+			}
+			body = map[string]any{"hits": []map[string]any{{"slug": "lithium", "title": "Lithium", "description": "optimization", "downloads": 42}}}
+		case strings.HasSuffix(path, "/version"):
+			id := strings.TrimSuffix(strings.TrimPrefix(path, "/project/"), "/version")
+			if r.URL.Query().Get("loaders") != `["fabric"]` || r.URL.Query().Get("game_versions") != `["1.21.4"]` {
+				body = []any{}
+				break
+			}
+			body = versions[id]
+		default:
+			project, ok := projects[strings.TrimPrefix(path, "/project/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			body = project
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+}
+
+// fakeHangar is a stand-in for the Hangar API with a plugin requiring Vault.
+func fakeHangar(t *testing.T) *httptest.Server {
+	t.Helper()
+	versions := map[string]any{
+		"Vault": map[string]any{"result": []map[string]any{{
+			"name":      "1.7.3",
+			"downloads": map[string]any{"PAPER": map[string]any{"fileInfo": map[string]string{"name": "Vault.jar", "sha256Hash": "abc"}, "downloadUrl": "https://hangar.example.com/Vault.jar"}},
+		}}},
+		"Economy": map[string]any{"result": []map[string]any{{
+			"name":               "2.0.0",
+			"downloads":          map[string]any{"PAPER": map[string]any{"fileInfo": map[string]string{"name": "Economy.jar"}, "downloadUrl": "https://hangar.example.com/Economy.jar"}},
+			"pluginDependencies": map[string]any{"PAPER": []map[string]any{{"name": "Vault", "required": true}, {"name": "Optional", "required": false}}},
+		}}},
+		"External": map[string]any{"result": []map[string]any{{
+			"name":               "1.0.0",
+			"downloads":          map[string]any{"PAPER": map[string]any{"externalUrl": "https://example.com/External.jar"}},
+			"pluginDependencies": map[string]any{"PAPER": []map[string]any{{"name": "ProtocolLib", "required": true, "externalUrl": "https://example.com/ProtocolLib"}}},
+		}}},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+		var body any
+		switch {
+		case path == "/projects":
+			body = map[string]any{"result": []map[string]any{{"name": "Economy", "namespace": map[string]string{"owner": "someone", "slug": "Economy"}, "description": "money", "stats": map[string]int{"downloads": 7}}}}
+		case strings.HasSuffix(path, "/versions"):
+			name := strings.TrimSuffix(strings.TrimPrefix(path, "/projects/"), "/versions")
+			v, ok := versions[name]
+			if !ok || r.URL.Query().Get("platform") != "PAPER" || r.URL.Query().Get("platformVersion") != "1.21.4" {
+				http.NotFound(w, r)
+				return
+			}
+			body = v
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+}
+
+func releaseNames(releases []*Release) []string {
+	names := make([]string, len(releases))
+	for i, r := range releases {
+		names[i] = r.Name + "@" + r.Version
+	}
+	return names
+}
+
+func TestTargetOf(t *testing.T) {
+	tests := []struct {
+		edition   string
+		minecraft string
+		want      Target
+		wantErr   bool
+	}{
+		{edition: "papermc", minecraft: "1.21.4-232", want: Target{Mod: Bukkit, Loaders: []string{"paper", "spigot", "bukkit"}, Minecraft: "1.21.4"}},
+		{edition: "purpur", minecraft: "1.21.11-2533", want: Target{Mod: Bukkit, Loaders: []string{"purpur", "paper", "spigot", "bukkit"}, Minecraft: "1.21.11"}},
+		{edition: "forge", minecraft: "1.20.1-47.3.0", want: Target{Mod: Forge, Loaders: []string{"forge"}, Minecraft: "1.20.1"}},
+		{edition: "fabric", minecraft: "1.21", want: Target{Mod: Fabric, Loaders: []string{"fabric"}, Minecraft: "1.21"}},
+		{edition: "java", minecraft: "1.21.4", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.edition+" "+tt.minecraft, func(t *testing.T) {
+			got, err := TargetOf(tt.edition, tt.minecraft)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TargetOf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TargetOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModrinth(t *testing.T) {
+	server := fakeModrinth(t)
+	defer server.Close()
+	modrinth := NewModrinth(server.Client(), server.URL+"/v2")
+	fabric, err := TargetOf("fabric", "1.21.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projects, err := modrinth.Search(t.Context(), "lith", fabric)
+	if err != nil || len(projects) != 1 || projects[0].Name != "lithium" || projects[0].Source != "modrinth" {
+		t.Errorf("Search() = %+v, %v", projects, err)
+	}
+
+	releases, err := ResolveAll(t.Context(), modrinth, "lithium", "", fabric)
+	if err != nil {
+		t.Fatalf("ResolveAll() error = %v", err)
+	}
+	if got, want := releaseNames(releases), []string{"lithium@0.14.0", "fabric-api@0.110.0+1.21.4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveAll() = %v, want %v", got, want)
+	}
+	want := Release{ID: "P7dR8mSH", Name: "fabric-api", Mod: Fabric, Version: "0.110.0+1.21.4", URL: "https://cdn.example.com/fabric-api.jar",
+		File: "fabric-api-0.110.0.jar", SHA512: "f00", Destination: "/minecraft/mods"}
+	if !reflect.DeepEqual(*releases[1], want) {
+		t.Errorf("dependency = %+v, want %+v", *releases[1], want)
+	}
+	if releases[0].FileName() != "lithium-0.14.0.jar" {
+		t.Errorf("FileName() = %s, want the primary file", releases[0].FileName())
+	}
+
+	tests := []struct {
+		name    string
+		project string
+		version string
+		target  Target
+		want    string
+		wantErr bool
+	}{
+		{name: "pinned version", project: "fabric-api", version: "0.100.0+1.21.4", target: fabric, want: "0.100.0+1.21.4"},
+		{name: "unknown version", project: "fabric-api", version: "1.0.0", target: fabric, wantErr: true},
+		{name: "other minecraft version", project: "fabric-api", target: Target{Mod: Fabric, Loaders: []string{"fabric"}, Minecraft: "1.20.1"}, wantErr: true},
+		{name: "other loader", project: "fabric-api", target: Target{Mod: Forge, Loaders: []string{"forge"}, Minecraft: "1.21.4"}, wantErr: true},
+		{name: "unknown project", project: "missing", target: fabric, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := modrinth.Resolve(t.Context(), tt.project, tt.version, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Version != tt.want {
+				t.Errorf("Resolve() = %s, want %s", got.Version, tt.want)
+			}
+		})
+	}
+}
+
+func TestHangar(t *testing.T) {
+	server := fakeHangar(t)
+	defer server.Close()
+	hangar := NewHangar(server.Client(), server.URL+"/api/v1")
+	paper, err := TargetOf("papermc", "1.21.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projects, err := hangar.Search(t.Context(), "eco", paper)
+	if err != nil || len(projects) != 1 || projects[0].Name != "Economy" || projects[0].Downloads != 7 {
+		t.Errorf("Search() = %+v, %v", projects, err)
+	}
+	spigot, err := TargetOf("spigot", "1.21.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hangar.Search(t.Context(), "eco", spigot); err == nil {
+		t.Error("Search() for Spigot should fail, Hangar hosts Paper plugins")
+	}
+
+	releases, err := ResolveAll(t.Context(), hangar, "Economy", "", paper)
+	if err != nil {
+		t.Fatalf("ResolveAll() error = %v", err)
+	}
+	if got, want := releaseNames(releases), []string{"Economy@2.0.0", "Vault@1.7.3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveAll() = %v, want %v", got, want)
+	}
+	if releases[1].SHA256 != "abc" || releases[1].Destination != "/minecraft/plugins" || releases[1].FileName() != "Vault.jar" {
+		t.Errorf("dependency = %+v", *releases[1])
+	}
+	if _, err := ResolveAll(t.Context(), hangar, "External", "", paper); err == nil {
+		t.Error("ResolveAll() should fail for dependencies outside Hangar")
+	}
+	if _, err := hangar.Resolve(t.Context(), "Vault", "", Target{Mod: Bukkit, Loaders: []string{"paper"}, Minecraft: "1.8.8"}); err == nil {
+		t.Error("Resolve() for another Minecraft version should fail")
+	}
+}
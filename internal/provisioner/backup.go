@@ -0,0 +1,188 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl-sdk/common"
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/internal/remote"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/pkg/errors"
+)
+
+// minecraftDir is the directory of the Minecraft server on the server.
+const minecraftDir = "/minecraft"
+
+// archiveWorldsCmd writes a tar stream of the worlds to stdout: the world of
+// level-name in server.properties with its nether and end dimensions for Java
+// servers, the worlds directory for Bedrock servers.
+const archiveWorldsCmd = `cd ` + minecraftDir + ` || exit 1; ` +
+	`level=$(sed -n 's/^level-name=//p' server.properties 2>/dev/null); level=${level:-world}; ` +
+	`sudo tar -cf - $(ls -d "$level" "${level}_nether" "${level}_the_end" worlds 2>/dev/null)`
+
+// Backup archives the worlds of the server and stores them in the backend.
+// Saving is turned off via RCON while the worlds are archived, so the files
+// are consistent. Afterwards, the backups expired by the retention are deleted.
+func (p *MinectlProvisioner) Backup(ctx context.Context, backend backup.Backend, retention backup.Retention, reason string) (*backup.Info, error) {
+	name := p.args.MinecraftResource.GetName()
+	spinner := ui.NewSpinner(fmt.Sprintf("Backing up server (%s)...", common.Green(name)), p.ui)
+	spinner.ErrorMessage = fmt.Sprintf("Server (%s) not backed up.", common.Green(name))
+	spinner.Start()
+	info, err := p.backup(ctx, backend, reason)
+	if err == nil {
+		spinner.FinalMessage = fmt.Sprintf("Server (%s) backed up: %s (%s).", common.Green(name), info.ID, ui.FormatSize(info.Size))
+	}
+	spinner.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return nil, interrupted(err, "backing up the server")
+	}
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := backup.Prune(ctx, backend, name, retention, time.Now())
+	if err != nil {
+		p.ui.Warn("Could not delete the expired backups: " + err.Error())
+	}
+	for _, expired := range deleted {
+		p.ui.Info(fmt.Sprintf("Deleted the expired backup %s.", expired.ID))
+	}
+	return info, nil
+}
+
+func (p *MinectlProvisioner) backup(ctx context.Context, backend backup.Backend, reason string) (*backup.Info, error) {
+	resource := p.args.MinecraftResource
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.connect(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	if resource.HasRCON() {
+		resume, err := p.pauseSaving(ctx, server)
+		if err != nil {
+			return nil, err
+		}
+		defer resume()
+	} else {
+		p.ui.Warn("RCON is not enabled in the manifest, the worlds are archived while Minecraft saves them.")
+	}
+
+	r, w := io.Pipe()
+	streamed := make(chan error, 1)
+	go func() {
+		err := client.Stream(ctx, archiveWorldsCmd, nil, w)
+		_ = w.CloseWithError(err)
+		streamed <- err
+	}()
+	info, err := backup.Create(ctx, backend, backup.Info{
+		Server:  resource.GetName(),
+		Edition: resource.GetEdition(),
+		Version: resource.GetVersion(),
+		Reason:  reason,
+	}, r)
+	_ = r.CloseWithError(errors.New("backup aborted"))
+	if streamErr := <-streamed; streamErr != nil && err == nil {
+		err = streamErr
+	}
+	return info, err
+}
+
+// pauseSaving turns saving off and flushes the worlds to disk. The returned
+// function turns saving on again.
+func (p *MinectlProvisioner) pauseSaving(ctx context.Context, server *automation.ResourceResults) (func(), error) {
+	for _, cmd := range []string{"save-off", "save-all flush"} {
+		if _, err := p.rconCommand(ctx, server, cmd); err != nil {
+			if cmd != "save-off" {
+				p.resumeSaving(server)
+			}
+			return nil, errors.Wrapf(err, "failed to run %s via RCON", cmd)
+		}
+	}
+	return func() { p.resumeSaving(server) }, nil
+}
+
+func (p *MinectlProvisioner) resumeSaving(server *automation.ResourceResults) {
+	// saving is turned on again even when the backup was interrupted
+	if _, err := p.rconCommand(context.Background(), server, "save-on"); err != nil {
+		p.ui.Warn("Could not turn saving on again, please run save-on via RCON: " + err.Error())
+	}
+}
+
+// Restore replaces the worlds of the server with the backup. The backup is
+// verified before Minecraft is stopped.
+func (p *MinectlProvisioner) Restore(ctx context.Context, backend backup.Backend, info *backup.Info, wait bool) error {
+	name := p.args.MinecraftResource.GetName()
+	spinner := ui.NewSpinner(fmt.Sprintf("Restoring backup %s to server (%s)...", info.ID, common.Green(name)), p.ui)
+	spinner.FinalMessage = fmt.Sprintf("Backup %s restored to server (%s).", info.ID, common.Green(name))
+	spinner.ErrorMessage = fmt.Sprintf("Backup %s not restored to server (%s).", info.ID, common.Green(name))
+	spinner.Start()
+	server, err := p.restore(ctx, backend, info)
+	spinner.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return interrupted(err, "restoring the backup")
+	}
+	if err != nil || !wait || provider.IsSimulated(p.auto) {
+		return err
+	}
+	if err := p.waitForMinecraftServerReady(ctx, server); err != nil {
+		if ctx.Err() != nil {
+			return interrupted(err, "waiting for the server to start")
+		}
+		return err
+	}
+	return nil
+}
+
+func (p *MinectlProvisioner) restore(ctx context.Context, backend backup.Backend, info *backup.Info) (*automation.ResourceResults, error) {
+	if len(info.Worlds) == 0 {
+		return nil, errors.Errorf("the backup %s contains no worlds", info.ID)
+	}
+	worlds := make([]string, 0, len(info.Worlds))
+	for _, world := range info.Worlds {
+		if world == "" || world == "." || world == ".." || strings.Contains(world, "/") {
+			return nil, errors.Errorf("the backup %s contains the invalid world %q", info.ID, world)
+		}
+		worlds = append(worlds, remote.Quote(world))
+	}
+	archive, err := backup.Open(ctx, backend, info)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.connect(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return server, replaceWorlds(ctx, client, worlds, archive, "restore the backup again")
+}
+
+// replaceWorlds stops Minecraft, replaces the quoted worlds with the tar
+// stream and starts Minecraft again. When the stream fails, Minecraft stays
+// stopped and the error asks the user to retry.
+func replaceWorlds(ctx context.Context, client provider.RemoteClient, worlds []string, archive io.Reader, retry string) error {
+	if _, err := client.Run(ctx, "sudo systemctl stop "+minecraftService); err != nil {
+		return err
+	}
+	cmd := fmt.Sprintf("cd %s && sudo rm -rf -- %s && sudo tar -xf -", minecraftDir, strings.Join(worlds, " "))
+	if err := client.Stream(ctx, cmd, archive, nil); err != nil {
+		return errors.Wrap(err, "Minecraft stays stopped, as the worlds are incomplete, please "+retry)
+	}
+	_, err := client.Run(ctx, "sudo systemctl start "+minecraftService)
+	return err
+}
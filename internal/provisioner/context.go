@@ -0,0 +1,87 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/pkg/errors"
+)
+
+// createGracePeriod is the time an interrupted create waits for the cloud
+// provider, so the half-created server is known and can be rolled back.
+const createGracePeriod = 3 * time.Minute
+
+// IncompleteServerError is returned when creating a server was interrupted or
+// Minecraft did not start after the server was created at the cloud provider.
+type IncompleteServerError struct {
+	Server *automation.ResourceResults
+	Err    error
+}
+
+func (e *IncompleteServerError) Error() string {
+	return fmt.Sprintf("server %s (%s) is incomplete: %v", e.Server.Name, e.Server.ID, e.Err)
+}
+
+func (e *IncompleteServerError) Unwrap() error {
+	return e.Err
+}
+
+// Rollback deletes a server whose creation was interrupted.
+func (p *MinectlProvisioner) Rollback(ctx context.Context, server *automation.ResourceResults) error {
+	p.args.ID = server.ID
+	return p.DeleteServer(ctx)
+}
+
+type result[T any] struct {
+	value T
+	err   error
+}
+
+// await runs fn and returns early with the error of the context when it is
+// done first. The calls of the SDK take no context, so fn keeps running in
+// the background.
+func await[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	done := run(fn)
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+func run[T any](fn func() (T, error)) <-chan result[T] {
+	done := make(chan result[T], 1)
+	go func() {
+		value, err := fn()
+		done <- result[T]{value: value, err: err}
+	}()
+	return done
+}
+
+// sleep waits for the duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// interrupted describes the error of an operation which failed as the
+// context is done. A nil error is returned unchanged, the operation succeeded.
+func interrupted(err error, action string) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errors.Wrapf(err, "timed out while %s", action)
+	}
+	return errors.Wrapf(err, "interrupted while %s", action)
+}
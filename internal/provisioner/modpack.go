@@ -0,0 +1,89 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/dirien/minectl-sdk/common"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/internal/world"
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/pkg/errors"
+)
+
+// UploadDir stops Minecraft, writes the files in dir to the same paths below
+// the Minecraft folder of the server and starts Minecraft again, e.g. the
+// mods and configs of a modpack.
+func (p *MinectlProvisioner) UploadDir(ctx context.Context, dir string, wait bool) error {
+	resource := p.args.MinecraftResource
+	size, err := world.Size(dir)
+	if err != nil {
+		return err
+	}
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return err
+	}
+	client, err := p.connect(ctx, server)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	name := common.Green(resource.GetName())
+	progress := ui.NewProgress(fmt.Sprintf("Uploading to server (%s)...", name), size, p.ui)
+	progress.FinalMessage = fmt.Sprintf("Uploaded to server (%s).", name)
+	progress.ErrorMessage = fmt.Sprintf("Not uploaded to server (%s).", name)
+	progress.Start()
+	err = uploadDir(ctx, client, dir, progress)
+	progress.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return interrupted(err, "uploading the files")
+	}
+	if err != nil || !wait || provider.IsSimulated(p.auto) {
+		return err
+	}
+	if err := p.waitForMinecraftServerReady(ctx, server); err != nil {
+		if ctx.Err() != nil {
+			return interrupted(err, "waiting for the server to start")
+		}
+		return err
+	}
+	return nil
+}
+
+func uploadDir(ctx context.Context, client provider.RemoteClient, dir string, progress *ui.Progress) error {
+	if _, err := client.Run(ctx, "sudo systemctl stop "+minecraftService); err != nil {
+		return errors.Wrap(err, "failed to stop Minecraft")
+	}
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := client.WriteFile(path.Join(minecraftDir, filepath.ToSlash(rel)), content, 0o644); err != nil {
+			return errors.Wrapf(err, "failed to upload %s", rel)
+		}
+		_, _ = progress.Write(content)
+		return nil
+	})
+	// Minecraft is started again, also when the upload failed
+	if _, startErr := client.Run(context.WithoutCancel(ctx), "sudo systemctl start "+minecraftService); err == nil && startErr != nil {
+		err = errors.Wrap(startErr, "failed to start Minecraft")
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl-sdk/common"
+	"github.com/dirien/minectl/internal/network"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/pkg/errors"
+)
+
+const (
+	minecraftNetworkConfiguringTitle  = "Configuring network of proxy (%s)..."
+	minecraftNetworkConfigureTitle    = "Network of proxy (%s) configured."
+	minecraftNetworkNotConfigureTitle = "Network of proxy (%s) not configured."
+)
+
+// HasNetwork returns true when the manifest is a proxy with backends.
+func (p *MinectlProvisioner) HasNetwork() bool {
+	return p.document.Network != nil
+}
+
+// ConfigureNetwork writes the server list, the try order and the forwarding
+// settings of the proxy and restarts it, when it is already running.
+func (p *MinectlProvisioner) ConfigureNetwork(ctx context.Context, server *automation.ResourceResults, backends []network.Backend) error {
+	if !p.HasNetwork() {
+		return errors.Errorf("the manifest %s has no network", p.args.MinecraftResource.GetName())
+	}
+	files, err := network.Render(p.args.MinecraftResource, p.document.Network, backends)
+	if err != nil {
+		return err
+	}
+	name := common.Green(p.args.MinecraftResource.GetName())
+	spinner := ui.NewSpinner(fmt.Sprintf(minecraftNetworkConfiguringTitle, name), p.ui)
+	spinner.FinalMessage = fmt.Sprintf(minecraftNetworkConfigureTitle, name)
+	spinner.ErrorMessage = fmt.Sprintf(minecraftNetworkNotConfigureTitle, name)
+	spinner.Start()
+	err = p.writeFiles(ctx, server, files)
+	spinner.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return interrupted(err, "configuring the network")
+	}
+	return err
+}
+
+func (p *MinectlProvisioner) writeFiles(ctx context.Context, server *automation.ResourceResults, files []network.File) error {
+	client, err := p.connect(ctx, server)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	for _, file := range files {
+		if err := client.WriteFile(file.Path, file.Content, file.Mode); err != nil {
+			return err
+		}
+	}
+	// a proxy which is still being installed picks up the configuration on its first start
+	_, err = client.Run(ctx, "sudo systemctl try-restart "+minecraftService)
+	return err
+}
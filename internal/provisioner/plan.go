@@ -0,0 +1,107 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl/internal/state"
+	"github.com/pkg/errors"
+)
+
+// Action is the change apply has to make to bring a server in sync with its manifest.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionNone   Action = "none"
+)
+
+// Plan describes what apply would do for a manifest.
+type Plan struct {
+	Action Action
+	Reason string
+	// Server is the running server, nil when it has to be created.
+	Server *automation.ResourceResults
+}
+
+func (p *Plan) String() string {
+	name := ""
+	if p.Server != nil {
+		name = fmt.Sprintf(" %s (%s)", p.Server.Name, p.Server.ID)
+	}
+	return fmt.Sprintf("%s%s: %s", p.Action, name, p.Reason)
+}
+
+// Plan compares the manifest with the running servers of the cloud provider.
+// The server is matched by the id recorded in the local state and, if it is
+// not tracked, by the name in the manifest.
+func (p *MinectlProvisioner) Plan(ctx context.Context) (*Plan, error) {
+	name := p.args.MinecraftResource.GetName()
+	servers, err := p.ListServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracked *state.Server
+	if p.store != nil {
+		tracked, err = p.store.Find(name, p.args.MinecraftResource.GetCloud())
+		if err != nil && !errors.Is(err, state.ErrNotFound) {
+			return nil, err
+		}
+	}
+	if tracked != nil {
+		for i := range servers {
+			if servers[i].ID == tracked.ID {
+				return p.planUpdate(&servers[i], tracked)
+			}
+		}
+	}
+
+	var found []automation.ResourceResults
+	for _, server := range servers {
+		if server.Name == name {
+			found = append(found, server)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return &Plan{Action: ActionCreate, Reason: fmt.Sprintf("no server named %s found", name)}, nil
+	case 1:
+		return p.planUpdate(&found[0], nil)
+	default:
+		return nil, errors.Errorf("found %d servers named %s, please remove the duplicates first", len(found), name)
+	}
+}
+
+// planUpdate plans the update of the server. From then on the provisioner
+// works on the server, e.g. to back it up before the update.
+func (p *MinectlProvisioner) planUpdate(server *automation.ResourceResults, tracked *state.Server) (*Plan, error) {
+	p.args.ID = server.ID
+	if tracked == nil || tracked.ID != server.ID {
+		return &Plan{Action: ActionUpdate, Reason: "server is not tracked in the local state", Server: server}, nil
+	}
+	if p.document.Hash() != tracked.ManifestHash {
+		return &Plan{Action: ActionUpdate, Reason: "manifest changed", Server: server}, nil
+	}
+	return &Plan{Action: ActionNone, Reason: "server is in sync", Server: server}, nil
+}
+
+// Apply executes the plan and returns the resulting server.
+func (p *MinectlProvisioner) Apply(ctx context.Context, plan *Plan, wait bool) (*automation.ResourceResults, error) {
+	switch plan.Action {
+	case ActionCreate:
+		return p.CreateServer(ctx, wait)
+	case ActionUpdate:
+		p.args.ID = plan.Server.ID
+		if err := p.UpdateServer(ctx); err != nil {
+			return nil, err
+		}
+		return plan.Server, nil
+	case ActionNone:
+		return plan.Server, nil
+	default:
+		return nil, errors.Errorf("unknown action %s", plan.Action)
+	}
+}
@@ -0,0 +1,58 @@
+package provisioner
+
+import (
+	"context"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl/internal/rcon"
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/pkg/errors"
+)
+
+// Players returns the number of players online on the server, via the Server
+// List Ping or, with viaRCON, the RCON list command.
+func (p *MinectlProvisioner) Players(ctx context.Context, server *automation.ResourceResults, viaRCON bool) (int, error) {
+	resource := p.args.MinecraftResource
+	// simulated servers have no RCON, they answer the ping
+	if viaRCON && !provider.IsSimulated(p.auto) {
+		if !resource.HasRCON() {
+			return 0, errors.Errorf("RCON is not enabled in the manifest %s", resource.GetName())
+		}
+		resp, err := p.rconCommand(ctx, server, "list")
+		if err != nil {
+			return 0, err
+		}
+		return rcon.Players(resp)
+	}
+	status := p.Probe(ctx, resource, server)
+	if status.Err != nil {
+		return 0, status.Err
+	}
+	return status.Ping.Players, nil
+}
+
+// Say broadcasts the message to the players via RCON.
+func (p *MinectlProvisioner) Say(ctx context.Context, server *automation.ResourceResults, message string) error {
+	resource := p.args.MinecraftResource
+	if !resource.HasRCON() {
+		return errors.Errorf("RCON is not enabled in the manifest %s", resource.GetName())
+	}
+	if provider.IsSimulated(p.auto) {
+		p.ui.Info("say " + message)
+		return nil
+	}
+	_, err := p.rconCommand(ctx, server, "say "+message)
+	return err
+}
+
+// rconCommand runs the command via RCON. Simulated servers have no RCON, the
+// command is skipped.
+func (p *MinectlProvisioner) rconCommand(ctx context.Context, server *automation.ResourceResults, cmd string) (string, error) {
+	if provider.IsSimulated(p.auto) {
+		return "", nil
+	}
+	resource := p.args.MinecraftResource
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	return rcon.Command(ctx, server.PublicIP, resource.GetRCONPassword(), resource.GetRCONPort(), cmd)
+}
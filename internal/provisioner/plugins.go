@@ -0,0 +1,138 @@
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dirien/minectl-sdk/common"
+	"github.com/dirien/minectl/internal/plugins"
+	"github.com/dirien/minectl/internal/remote"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/pkg/errors"
+)
+
+// Jar is a plugin or mod on the server.
+type Jar struct {
+	Path   string
+	SHA256 string
+}
+
+// listJarsCmd prints the checksum and path of the jars in the folder, nothing when it does not exist.
+func listJarsCmd(dir string) string {
+	return fmt.Sprintf("if [ -d %[1]s ]; then sudo find %[1]s -maxdepth 1 -type f -name '*.jar' -exec sha256sum {} +; fi", remote.Quote(dir))
+}
+
+// Plugin is a jar on the server with the metadata it declares. Metadata is
+// nil when the jar declares none.
+type Plugin struct {
+	Jar
+	Metadata *plugins.Metadata
+}
+
+// ManagedPlugins returns the plugins section of the manifest, nil without one.
+func (p *MinectlProvisioner) ManagedPlugins() []plugins.Spec {
+	return p.document.Plugins
+}
+
+// InstalledJars returns the jars in the folder on the server.
+func (p *MinectlProvisioner) InstalledJars(ctx context.Context, dir string) ([]Jar, error) {
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.connect(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return installedJars(ctx, client, dir)
+}
+
+func installedJars(ctx context.Context, client provider.RemoteClient, dir string) ([]Jar, error) {
+	out, err := client.Run(ctx, listJarsCmd(dir))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the jars in %s", dir)
+	}
+	var jars []Jar
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		sum, path, ok := strings.Cut(line, "  ")
+		if !ok {
+			continue
+		}
+		jars = append(jars, Jar{Path: path, SHA256: sum})
+	}
+	return jars, nil
+}
+
+// InstalledPlugins returns the jars in the folder on the server with their
+// metadata, which is read from the jars downloaded over SSH.
+func (p *MinectlProvisioner) InstalledPlugins(ctx context.Context, dir string) ([]Plugin, error) {
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.connect(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	jars, err := installedJars(ctx, client, dir)
+	if err != nil {
+		return nil, err
+	}
+	installed := make([]Plugin, 0, len(jars))
+	for _, jar := range jars {
+		var buf bytes.Buffer
+		if err := client.Stream(ctx, "sudo cat -- "+remote.Quote(jar.Path), nil, &buf); err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", jar.Path)
+		}
+		metadata, err := plugins.ReadMetadata(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			p.ui.Warn(fmt.Sprintf("%s: %v", jar.Path, err))
+		}
+		installed = append(installed, Plugin{Jar: jar, Metadata: metadata})
+	}
+	return installed, nil
+}
+
+// RemovePlugins deletes the jars from the server.
+func (p *MinectlProvisioner) RemovePlugins(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	name := common.Green(p.args.MinecraftResource.GetName())
+	spinner := ui.NewSpinner(fmt.Sprintf("Removing %d plugin(s) from server (%s)...", len(paths), name), p.ui)
+	spinner.FinalMessage = fmt.Sprintf("Removed %d plugin(s) from server (%s).", len(paths), name)
+	spinner.ErrorMessage = fmt.Sprintf("Plugins not removed from server (%s).", name)
+	spinner.Start()
+	err := p.removePlugins(ctx, paths)
+	spinner.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return interrupted(err, "removing the plugins")
+	}
+	return err
+}
+
+func (p *MinectlProvisioner) removePlugins(ctx context.Context, paths []string) error {
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return err
+	}
+	client, err := p.connect(ctx, server)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	quoted := make([]string, len(paths))
+	for i, path := range paths {
+		if !strings.HasPrefix(path, minecraftDir+"/") || strings.Contains(path, "..") {
+			return errors.Errorf("refusing to remove %s outside of %s", path, minecraftDir)
+		}
+		quoted[i] = remote.Quote(path)
+	}
+	_, err = client.Run(ctx, "sudo rm -f -- "+strings.Join(quoted, " "))
+	return err
+}
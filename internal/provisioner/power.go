@@ -0,0 +1,206 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl-sdk/common"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/pkg/errors"
+)
+
+const (
+	minecraftServerStoppingTitle  = "Stopping server (%s)..."
+	minecraftServerStopTitle      = "Server (%s) stopped."
+	minecraftServerNotStopTitle   = "Server (%s) not stopped."
+	minecraftServerPoweringTitle  = "Starting server (%s)..."
+	minecraftServerPowerTitle     = "Server (%s) started."
+	minecraftServerNotPowerTitle  = "Server (%s) not started."
+	minecraftServiceStoppingTitle = "Stopping Minecraft on server (%s)..."
+	minecraftServiceStopTitle     = "Minecraft on server (%s) stopped."
+	minecraftServiceNotStopTitle  = "Minecraft on server (%s) not stopped."
+
+	// powerTimeout is the time the cloud provider gets to power a server off or on.
+	powerTimeout  = 5 * time.Minute
+	powerInterval = 5 * time.Second
+
+	minecraftService = "minecraft.service"
+)
+
+// SupportsPower returns true when the cloud provider can power the server off and on.
+func (p *MinectlProvisioner) SupportsPower() bool {
+	_, ok := p.auto.(provider.PowerManager)
+	return ok
+}
+
+// PowerState returns the power state of the server, provider.PowerUnknown
+// when the cloud provider has no power management.
+func (p *MinectlProvisioner) PowerState(ctx context.Context, id string) (string, error) {
+	power, ok := p.auto.(provider.PowerManager)
+	if !ok {
+		return provider.PowerUnknown, nil
+	}
+	return await(ctx, func() (string, error) {
+		return power.PowerState(id)
+	})
+}
+
+func (p *MinectlProvisioner) powerManager() (provider.PowerManager, error) {
+	power, ok := p.auto.(provider.PowerManager)
+	if !ok {
+		return nil, errors.Errorf("%s can not power servers off and on, please use --service-only to stop only Minecraft", provider.FullName(p.args.MinecraftResource.GetCloud()))
+	}
+	return power, nil
+}
+
+// StopServer powers the server off, keeping its disks. With serviceOnly, only
+// the Minecraft unit is stopped and the server keeps running.
+func (p *MinectlProvisioner) StopServer(ctx context.Context, serviceOnly bool) error {
+	name := common.Green(p.args.MinecraftResource.GetName())
+	if serviceOnly {
+		spinner := ui.NewSpinner(fmt.Sprintf(minecraftServiceStoppingTitle, name), p.ui)
+		spinner.FinalMessage = fmt.Sprintf(minecraftServiceStopTitle, name)
+		spinner.ErrorMessage = fmt.Sprintf(minecraftServiceNotStopTitle, name)
+		spinner.Start()
+		err := p.systemctl(ctx, "stop")
+		spinner.Stop(err)
+		if err != nil && ctx.Err() != nil {
+			return interrupted(err, "stopping Minecraft")
+		}
+		return err
+	}
+
+	power, err := p.powerManager()
+	if err != nil {
+		return err
+	}
+	spinner := ui.NewSpinner(fmt.Sprintf(minecraftServerStoppingTitle, name), p.ui)
+	spinner.FinalMessage = fmt.Sprintf(minecraftServerStopTitle, name)
+	spinner.ErrorMessage = fmt.Sprintf(minecraftServerNotStopTitle, name)
+	spinner.Start()
+	err = p.changePower(ctx, power.StopServer, provider.PowerStopped)
+	spinner.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return interrupted(err, "stopping the server")
+	}
+	return err
+}
+
+// StartServer powers the server on and waits until Minecraft is reachable.
+// With serviceOnly, only the Minecraft unit is started. The server is
+// returned with its public IP, which changes on some cloud providers.
+func (p *MinectlProvisioner) StartServer(ctx context.Context, serviceOnly, wait bool) (*automation.ResourceResults, error) {
+	name := common.Green(p.args.MinecraftResource.GetName())
+	spinner := ui.NewSpinner(fmt.Sprintf(minecraftServerPoweringTitle, name), p.ui)
+	spinner.FinalMessage = fmt.Sprintf(minecraftServerPowerTitle, name)
+	spinner.ErrorMessage = fmt.Sprintf(minecraftServerNotPowerTitle, name)
+	var err error
+	if serviceOnly {
+		spinner.Start()
+		err = p.systemctl(ctx, "start")
+	} else {
+		var power provider.PowerManager
+		if power, err = p.powerManager(); err != nil {
+			return nil, err
+		}
+		spinner.Start()
+		err = p.changePower(ctx, power.StartServer, provider.PowerRunning)
+	}
+	spinner.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return nil, interrupted(err, "starting the server")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.awaitStarted(ctx, wait)
+}
+
+// RestartServer stops and starts the server. With serviceOnly, only the
+// Minecraft unit is restarted.
+func (p *MinectlProvisioner) RestartServer(ctx context.Context, serviceOnly, wait bool) (*automation.ResourceResults, error) {
+	if !serviceOnly {
+		if err := p.StopServer(ctx, false); err != nil {
+			return nil, err
+		}
+		return p.StartServer(ctx, false, wait)
+	}
+	name := common.Green(p.args.MinecraftResource.GetName())
+	spinner := ui.NewSpinner(fmt.Sprintf(minecraftServerPoweringTitle, name), p.ui)
+	spinner.FinalMessage = fmt.Sprintf(minecraftServerPowerTitle, name)
+	spinner.ErrorMessage = fmt.Sprintf(minecraftServerNotPowerTitle, name)
+	spinner.Start()
+	err := p.systemctl(ctx, "restart")
+	spinner.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return nil, interrupted(err, "restarting Minecraft")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.awaitStarted(ctx, wait)
+}
+
+// awaitStarted records the server with its current IP and waits for Minecraft.
+func (p *MinectlProvisioner) awaitStarted(ctx context.Context, wait bool) (*automation.ResourceResults, error) {
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.recordServer(server); err != nil {
+		p.ui.Warn("Could not update the local state: " + err.Error())
+	}
+	if wait && !provider.IsSimulated(p.auto) {
+		if err := p.waitForMinecraftServerReady(ctx, server); err != nil {
+			if ctx.Err() != nil {
+				return nil, interrupted(err, "waiting for the server to start")
+			}
+			return nil, err
+		}
+	}
+	return server, nil
+}
+
+// changePower requests the power change and polls until the server reached the state.
+func (p *MinectlProvisioner) changePower(ctx context.Context, change func(id string) error, want string) error {
+	_, err := await(ctx, func() (struct{}, error) {
+		return struct{}{}, change(p.args.ID)
+	})
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(powerTimeout)
+	for {
+		state, err := p.PowerState(ctx, p.args.ID)
+		if err != nil {
+			return err
+		}
+		if state == want {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("the server is still %s after %s", state, powerTimeout)
+		}
+		if err := sleep(ctx, powerInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// systemctl runs the action on the Minecraft unit of the server.
+func (p *MinectlProvisioner) systemctl(ctx context.Context, action string) error {
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return err
+	}
+	client, err := p.connect(ctx, server)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	_, err = client.Run(ctx, fmt.Sprintf("sudo systemctl %s %s", action, minecraftService))
+	return err
+}
@@ -1,34 +1,23 @@
 package provisioner
 
 import (
+	"context"
 	"fmt"
-	"net"
-	"os"
-	"strconv"
+	"path/filepath"
 	"time"
 
 	"github.com/dirien/minectl-sdk/automation"
-	"github.com/dirien/minectl-sdk/cloud"
-	"github.com/dirien/minectl-sdk/cloud/akamai"
-	"github.com/dirien/minectl-sdk/cloud/aws"
-	"github.com/dirien/minectl-sdk/cloud/azure"
-	"github.com/dirien/minectl-sdk/cloud/civo"
-	"github.com/dirien/minectl-sdk/cloud/do"
-	"github.com/dirien/minectl-sdk/cloud/exoscale"
-	"github.com/dirien/minectl-sdk/cloud/fuga"
-	"github.com/dirien/minectl-sdk/cloud/gce"
-	"github.com/dirien/minectl-sdk/cloud/hetzner"
-	"github.com/dirien/minectl-sdk/cloud/multipass"
-	"github.com/dirien/minectl-sdk/cloud/oci"
-	"github.com/dirien/minectl-sdk/cloud/ovh"
-	"github.com/dirien/minectl-sdk/cloud/scaleway"
-	"github.com/dirien/minectl-sdk/cloud/vexxhost"
-	"github.com/dirien/minectl-sdk/cloud/vultr"
 	"github.com/dirien/minectl-sdk/common"
 	"github.com/dirien/minectl-sdk/model"
+	"github.com/dirien/minectl/internal/backup"
+	"github.com/dirien/minectl/internal/config"
 	"github.com/dirien/minectl/internal/manifest"
+	"github.com/dirien/minectl/internal/ping"
 	"github.com/dirien/minectl/internal/rcon"
+	"github.com/dirien/minectl/internal/remote"
+	"github.com/dirien/minectl/internal/state"
 	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/pkg/provider"
 	"github.com/pkg/errors"
 )
 
@@ -54,42 +43,144 @@ const (
 	minecraftServerUpdateTitle    = "Server (%s) updated."
 	minecraftServerNotUpdateTitle = "Server (%s) update failed."
 
-	startCheckCount = 50
+	// DefaultWaitTimeout is the time a server gets until Minecraft answers the ping.
+	DefaultWaitTimeout = 10 * time.Minute
+	pingInterval       = 10 * time.Second
+
+	// sshConnectTimeout is the time a freshly created server gets until SSH is reachable.
+	sshConnectTimeout  = 5 * time.Minute
+	sshConnectInterval = 10 * time.Second
 )
 
 type MinectlProvisionerOpts struct {
-	ManifestPath      string
+	ManifestPath string
+	// Name selects the manifest when ManifestPath contains several manifests.
+	Name string
+	// Document is used instead of reading the manifest from ManifestPath. Optional.
+	Document          *manifest.Document
 	ID                string
 	SSHPrivateKeyPath string
+	// Store records created servers, so they can later be resolved by name. Optional.
+	Store *state.Store
+	// Profile holds the credentials of the selected profile. Environment variables take precedence.
+	Profile config.Profile
+	// WaitTimeout limits waiting for Minecraft to start. Defaults to DefaultWaitTimeout.
+	WaitTimeout time.Duration
 }
 
 type MinectlProvisionerListOpts struct {
 	Provider string
 	Region   string
+	Profile  config.Profile
 }
 
 type MinectlProvisioner struct {
-	auto automation.Automation
-	args automation.ServerArgs
-	ui   *ui.UI
+	auto        automation.Automation
+	args        automation.ServerArgs
+	ui          *ui.UI
+	document    *manifest.Document
+	store       *state.Store
+	credentials provider.Credentials
+	waitTimeout time.Duration
 }
 
+// Provisioner manages the server of a manifest. Canceling the context stops
+// waiting for the cloud provider, the call itself can not be canceled.
 type Provisioner interface {
-	CreateServer(wait bool) (*automation.ResourceResults, error)
-	DeleteServer() error
-	UpdateServer() error
-	UploadPlugin(plugin, destination string) error
-	ListServer() ([]automation.ResourceResults, error)
-	GetServer() (*automation.ResourceResults, error)
-	DoRCON() error
+	CreateServer(ctx context.Context, wait bool) (*automation.ResourceResults, error)
+	DeleteServer(ctx context.Context) error
+	UpdateServer(ctx context.Context) error
+	UploadPlugin(ctx context.Context, plugin, destination string) error
+	ListServer(ctx context.Context) ([]automation.ResourceResults, error)
+	GetServer(ctx context.Context) (*automation.ResourceResults, error)
+	ImportServer(ctx context.Context) (*automation.ResourceResults, error)
+	DoRCON(ctx context.Context) error
+	StopServer(ctx context.Context, serviceOnly bool) error
+	StartServer(ctx context.Context, serviceOnly, wait bool) (*automation.ResourceResults, error)
+	RestartServer(ctx context.Context, serviceOnly, wait bool) (*automation.ResourceResults, error)
+	Backup(ctx context.Context, backend backup.Backend, retention backup.Retention, reason string) (*backup.Info, error)
+	Restore(ctx context.Context, backend backup.Backend, info *backup.Info, wait bool) error
+	PullWorld(ctx context.Context, dir string) ([]string, error)
+	PushWorld(ctx context.Context, dir string, force, wait bool) error
+	InstalledJars(ctx context.Context, dir string) ([]Jar, error)
+	InstalledPlugins(ctx context.Context, dir string) ([]Plugin, error)
+	UploadDir(ctx context.Context, dir string, wait bool) error
+	RemovePlugins(ctx context.Context, paths []string) error
+}
+
+// MinecraftResource returns the manifest the provisioner was created with.
+func (p *MinectlProvisioner) MinecraftResource() *model.MinecraftResource {
+	return p.args.MinecraftResource
 }
 
-func (p *MinectlProvisioner) GetServer() (*automation.ResourceResults, error) {
-	return p.auto.GetServer(p.args.ID, p.args)
+func (p *MinectlProvisioner) GetServer(ctx context.Context) (*automation.ResourceResults, error) {
+	return await(ctx, func() (*automation.ResourceResults, error) {
+		return p.auto.GetServer(p.args.ID, p.args)
+	})
 }
 
-func (p *MinectlProvisioner) DoRCON() error {
-	server, err := p.GetServer()
+// ImportServer fetches an existing server and records it in the local state.
+func (p *MinectlProvisioner) ImportServer(ctx context.Context) (*automation.ResourceResults, error) {
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.recordServer(server); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// recordServer stores the server in the local state, when a store is configured.
+func (p *MinectlProvisioner) recordServer(server *automation.ResourceResults) error {
+	if p.store == nil || server == nil {
+		return nil
+	}
+	manifestPath, err := filepath.Abs(p.document.File)
+	if err != nil {
+		return err
+	}
+	return p.store.Put(state.Server{
+		ID:           server.ID,
+		Name:         p.args.MinecraftResource.GetName(),
+		Cloud:        p.args.MinecraftResource.GetCloud(),
+		Region:       p.args.MinecraftResource.GetRegion(),
+		PublicIP:     server.PublicIP,
+		Tags:         server.Tags,
+		ManifestPath: manifestPath,
+		ManifestHash: p.document.Hash(),
+	})
+}
+
+// RequiresSSHKey returns true when the provisioner needs the private SSH key
+// to connect to the server. Simulated providers connect without SSH.
+func (p *MinectlProvisioner) RequiresSSHKey() bool {
+	_, ok := p.auto.(provider.Connector)
+	return !ok
+}
+
+// connect opens a session on the server.
+func (p *MinectlProvisioner) connect(ctx context.Context, server *automation.ResourceResults) (provider.RemoteClient, error) {
+	if connector, ok := p.auto.(provider.Connector); ok {
+		return connector.Connect(server.ID)
+	}
+	if p.args.SSHPrivateKeyPath == "" {
+		return nil, errors.New("Please provide a valid ssh key path via -k|--ssh-key flag to connect to the server")
+	}
+	user, err := provider.SSHUser(p.args.MinecraftResource.GetCloud(), p.credentials)
+	if err != nil {
+		return nil, err
+	}
+	return remote.DialRetry(ctx, remote.Config{
+		User:           user,
+		Host:           server.PublicIP,
+		Port:           p.args.MinecraftResource.GetSSHPort(),
+		PrivateKeyPath: p.args.SSHPrivateKeyPath,
+	}, sshConnectTimeout, sshConnectInterval)
+}
+
+func (p *MinectlProvisioner) DoRCON(ctx context.Context) error {
+	server, err := p.GetServer(ctx)
 	if err != nil {
 		return err
 	}
@@ -98,95 +189,170 @@ func (p *MinectlProvisioner) DoRCON() error {
 	return nil
 }
 
-func (p *MinectlProvisioner) UploadPlugin(plugin, destination string) error {
+func (p *MinectlProvisioner) UploadPlugin(ctx context.Context, plugin, destination string) error {
 	p.ui.Warn("Note: Plugins feature is still in beta.")
 	spinner := ui.NewSpinner(fmt.Sprintf("Uploading plugin to server (%s)...", common.Green(p.args.MinecraftResource.GetName())), p.ui)
 	spinner.FinalMessage = fmt.Sprintf("Plugin (%s) uploaded.", common.Green(p.args.MinecraftResource.GetName()))
 	spinner.ErrorMessage = fmt.Sprintf("Plugin (%s) not uploaded.", common.Green(p.args.MinecraftResource.GetName()))
 	spinner.Start()
-	err := p.auto.UploadPlugin(p.args.ID, p.args, plugin, destination)
+	_, err := await(ctx, func() (struct{}, error) {
+		return struct{}{}, p.auto.UploadPlugin(p.args.ID, p.args, plugin, destination)
+	})
 	spinner.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return interrupted(err, "uploading the plugin")
+	}
 	return err
 }
 
-func (p *MinectlProvisioner) UpdateServer() error {
+func (p *MinectlProvisioner) UpdateServer(ctx context.Context) error {
 	spinner := ui.NewSpinner(fmt.Sprintf(minecraftServerUpdatingTitle, common.Green(p.args.MinecraftResource.GetName())), p.ui)
 	spinner.FinalMessage = fmt.Sprintf(minecraftServerUpdateTitle, common.Green(p.args.MinecraftResource.GetName()))
 	spinner.ErrorMessage = fmt.Sprintf(minecraftServerNotUpdateTitle, common.Green(p.args.MinecraftResource.GetName()))
 	spinner.Start()
-	err := p.auto.UpdateServer(p.args.ID, p.args)
+	_, err := await(ctx, func() (struct{}, error) {
+		return struct{}{}, p.auto.UpdateServer(p.args.ID, p.args)
+	})
 	spinner.Stop(err)
-	return err
+	if err != nil && ctx.Err() != nil {
+		return interrupted(err, "updating the server")
+	}
+	if err != nil {
+		return err
+	}
+	if p.store != nil {
+		// the server is updated, a failed refresh of the state is only reported
+		server, err := p.GetServer(ctx)
+		if err != nil {
+			p.ui.Warn("Could not update the local state, please run 'minectl state import': " + err.Error())
+			return nil
+		}
+		if err := p.recordServer(server); err != nil {
+			p.ui.Warn("Could not update the local state: " + err.Error())
+		}
+	}
+	return nil
 }
 
-// wait that server is ready... Currently, only for Java based Editions (TCP), as Bedrock is UDP
-func (p *MinectlProvisioner) waitForMinecraftServerReady(server *automation.ResourceResults) error {
-	if p.args.MinecraftResource.GetEdition() != "bedrock" && p.args.MinecraftResource.GetEdition() != "nukkit" && p.args.MinecraftResource.GetEdition() != "powernukkit" {
-		spinner := ui.NewSpinner(minecraftServerStartingTitle, p.ui)
-		defer spinner.Stop(nil)
-		spinner.FinalMessage = minecraftServerStartTitle
-		spinner.ErrorMessage = minecraftServerNotStartTitle
-		spinner.Start()
-		check := net.JoinHostPort(server.PublicIP, strconv.Itoa(p.args.MinecraftResource.GetPort()))
-		checkCounter := 0
-		dialer := net.Dialer{Timeout: 15 * time.Second}
-
-		for checkCounter < startCheckCount {
-			timeout, err := dialer.Dial("tcp", check)
-			if err != nil {
-				time.Sleep(15 * time.Second)
-				checkCounter++
-			}
-			if timeout != nil {
-				err = timeout.Close()
-				if err != nil {
-					return errors.Errorf("timeout error: %s", err)
-				}
-				break
-			}
+// waitForMinecraftServerReady pings the server until Minecraft answers with
+// the version of the manifest or the wait timeout is reached.
+func (p *MinectlProvisioner) waitForMinecraftServerReady(ctx context.Context, server *automation.ResourceResults) error {
+	spinner := ui.NewSpinner(minecraftServerStartingTitle, p.ui)
+	spinner.FinalMessage = minecraftServerStartTitle
+	spinner.ErrorMessage = minecraftServerNotStartTitle
+	spinner.Start()
+	waitCtx, cancel := context.WithTimeout(ctx, p.waitTimeout)
+	defer cancel()
+	status, err := ping.Wait(waitCtx, p.args.MinecraftResource, server.PublicIP, pingInterval)
+	spinner.Stop(err)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		if waitCtx.Err() != nil {
+			return errors.Wrapf(err, "the server was not ready within %s (--wait-timeout)", p.waitTimeout)
 		}
+		return err
 	}
+	p.ui.Info(fmt.Sprintf("Minecraft %s is running: %d/%d players online", status.Version, status.Players, status.MaxPlayers))
 	return nil
 }
 
-func (p *MinectlProvisioner) CreateServer(wait bool) (*automation.ResourceResults, error) {
+// CreateServer creates the server and waits until Minecraft is reachable.
+// When the context is done after the server was created at the cloud
+// provider, an IncompleteServerError is returned, so the caller can roll the
+// server back.
+func (p *MinectlProvisioner) CreateServer(ctx context.Context, wait bool) (*automation.ResourceResults, error) {
 	spinner := ui.NewSpinner(fmt.Sprintf(minecraftServerCreatingTitle, common.Green(p.args.MinecraftResource.GetName())), p.ui)
 	spinner.FinalMessage = fmt.Sprintf(minecraftServerCreateTitle, common.Green(p.args.MinecraftResource.GetName()))
 	spinner.ErrorMessage = fmt.Sprintf(minecraftServerNotCreateTitle, common.Green(p.args.MinecraftResource.GetName()))
 	spinner.Start()
-	server, err := p.auto.CreateServer(p.args)
-	spinner.Stop(err)
-	if err != nil {
-		return nil, err
+	done := run(func() (*automation.ResourceResults, error) {
+		return p.auto.CreateServer(p.args)
+	})
+	var r result[*automation.ResourceResults]
+	select {
+	case r = <-done:
+		spinner.Stop(r.err)
+	case <-ctx.Done():
+		spinner.Stop(ctx.Err())
+		return nil, p.awaitInterruptedCreate(ctx, done)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	server := r.value
+	// the provisioner works on the new server from now on, e.g. to restore its worlds
+	p.args.ID = server.ID
+	if err := p.recordServer(server); err != nil {
+		p.ui.Warn("Could not record the server in the local state: " + err.Error())
 	}
 
-	if wait {
-		err := p.waitForMinecraftServerReady(server)
+	if wait && !provider.IsSimulated(p.auto) {
+		err := p.waitForMinecraftServerReady(ctx, server)
+		if ctx.Err() != nil {
+			return nil, &IncompleteServerError{Server: server, Err: interrupted(ctx.Err(), "waiting for the server to start")}
+		}
 		if err != nil {
-			return nil, err
+			return nil, &IncompleteServerError{Server: server, Err: err}
+		}
+	}
+	return server, nil
+}
+
+// awaitInterruptedCreate waits for the create call the context interrupted,
+// so a server created in the meantime is recorded and can be rolled back.
+func (p *MinectlProvisioner) awaitInterruptedCreate(ctx context.Context, done <-chan result[*automation.ResourceResults]) error {
+	err := interrupted(ctx.Err(), "creating the server")
+	p.ui.Warn("Waiting for the cloud provider to finish creating the server, so it can be rolled back...")
+	timer := time.NewTimer(createGracePeriod)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		if r.err != nil || r.value == nil {
+			return err
 		}
+		if recordErr := p.recordServer(r.value); recordErr != nil {
+			p.ui.Warn("Could not record the server in the local state: " + recordErr.Error())
+		}
+		return &IncompleteServerError{Server: r.value, Err: err}
+	case <-timer.C:
+		p.ui.Warn(fmt.Sprintf("The cloud provider did not answer within %s, please check for a half-created server with 'minectl list'.", createGracePeriod))
+		return err
 	}
-	return server, err
 }
 
-func (p *MinectlProvisioner) ListServer() ([]automation.ResourceResults, error) {
-	return p.auto.ListServer()
+func (p *MinectlProvisioner) ListServer(ctx context.Context) ([]automation.ResourceResults, error) {
+	return await(ctx, p.auto.ListServer)
 }
 
-func (p *MinectlProvisioner) DeleteServer() error {
+func (p *MinectlProvisioner) DeleteServer(ctx context.Context) error {
 	spinner := ui.NewSpinner(fmt.Sprintf(minecraftServerDeletingTitle, common.Green(p.args.MinecraftResource.GetName())), p.ui)
 	spinner.FinalMessage = fmt.Sprintf(minecraftServerDeleteTitle, common.Green(p.args.MinecraftResource.GetName()))
 	spinner.ErrorMessage = fmt.Sprintf(minecraftServerNotDeleteTitle, common.Green(p.args.MinecraftResource.GetName()))
 	spinner.Start()
-	err := p.auto.DeleteServer(p.args.ID, p.args)
+	_, err := await(ctx, func() (struct{}, error) {
+		return struct{}{}, p.auto.DeleteServer(p.args.ID, p.args)
+	})
 	spinner.Stop(err)
-	return err
+	if err != nil && ctx.Err() != nil {
+		return interrupted(err, "deleting the server")
+	}
+	if err != nil {
+		return err
+	}
+	if p.store != nil {
+		if err := p.store.Remove(p.args.ID, p.args.MinecraftResource.GetCloud()); err != nil {
+			p.ui.Warn("Could not remove the server from the local state: " + err.Error())
+		}
+	}
+	return nil
 }
 
 func ListProvisioner(options *MinectlProvisionerListOpts, u *ui.UI) (*MinectlProvisioner, error) {
 	u.Info(minecraftListServersTitle)
-	cloudProvider, err := getProvisioner(options.Provider, options.Region)
-	u.Info(fmt.Sprintf(minecraftSelectedCloudProviderTitle, cloud.GetCloudProviderFullName(options.Provider)))
+	cloudProvider, err := provider.New(options.Provider, options.Region, options.Profile.For(options.Provider))
+	u.Info(fmt.Sprintf(minecraftSelectedCloudProviderTitle, provider.FullName(options.Provider)))
 	if err != nil {
 		return nil, err
 	}
@@ -197,122 +363,29 @@ func ListProvisioner(options *MinectlProvisionerListOpts, u *ui.UI) (*MinectlPro
 	return p, nil
 }
 
-func getProvisioner(provider, region string) (automation.Automation, error) { //nolint:gocyclo // large switch is inherent to provider selection
-	switch provider {
-	case model.PROVIDER_HETZNER:
-		cloudProvider, err := hetzner.NewHetzner(os.Getenv("HCLOUD_TOKEN"))
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_DIGITALOCEAN:
-		cloudProvider, err := do.NewDigitalOcean(os.Getenv("DIGITALOCEAN_TOKEN"))
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_CIVO:
-		cloudProvider, err := civo.NewCivo(os.Getenv("CIVO_TOKEN"), region)
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_SCALEWAY:
-		cloudProvider, err := scaleway.NewScaleway(os.Getenv("ACCESS_KEY"), os.Getenv("SECRET_KEY"), os.Getenv("ORGANISATION_ID"), region)
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_AKAMAI:
-		cloudProvider, err := akamai.NewAkamai(os.Getenv("LINODE_TOKEN"))
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_OVH:
-		cloudProvider, err := ovh.NewOVHcloud(os.Getenv("OVH_ENDPOINT"), os.Getenv("APPLICATION_KEY"), os.Getenv("APPLICATION_SECRET"), os.Getenv("CONSUMER_KEY"), os.Getenv("SERVICENAME"), region)
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_GCE:
-		cloudProvider, err := gce.NewGCE(region)
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_VULTR:
-		cloudProvider, err := vultr.NewVultr(os.Getenv("VULTR_API_KEY"))
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_AZURE:
-		cloudProvider, err := azure.NewAzure()
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_OCI:
-		cloudProvider, err := oci.NewOCI()
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_AWS:
-		cloudProvider, err := aws.NewAWS(region)
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_VEXXHOST:
-		cloudProvider, err := vexxhost.NewVEXXHOST()
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_FUGA:
-		cloudProvider, err := fuga.NewFuga()
-		if err != nil {
-			return nil, err
-		}
-		return cloudProvider, nil
-	case model.PROVIDER_EXOSCALE:
-		cloudProvider, err := exoscale.NewExoscale(os.Getenv("EXOSCALE_API_KEY"), os.Getenv("EXOSCALE_API_SECRET"))
+func NewProvisioner(options *MinectlProvisionerOpts, u *ui.UI) (*MinectlProvisioner, error) {
+	document := options.Document
+	if document == nil {
+		documents, err := manifest.Load(options.ManifestPath)
 		if err != nil {
 			return nil, err
 		}
-		return cloudProvider, nil
-	case model.PROVIDER_MULTIPASS:
-		cloudProvider, err := multipass.NewMultipass()
+		document, err = manifest.Select(documents, options.Name)
 		if err != nil {
 			return nil, err
 		}
-		return cloudProvider, nil
-
-	default:
-		return nil, errors.Errorf("Could not find provider %s", provider)
-	}
-}
-
-func NewProvisioner(options *MinectlProvisionerOpts, u *ui.UI) (*MinectlProvisioner, error) {
-	var cloudProvider automation.Automation
-
-	minecraftResource, err := manifest.NewMinecraftResource(options.ManifestPath)
-	if err != nil {
-		return nil, err
 	}
 	args := automation.ServerArgs{
-		MinecraftResource: minecraftResource,
+		MinecraftResource: document.Resource,
 		ID:                options.ID,
 		SSHPrivateKeyPath: options.SSHPrivateKeyPath,
 	}
-	cloudProvider, err = getProvisioner(args.MinecraftResource.GetCloud(), args.MinecraftResource.GetRegion())
+	cloudProvider, err := provider.New(args.MinecraftResource.GetCloud(), args.MinecraftResource.GetRegion(), options.Profile.For(args.MinecraftResource.GetCloud()))
 	if err != nil {
 		return nil, err
 	}
 
-	u.Info(fmt.Sprintf(minecraftSelectedCloudProviderTitle, cloud.GetCloudProviderFullName(args.MinecraftResource.GetCloud())))
+	u.Info(fmt.Sprintf(minecraftSelectedCloudProviderTitle, provider.FullName(args.MinecraftResource.GetCloud())))
 
 	if args.MinecraftResource.IsProxyServer() {
 		u.Info(fmt.Sprintf(minecraftProxyTitle, args.MinecraftResource.GetEdition()))
@@ -321,9 +394,16 @@ func NewProvisioner(options *MinectlProvisionerOpts, u *ui.UI) (*MinectlProvisio
 	}
 
 	p := &MinectlProvisioner{
-		auto: cloudProvider,
-		args: args,
-		ui:   u,
+		auto:        cloudProvider,
+		args:        args,
+		ui:          u,
+		document:    document,
+		store:       options.Store,
+		credentials: options.Profile.For(args.MinecraftResource.GetCloud()),
+		waitTimeout: options.WaitTimeout,
+	}
+	if p.waitTimeout <= 0 {
+		p.waitTimeout = DefaultWaitTimeout
 	}
 	return p, nil
 }
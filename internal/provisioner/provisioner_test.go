@@ -0,0 +1,381 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dirien/minectl/internal/logging"
+	"github.com/dirien/minectl/internal/state"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/pkg/provider/fake"
+)
+
+const testManifest = "testdata/server-fake.yaml"
+
+func newTestUI(t *testing.T) *ui.UI {
+	t.Helper()
+	l, err := logging.NewLogging("error", "console", true)
+	if err != nil {
+		t.Fatalf("failed to create logging: %v", err)
+	}
+	return ui.NewUI(true, l)
+}
+
+// setupFake points the fake provider to a temporary state file and returns a fresh store.
+func setupFake(t *testing.T, fail string) *state.Store {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(fake.EnvState, filepath.Join(dir, "fake.json"))
+	t.Setenv(fake.EnvFail, fail)
+	t.Setenv(fake.EnvLatency, "")
+	return state.NewStore(filepath.Join(dir, state.FileName))
+}
+
+func newTestProvisioner(t *testing.T, store *state.Store, id string) *MinectlProvisioner {
+	t.Helper()
+	p, err := NewProvisioner(&MinectlProvisionerOpts{
+		ManifestPath: testManifest,
+		ID:           id,
+		Store:        store,
+	}, newTestUI(t))
+	if err != nil {
+		t.Fatalf("NewProvisioner() error = %v", err)
+	}
+	return p
+}
+
+func TestCreateServer(t *testing.T) {
+	tests := []struct {
+		name      string
+		fail      string
+		wantErr   bool
+		wantState int
+	}{
+		{"create records the server", "", false, 1},
+		{"failed create records nothing", "create", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := setupFake(t, tt.fail)
+			p := newTestProvisioner(t, store, "")
+
+			res, err := p.CreateServer(t.Context(), true)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateServer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, fake.ErrInjected) {
+				t.Errorf("got error %v, want ErrInjected", err)
+			}
+			servers, err := store.List()
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(servers) != tt.wantState {
+				t.Fatalf("got %d servers in the state, want %d", len(servers), tt.wantState)
+			}
+			if tt.wantState == 1 {
+				if servers[0].ID != res.ID || servers[0].Cloud != "fake" || servers[0].ManifestHash == "" {
+					t.Errorf("unexpected state entry %+v", servers[0])
+				}
+			}
+		})
+	}
+}
+
+func TestServerLifecycle(t *testing.T) {
+	store := setupFake(t, "")
+	created, err := newTestProvisioner(t, store, "").CreateServer(t.Context(), false)
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	p := newTestProvisioner(t, store, created.ID)
+	got, err := p.GetServer(t.Context())
+	if err != nil {
+		t.Fatalf("GetServer() error = %v", err)
+	}
+	if got.Name != "minecraft-server" {
+		t.Errorf("got name %q, want %q", got.Name, "minecraft-server")
+	}
+
+	servers, err := p.ListServer(t.Context())
+	if err != nil {
+		t.Fatalf("ListServer() error = %v", err)
+	}
+	if len(servers) != 1 {
+		t.Errorf("got %d servers, want 1", len(servers))
+	}
+
+	if err := p.UpdateServer(t.Context()); err != nil {
+		t.Fatalf("UpdateServer() error = %v", err)
+	}
+
+	plugin := filepath.Join(t.TempDir(), "plugin.jar")
+	if err := os.WriteFile(plugin, []byte("jar"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UploadPlugin(t.Context(), plugin, "/minecraft/plugins"); err != nil {
+		t.Fatalf("UploadPlugin() error = %v", err)
+	}
+
+	if err := p.DeleteServer(t.Context()); err != nil {
+		t.Fatalf("DeleteServer() error = %v", err)
+	}
+	if _, err := store.Find("minecraft-server", ""); !errors.Is(err, state.ErrNotFound) {
+		t.Errorf("expected the server to be removed from the state, got %v", err)
+	}
+}
+
+func TestUpdateServerStateRefreshFails(t *testing.T) {
+	store := setupFake(t, "")
+	created, err := newTestProvisioner(t, store, "").CreateServer(t.Context(), false)
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	// the server is updated, only the refresh of the state fails
+	t.Setenv(fake.EnvFail, string(fake.OperationGet))
+	if err := newTestProvisioner(t, store, created.ID).UpdateServer(t.Context()); err != nil {
+		t.Fatalf("UpdateServer() error = %v", err)
+	}
+}
+
+func TestImportServer(t *testing.T) {
+	store := setupFake(t, "")
+	created, err := newTestProvisioner(t, nil, "").CreateServer(t.Context(), false)
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if _, err := store.Find("minecraft-server", ""); !errors.Is(err, state.ErrNotFound) {
+		t.Fatalf("expected no state entry without a store, got %v", err)
+	}
+
+	if _, err := newTestProvisioner(t, store, created.ID).ImportServer(t.Context()); err != nil {
+		t.Fatalf("ImportServer() error = %v", err)
+	}
+	server, err := store.Find("minecraft-server", "fake")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if server.ID != created.ID {
+		t.Errorf("got ID %q, want %q", server.ID, created.ID)
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	store := setupFake(t, "")
+	p := newTestProvisioner(t, store, "")
+
+	steps := []struct {
+		name       string
+		before     func(t *testing.T)
+		wantAction Action
+	}{
+		{"missing server is created", nil, ActionCreate},
+		{"tracked server is in sync", nil, ActionNone},
+		{
+			name: "changed manifest is updated",
+			before: func(t *testing.T) {
+				t.Helper()
+				if err := store.Update(func(servers []state.Server) ([]state.Server, error) {
+					servers[0].ManifestHash = "outdated"
+					return servers, nil
+				}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantAction: ActionUpdate,
+		},
+		{"updated server is in sync", nil, ActionNone},
+		{
+			name: "untracked server is updated",
+			before: func(t *testing.T) {
+				t.Helper()
+				servers, err := store.List()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := store.Remove(servers[0].ID, servers[0].Cloud); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantAction: ActionUpdate,
+		},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if step.before != nil {
+				step.before(t)
+			}
+			plan, err := p.Plan(t.Context())
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			if plan.Action != step.wantAction {
+				t.Fatalf("got action %s (%s), want %s", plan.Action, plan.Reason, step.wantAction)
+			}
+			if _, err := p.Apply(t.Context(), plan, false); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateServerInterrupted(t *testing.T) {
+	store := setupFake(t, "")
+	t.Setenv(fake.EnvLatency, "200ms")
+	p := newTestProvisioner(t, store, "")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := p.CreateServer(ctx, false)
+	var incomplete *IncompleteServerError
+	if !errors.As(err, &incomplete) {
+		t.Fatalf("expected IncompleteServerError, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the error to wrap context.Canceled, got %v", err)
+	}
+	if _, err := store.Find("minecraft-server", ""); err != nil {
+		t.Fatalf("expected the incomplete server in the state, got %v", err)
+	}
+
+	if err := p.Rollback(t.Context(), incomplete.Server); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if _, err := store.Find("minecraft-server", ""); !errors.Is(err, state.ErrNotFound) {
+		t.Errorf("expected the server to be removed from the state, got %v", err)
+	}
+}
+
+func TestListServerTimeout(t *testing.T) {
+	setupFake(t, "")
+	t.Setenv(fake.EnvLatency, "200ms")
+	p := newTestProvisioner(t, nil, "")
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := p.ListServer(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("ListServer() returned after %s, expected it to return on the timeout", elapsed)
+	}
+	// let the abandoned call finish before the state file is removed
+	time.Sleep(300 * time.Millisecond)
+}
+
+func TestStopStartServer(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceOnly bool
+		wantPower   string
+	}{
+		{"power off", false, "stopped"},
+		{"service only", true, "running"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := setupFake(t, "")
+			res, err := newTestProvisioner(t, store, "").CreateServer(t.Context(), true)
+			if err != nil {
+				t.Fatalf("CreateServer() error = %v", err)
+			}
+			p := newTestProvisioner(t, store, res.ID)
+
+			if err := p.StopServer(t.Context(), tt.serviceOnly); err != nil {
+				t.Fatalf("StopServer() error = %v", err)
+			}
+			power, err := p.PowerState(t.Context(), res.ID)
+			if err != nil {
+				t.Fatalf("PowerState() error = %v", err)
+			}
+			if power != tt.wantPower {
+				t.Errorf("got power state %q after stop, want %q", power, tt.wantPower)
+			}
+			if status, err := p.Status(t.Context()); err != nil || status.Online() {
+				t.Errorf("Status() = %+v, %v, want offline", status, err)
+			}
+
+			if _, err := p.StartServer(t.Context(), tt.serviceOnly, true); err != nil {
+				t.Fatalf("StartServer() error = %v", err)
+			}
+			if status, err := p.Status(t.Context()); err != nil || !status.Online() {
+				t.Errorf("Status() = %+v, %v, want online", status, err)
+			}
+
+			if _, err := p.RestartServer(t.Context(), tt.serviceOnly, true); err != nil {
+				t.Fatalf("RestartServer() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestPushedWorlds(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		bedrock bool
+		want    []string
+	}{
+		{"java world with its dimensions", "world", false, []string{"'world'", "'world_nether'", "'world_the_end'"}},
+		{"bedrock world", "worlds/Bedrock level", true, []string{"'worlds/Bedrock level'"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pushedWorlds(tt.target, tt.bedrock); !slices.Equal(got, tt.want) {
+				t.Errorf("pushedWorlds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// canceledAfter is a context which is canceled right after the operations
+// succeeded: Done never fires, but Err reports the cancellation.
+type canceledAfter struct {
+	context.Context
+}
+
+func (canceledAfter) Err() error {
+	return context.Canceled
+}
+
+func TestDeleteServerCanceledAfterSuccess(t *testing.T) {
+	store := setupFake(t, "")
+	created, err := newTestProvisioner(t, store, "").CreateServer(t.Context(), false)
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if err := newTestProvisioner(t, store, created.ID).DeleteServer(canceledAfter{t.Context()}); err != nil {
+		t.Fatalf("DeleteServer() error = %v", err)
+	}
+	if _, err := store.Find("minecraft-server", ""); !errors.Is(err, state.ErrNotFound) {
+		t.Errorf("expected the server to be removed from the state, got %v", err)
+	}
+}
+
+func TestInterrupted(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"succeeded", nil, ""},
+		{"timed out", context.DeadlineExceeded, "timed out while deleting the server: context deadline exceeded"},
+		{"interrupted", context.Canceled, "interrupted while deleting the server: context canceled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := interrupted(tt.err, "deleting the server")
+			if got := fmt.Sprint(err); (err == nil) != (tt.want == "") || (err != nil && got != tt.want) {
+				t.Errorf("interrupted() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package provisioner
+
+import (
+	"context"
+	"time"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl-sdk/model"
+	"github.com/dirien/minectl/internal/ping"
+	"github.com/dirien/minectl/internal/rcon"
+	"github.com/dirien/minectl/pkg/provider"
+)
+
+// probeTimeout limits the pings of a status query, so an offline server does
+// not block it.
+const probeTimeout = 5 * time.Second
+
+// RCON states of a server.
+const (
+	RCONDisabled    = "disabled"
+	RCONReachable   = "reachable"
+	RCONUnreachable = "unreachable"
+)
+
+// ServerStatus is the live health of a server.
+type ServerStatus struct {
+	Server   *automation.ResourceResults
+	Resource *model.MinecraftResource
+	// Ping is nil when the server is offline.
+	Ping *ping.Status
+	// Err is the reason the server is offline.
+	Err  error
+	RCON string
+}
+
+// Online returns true when Minecraft answered the ping.
+func (s *ServerStatus) Online() bool {
+	return s.Ping != nil
+}
+
+// Status fetches the server from the cloud provider and pings it.
+func (p *MinectlProvisioner) Status(ctx context.Context) (*ServerStatus, error) {
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.Probe(ctx, p.args.MinecraftResource, server), nil
+}
+
+// Probe pings Minecraft and RCON of the server, which runs the manifest.
+func (p *MinectlProvisioner) Probe(ctx context.Context, resource *model.MinecraftResource, server *automation.ResourceResults) *ServerStatus {
+	status := &ServerStatus{Server: server, Resource: resource, RCON: RCONDisabled}
+	if pinger, ok := p.auto.(provider.Pinger); ok {
+		var answer *provider.PingStatus
+		answer, status.Err = pinger.Ping(server.ID)
+		if answer != nil {
+			status.Ping = &ping.Status{
+				Version:    answer.Version,
+				MOTD:       answer.MOTD,
+				Players:    answer.Players,
+				MaxPlayers: answer.MaxPlayers,
+				Sample:     answer.Sample,
+				Latency:    answer.Latency,
+			}
+		}
+		if resource.HasRCON() {
+			status.RCON = RCONUnreachable
+			if status.Err == nil {
+				status.RCON = RCONReachable
+			}
+		}
+		return status
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	status.Ping, status.Err = ping.Server(pingCtx, resource, server.PublicIP)
+	if resource.HasRCON() {
+		rconCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		defer cancel()
+		status.RCON = RCONReachable
+		if err := rcon.Check(rconCtx, server.PublicIP, resource.GetRCONPassword(), resource.GetRCONPort()); err != nil {
+			status.RCON = RCONUnreachable
+		}
+	}
+	return status
+}
@@ -0,0 +1,192 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/dirien/minectl-sdk/common"
+	"github.com/dirien/minectl/internal/ping"
+	"github.com/dirien/minectl/internal/remote"
+	"github.com/dirien/minectl/internal/ui"
+	"github.com/dirien/minectl/internal/world"
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/pkg/errors"
+)
+
+// sizeWorldsCmd prints the size in bytes of the worlds archiveWorldsCmd archives.
+const sizeWorldsCmd = `cd ` + minecraftDir + ` || exit 1; ` +
+	`level=$(sed -n 's/^level-name=//p' server.properties 2>/dev/null); level=${level:-world}; ` +
+	`sudo du -scb $(ls -d "$level" "${level}_nether" "${level}_the_end" worlds 2>/dev/null) | tail -n 1 | cut -f 1`
+
+// levelNameCmd prints level-name of server.properties.
+const levelNameCmd = `sed -n 's/^level-name=//p' ` + minecraftDir + `/server.properties 2>/dev/null`
+
+// PullWorld copies the worlds of the server into dir. Saving is turned off via
+// RCON during the copy, so the files are consistent. It returns the copied
+// world directories.
+func (p *MinectlProvisioner) PullWorld(ctx context.Context, dir string) ([]string, error) {
+	resource := p.args.MinecraftResource
+	if resource.IsProxyServer() {
+		return nil, errors.New("proxy servers have no worlds")
+	}
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.connect(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	if resource.HasRCON() {
+		resume, err := p.pauseSaving(ctx, server)
+		if err != nil {
+			return nil, err
+		}
+		defer resume()
+	} else {
+		p.ui.Warn("RCON is not enabled in the manifest, the worlds are copied while Minecraft saves them.")
+	}
+
+	// the size is only used for the progress bar
+	var total int64
+	if out, err := client.Run(ctx, sizeWorldsCmd); err == nil {
+		total, _ = strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	}
+	progress := ui.NewProgress(fmt.Sprintf("Pulling the worlds of server (%s)...", common.Green(resource.GetName())), total, p.ui)
+	progress.ErrorMessage = fmt.Sprintf("The worlds of server (%s) not pulled.", common.Green(resource.GetName()))
+	progress.Start()
+
+	r, w := io.Pipe()
+	streamed := make(chan error, 1)
+	go func() {
+		err := client.Stream(ctx, archiveWorldsCmd, nil, w)
+		_ = w.CloseWithError(err)
+		streamed <- err
+	}()
+	worlds, err := world.Untar(io.TeeReader(r, progress), dir)
+	_ = r.CloseWithError(errors.New("pull aborted"))
+	if streamErr := <-streamed; streamErr != nil && err == nil {
+		err = streamErr
+	}
+	if err == nil && len(worlds) == 0 {
+		err = errors.New("the server has no worlds")
+	}
+	if err == nil {
+		progress.FinalMessage = fmt.Sprintf("Pulled %s of server (%s) into %s.", strings.Join(worlds, ", "), common.Green(resource.GetName()), dir)
+	}
+	progress.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return nil, interrupted(err, "pulling the worlds")
+	}
+	return worlds, err
+}
+
+// PushWorld replaces the world of the server with the world in dir. The
+// nether and end dimensions of the old world are deleted on Java servers, as
+// they do not belong to the pushed world. The level.dat of the world is
+// validated and, unless force is set, worlds saved by a newer Minecraft
+// version than the server runs are rejected, before Minecraft is stopped.
+func (p *MinectlProvisioner) PushWorld(ctx context.Context, dir string, force, wait bool) error {
+	resource := p.args.MinecraftResource
+	if resource.IsProxyServer() {
+		return errors.New("proxy servers have no worlds")
+	}
+	if _, err := p.ValidateWorld(dir, force); err != nil {
+		return err
+	}
+	// Nukkit servers keep their worlds below worlds, but in the Java edition format
+	bedrock := resource.GetEdition() == "bedrock"
+	size, err := world.Size(dir)
+	if err != nil {
+		return err
+	}
+
+	server, err := p.GetServer(ctx)
+	if err != nil {
+		return err
+	}
+	client, err := p.connect(ctx, server)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	out, err := client.Run(ctx, levelNameCmd)
+	if err != nil {
+		return errors.Wrap(err, "failed to read level-name of server.properties")
+	}
+	name := strings.TrimSpace(out)
+	target := defaultString(name, "world")
+	switch {
+	case bedrock:
+		target = path.Join("worlds", defaultString(name, "Bedrock level"))
+	case ping.IsBedrock(resource.GetEdition()):
+		target = path.Join("worlds", target)
+	}
+	if target == "" || strings.HasPrefix(target, "/") || strings.Contains(target, "..") {
+		return errors.Errorf("invalid level-name %q in server.properties", name)
+	}
+
+	progress := ui.NewProgress(fmt.Sprintf("Pushing %s to server (%s)...", dir, common.Green(resource.GetName())), size, p.ui)
+	progress.FinalMessage = fmt.Sprintf("Pushed %s to server (%s).", dir, common.Green(resource.GetName()))
+	progress.ErrorMessage = fmt.Sprintf("%s not pushed to server (%s).", dir, common.Green(resource.GetName()))
+	progress.Start()
+	r, w := io.Pipe()
+	go func() {
+		_ = w.CloseWithError(world.Tar(w, dir, target))
+	}()
+	err = replaceWorlds(ctx, client, pushedWorlds(target, ping.IsBedrock(resource.GetEdition())), io.TeeReader(r, progress), "push the world again")
+	_ = r.CloseWithError(errors.New("push aborted"))
+	progress.Stop(err)
+	if err != nil && ctx.Err() != nil {
+		return interrupted(err, "pushing the world")
+	}
+	if err != nil || !wait || provider.IsSimulated(p.auto) {
+		return err
+	}
+	if err := p.waitForMinecraftServerReady(ctx, server); err != nil {
+		if ctx.Err() != nil {
+			return interrupted(err, "waiting for the server to start")
+		}
+		return err
+	}
+	return nil
+}
+
+// ValidateWorld reads level.dat of the world in dir and, unless force is set,
+// rejects worlds saved by a newer Minecraft version than the server runs.
+func (p *MinectlProvisioner) ValidateWorld(dir string, force bool) (*world.Level, error) {
+	resource := p.args.MinecraftResource
+	level, err := world.ReadLevel(dir, resource.GetEdition() == "bedrock")
+	if err != nil {
+		return nil, err
+	}
+	if !force {
+		if err := world.CheckVersion(level, resource.GetVersion()); err != nil {
+			return nil, errors.Wrap(err, "use --force to push it anyway")
+		}
+	}
+	return level, nil
+}
+
+// pushedWorlds returns the quoted directories a push of the world target
+// replaces: the world and, like archiveWorldsCmd, its nether and end
+// dimensions on Java servers.
+func pushedWorlds(target string, bedrock bool) []string {
+	if bedrock {
+		return []string{remote.Quote(target)}
+	}
+	return []string{remote.Quote(target), remote.Quote(target + "_nether"), remote.Quote(target + "_the_end")}
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -1,12 +1,22 @@
 package rcon
 
 import (
+	"context"
 	"fmt"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
 
 	mcnet "github.com/Tnze/go-mc/net"
 	prompt "github.com/c-bata/go-prompt"
+	"github.com/pkg/errors"
 )
 
+// RCON packet types, see https://minecraft.wiki/w/RCON
+const loginType = 3
+
 type RCON struct {
 	server   string
 	port     int
@@ -67,3 +77,74 @@ func NewRCON(server, passwort string, port int) *RCON {
 	r.prompt = p
 	return r
 }
+
+// Check logs in to RCON, to verify that it is reachable and accepts the password.
+func Check(ctx context.Context, server, password string, port int) error {
+	conn, stop, err := login(ctx, server, password, port)
+	if err != nil {
+		return err
+	}
+	defer stop()
+	return conn.Close()
+}
+
+// Command runs a single command via RCON and returns the response.
+func Command(ctx context.Context, server, password string, port int, cmd string) (string, error) {
+	conn, stop, err := login(ctx, server, password, port)
+	if err != nil {
+		return "", err
+	}
+	defer stop()
+	defer conn.Close()
+	if err := conn.Cmd(cmd); err != nil {
+		return "", errors.Wrapf(err, "failed to run %q via RCON", cmd)
+	}
+	resp, err := conn.Resp()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read the response to %q via RCON", cmd)
+	}
+	return resp, nil
+}
+
+var listRegex = regexp.MustCompile(`There are (\d+)`)
+
+// Players parses the number of players online from the response to the list
+// command, e.g. "There are 2 of a max of 20 players online: alex, steve".
+func Players(listResponse string) (int, error) {
+	match := listRegex.FindStringSubmatch(listResponse)
+	if match == nil {
+		return 0, errors.Errorf("unexpected response to the list command: %q", listResponse)
+	}
+	return strconv.Atoi(match[1])
+}
+
+// login connects to RCON and logs in. Closing the context aborts the
+// connection until stop is called.
+func login(ctx context.Context, server, password string, port int) (*mcnet.RCONConn, func() bool, error) {
+	var dialer net.Dialer
+	addr := net.JoinHostPort(server, strconv.Itoa(port))
+	socket, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to connect to RCON at %s", addr)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = socket.SetDeadline(time.Now())
+	})
+
+	conn := &mcnet.RCONConn{Conn: socket, ReqID: rand.Int32()} //nolint:gosec
+	if err := conn.WritePacket(conn.ReqID, loginType, password); err != nil {
+		stop()
+		_ = socket.Close()
+		return nil, nil, errors.Wrap(err, "failed to log in to RCON")
+	}
+	id, _, _, err := conn.ReadPacket()
+	if err == nil && id != conn.ReqID {
+		err = errors.New("RCON rejected the password")
+	}
+	if err != nil {
+		stop()
+		_ = socket.Close()
+		return nil, nil, errors.Wrap(err, "failed to log in to RCON")
+	}
+	return conn, stop, nil
+}
@@ -0,0 +1,102 @@
+package rcon
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	mcnet "github.com/Tnze/go-mc/net"
+)
+
+// serveRCON accepts RCON logins with the password and answers the list command.
+func serveRCON(t *testing.T, password string) (string, int) {
+	t.Helper()
+	listener, err := mcnet.ListenRCON("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if conn.AcceptLogin(password) == nil {
+				if cmd, err := conn.AcceptCmd(); err == nil {
+					resp := "Unknown command"
+					if cmd == "list" {
+						resp = "There are 2 of a max of 20 players online: alex, steve"
+					}
+					_ = conn.RespCmd(resp)
+				}
+			}
+			_ = conn.Close()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p
+}
+
+func TestCheck(t *testing.T) {
+	host, port := serveRCON(t, "s3cr3t")
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"valid password", "s3cr3t", false},
+		{"wrong password", "wrong", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+			err := Check(ctx, host, tt.password, port)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	host, port := serveRCON(t, "s3cr3t")
+	resp, err := Command(t.Context(), host, "s3cr3t", port, "list")
+	if err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+	players, err := Players(resp)
+	if err != nil {
+		t.Fatalf("Players() error = %v", err)
+	}
+	if players != 2 {
+		t.Errorf("got %d players, want 2", players)
+	}
+}
+
+func TestPlayers(t *testing.T) {
+	tests := []struct {
+		resp    string
+		want    int
+		wantErr bool
+	}{
+		{"There are 0 of a max of 20 players online: ", 0, false},
+		{"There are 3 of a max of 100 players online: alex, steve, notch", 3, false},
+		{"Unknown command", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resp, func(t *testing.T) {
+			got, err := Players(tt.resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Players() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d players, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package remote runs commands and writes files on the servers minectl
+// provisions, using SSH with the key of the manifest.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dirien/minectl/pkg/provider"
+	"github.com/melbahja/goph"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// Config holds the SSH connection settings of a server.
+type Config struct {
+	User           string
+	Host           string
+	Port           int
+	PrivateKeyPath string
+}
+
+// SSH is a provider.RemoteClient connected via SSH.
+type SSH struct {
+	client *goph.Client
+}
+
+var _ provider.RemoteClient = (*SSH)(nil)
+
+// Dial connects to the server.
+func Dial(cfg Config) (*SSH, error) {
+	auth, err := goph.Key(cfg.PrivateKeyPath, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the SSH key %s", cfg.PrivateKeyPath)
+	}
+	client, err := goph.NewConn(&goph.Config{
+		User:     cfg.User,
+		Addr:     cfg.Host,
+		Port:     uint(cfg.Port), //nolint:gosec
+		Auth:     auth,
+		Timeout:  goph.DefaultTimeout,
+		Callback: ssh.InsecureIgnoreHostKey(), //nolint:gosec
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %s", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+	}
+	return &SSH{client: client}, nil
+}
+
+// DialRetry connects to the server, retrying until the timeout is reached or
+// the context is done. Freshly created servers need some time until SSH is
+// reachable.
+func DialRetry(ctx context.Context, cfg Config, timeout, interval time.Duration) (*SSH, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		client, err := Dial(cfg)
+		if err == nil || time.Now().Add(interval).After(deadline) {
+			return client, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (s *SSH) Run(ctx context.Context, cmd string) (string, error) {
+	out, err := s.client.RunContext(ctx, cmd)
+	if err != nil {
+		return string(out), errors.Wrapf(err, "failed to run %q: %s", cmd, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+func (s *SSH) Stream(ctx context.Context, cmd string, stdin io.Reader, stdout io.Writer) error {
+	session, err := s.client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	var stderr bytes.Buffer
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = &stderr
+	if err := session.Start(cmd); err != nil {
+		return errors.Wrapf(err, "failed to run %q", cmd)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		_ = session.Signal(ssh.SIGTERM)
+		_ = session.Close()
+		return ctx.Err()
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to run %q: %s", cmd, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// WriteFile creates the file with the mode via sudo, as the user of some
+// providers is not root, and streams the content into it. The content never
+// lands in a temporary file other users could read.
+func (s *SSH) WriteFile(dst string, content []byte, mode os.FileMode) error {
+	quoted := Quote(dst)
+	cmd := fmt.Sprintf("sudo install -D -m %o /dev/null %s && sudo tee %s > /dev/null", mode.Perm(), quoted, quoted)
+	if err := s.Stream(context.Background(), cmd, bytes.NewReader(content), nil); err != nil {
+		return errors.Wrapf(err, "failed to write %s", dst)
+	}
+	return nil
+}
+
+func (s *SSH) Close() error {
+	return s.client.Close()
+}
+
+// Quote quotes the argument for the shell.
+func Quote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
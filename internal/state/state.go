@@ -0,0 +1,231 @@
+// Package state keeps a local inventory of the servers created by minectl, so
+// follow-up commands can resolve a server by its name instead of its ID.
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FileName is the name of the state file inside the minectl home folder.
+	FileName = "state.json"
+
+	lockTimeout    = 30 * time.Second
+	lockRetryDelay = 100 * time.Millisecond
+)
+
+// ErrNotFound is returned when no server matches a lookup.
+var ErrNotFound = errors.New("server not found in the local state")
+
+// Server is a single inventory entry.
+type Server struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Cloud        string    `json:"cloud"`
+	Region       string    `json:"region"`
+	PublicIP     string    `json:"public_ip"`
+	Tags         string    `json:"tags,omitempty"`
+	ManifestPath string    `json:"manifest_path"`
+	ManifestHash string    `json:"manifest_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type document struct {
+	Servers []Server `json:"servers"`
+}
+
+// Store reads and writes the state file. Every access is guarded by a file
+// lock, so concurrent minectl invocations can not corrupt the inventory.
+type Store struct {
+	path string
+}
+
+// NewStore creates a store backed by the file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the location of the state file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// List returns all servers sorted by name.
+func (s *Store) List() ([]Server, error) {
+	var servers []Server
+	err := s.withLock(false, func() error {
+		doc, err := s.read()
+		if err != nil {
+			return err
+		}
+		servers = doc.Servers
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		if servers[i].Name == servers[j].Name {
+			return servers[i].Cloud < servers[j].Cloud
+		}
+		return servers[i].Name < servers[j].Name
+	})
+	return servers, nil
+}
+
+// Find looks up a server by name. The cloud is optional and narrows the
+// search when the same name is used on several providers.
+func (s *Store) Find(name, cloud string) (*Server, error) {
+	servers, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var found []Server
+	for _, server := range servers {
+		if server.Name != name {
+			continue
+		}
+		if cloud != "" && server.Cloud != cloud {
+			continue
+		}
+		found = append(found, server)
+	}
+	switch len(found) {
+	case 0:
+		return nil, errors.Wrapf(ErrNotFound, "no server named %q", name)
+	case 1:
+		return &found[0], nil
+	default:
+		return nil, errors.Errorf("found %d servers named %q, please provide the id via --id", len(found), name)
+	}
+}
+
+// Put adds the server or replaces the entry with the same ID.
+func (s *Store) Put(server Server) error {
+	return s.Update(func(servers []Server) ([]Server, error) {
+		now := time.Now().UTC()
+		server.UpdatedAt = now
+		for i := range servers {
+			if servers[i].ID == server.ID && servers[i].Cloud == server.Cloud {
+				if server.CreatedAt.IsZero() {
+					server.CreatedAt = servers[i].CreatedAt
+				}
+				servers[i] = server
+				return servers, nil
+			}
+		}
+		if server.CreatedAt.IsZero() {
+			server.CreatedAt = now
+		}
+		return append(servers, server), nil
+	})
+}
+
+// Remove deletes the entry with the given ID and cloud, as IDs are only
+// unique per provider. Removing an unknown entry is not an error.
+func (s *Store) Remove(id, cloud string) error {
+	return s.Update(func(servers []Server) ([]Server, error) {
+		kept := servers[:0]
+		for _, server := range servers {
+			if server.ID != id || server.Cloud != cloud {
+				kept = append(kept, server)
+			}
+		}
+		return kept, nil
+	})
+}
+
+// Update runs fn with the current servers while holding an exclusive lock and
+// writes the returned slice back to disk.
+func (s *Store) Update(fn func(servers []Server) ([]Server, error)) error {
+	return s.withLock(true, func() error {
+		doc, err := s.read()
+		if err != nil {
+			return err
+		}
+		servers, err := fn(doc.Servers)
+		if err != nil {
+			return err
+		}
+		doc.Servers = servers
+		return s.write(doc)
+	})
+}
+
+func (s *Store) withLock(exclusive bool, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return errors.Wrap(err, "failed to create the state directory")
+	}
+	lock := flock.New(s.path + ".lock")
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+
+	var locked bool
+	var err error
+	if exclusive {
+		locked, err = lock.TryLockContext(ctx, lockRetryDelay)
+	} else {
+		locked, err = lock.TryRLockContext(ctx, lockRetryDelay)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to lock the state file %s, is another minectl running?", s.path)
+	}
+	if !locked {
+		return errors.Errorf("failed to lock the state file %s, is another minectl running?", s.path)
+	}
+	defer func() {
+		_ = lock.Unlock()
+	}()
+	return fn()
+}
+
+func (s *Store) read() (*document, error) {
+	doc := &document{}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return doc, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return doc, nil
+	}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the state file %s", s.path)
+	}
+	return doc, nil
+}
+
+// write replaces the state file atomically, so a crash never leaves a truncated file behind.
+func (s *Store) write(doc *document) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,137 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	return NewStore(filepath.Join(t.TempDir(), FileName))
+}
+
+func TestStoreListEmpty(t *testing.T) {
+	s := newTestStore(t)
+	servers, err := s.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(servers) != 0 {
+		t.Errorf("got %d servers, want 0", len(servers))
+	}
+}
+
+func TestStorePutReplacesByID(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.Put(Server{ID: "1", Name: "mc", Cloud: "do", PublicIP: "1.1.1.1"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := s.Put(Server{ID: "1", Name: "mc", Cloud: "do", PublicIP: "2.2.2.2"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	servers, err := s.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(servers) != 1 {
+		t.Fatalf("got %d servers, want 1", len(servers))
+	}
+	if servers[0].PublicIP != "2.2.2.2" {
+		t.Errorf("got PublicIP %q, want %q", servers[0].PublicIP, "2.2.2.2")
+	}
+	if servers[0].CreatedAt.IsZero() {
+		t.Error("expected CreatedAt to be set")
+	}
+}
+
+func TestStoreFind(t *testing.T) {
+	s := newTestStore(t)
+	for _, server := range []Server{
+		{ID: "1", Name: "survival", Cloud: "do"},
+		{ID: "2", Name: "creative", Cloud: "do"},
+		{ID: "3", Name: "creative", Cloud: "hetzner"},
+	} {
+		if err := s.Put(server); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		server   string
+		cloud    string
+		wantID   string
+		wantErr  bool
+		notFound bool
+	}{
+		{"unique name", "survival", "", "1", false, false},
+		{"ambiguous name", "creative", "", "", true, false},
+		{"ambiguous name narrowed by cloud", "creative", "hetzner", "3", false, false},
+		{"unknown name", "skyblock", "", "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Find(tt.server, tt.cloud)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Find() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.notFound && !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound, got %v", err)
+			}
+			if err == nil && got.ID != tt.wantID {
+				t.Errorf("got ID %q, want %q", got.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestStoreRemove(t *testing.T) {
+	s := newTestStore(t)
+	for _, cloud := range []string{"civo", "hetzner"} {
+		if err := s.Put(Server{ID: "1", Name: "mc", Cloud: cloud}); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+	if err := s.Remove("1", "civo"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := s.Remove("unknown", "civo"); err != nil {
+		t.Fatalf("Remove() of unknown id error = %v", err)
+	}
+	servers, err := s.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(servers) != 1 || servers[0].Cloud != "hetzner" {
+		t.Errorf("got servers %+v, want only the server of hetzner", servers)
+	}
+}
+
+func TestStoreConcurrentPut(t *testing.T) {
+	s := newTestStore(t)
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// every goroutine uses its own store, like separate minectl processes would
+			other := NewStore(s.Path())
+			if err := other.Put(Server{ID: strconv.Itoa(i), Name: fmt.Sprintf("mc-%d", i)}); err != nil {
+				t.Errorf("Put() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	servers, err := s.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(servers) != 20 {
+		t.Errorf("got %d servers, want 20", len(servers))
+	}
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+const progressWidth = 20
+
+// Progress is a Spinner which shows the bytes copied so far. It is an
+// io.Writer counting the bytes written to it, e.g. via io.TeeReader.
+type Progress struct {
+	*Spinner
+	total int64
+	done  atomic.Int64
+}
+
+// NewProgress creates a progress of a copy of total bytes. With an unknown
+// total of 0, only the copied bytes are shown.
+func NewProgress(message string, total int64, u *UI) *Progress {
+	p := &Progress{Spinner: NewSpinner(message, u), total: total}
+	p.status = p.String
+	return p
+}
+
+func (p *Progress) Write(b []byte) (int, error) {
+	p.done.Add(int64(len(b)))
+	return len(b), nil
+}
+
+// Stop stops the progress, logging the copied bytes in headless mode.
+func (p *Progress) Stop(err error) {
+	if p.headless && err == nil {
+		zap.S().Infow(p.Message, "bytes", p.done.Load())
+	}
+	p.Spinner.Stop(err)
+}
+
+// String renders the bar and the copied bytes.
+func (p *Progress) String() string {
+	done := p.done.Load()
+	if p.total <= 0 {
+		return FormatSize(done)
+	}
+	// the copy can exceed the estimated total, e.g. by the headers of an archive
+	ratio := min(float64(done)/float64(p.total), 1)
+	filled := int(ratio * progressWidth)
+	return fmt.Sprintf("[%s%s] %3.0f%% %s / %s", strings.Repeat("#", filled), strings.Repeat("-", progressWidth-filled),
+		ratio*100, FormatSize(done), FormatSize(p.total))
+}
+
+// FormatSize formats a size in bytes for humans.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
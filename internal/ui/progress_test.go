@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		written int
+		want    string
+	}{
+		{"unknown total", 0, 2048, "2.0 KiB"},
+		{"half", 4096, 2048, "[##########----------]  50% 2.0 KiB / 4.0 KiB"},
+		{"beyond the total", 1024, 2048, "[####################] 100% 2.0 KiB / 1.0 KiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProgress("copying", tt.total, newTestUI(t, true))
+			if _, err := io.Copy(p, strings.NewReader(strings.Repeat("x", tt.written))); err != nil {
+				t.Fatal(err)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{512, "512 B"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.size); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
@@ -18,17 +18,22 @@ type Spinner struct {
 	FinalMessage string
 	ErrorMessage string
 	headless     bool
-	program      *tea.Program
-	mu           sync.Mutex
-	started      bool
-	done         chan struct{}
+	interrupt    func()
+	// status is rendered after the message, e.g. by Progress.
+	status  func() string
+	program *tea.Program
+	mu      sync.Mutex
+	started bool
+	done    chan struct{}
 }
 
 // spinnerModel is the Bubble Tea model for the spinner.
 type spinnerModel struct {
-	spinner  spinner.Model
-	message  string
-	quitting bool
+	spinner   spinner.Model
+	message   string
+	interrupt func()
+	status    func() string
+	quitting  bool
 }
 
 func (m spinnerModel) Init() tea.Cmd {
@@ -39,6 +44,9 @@ func (m spinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" {
+			if m.interrupt != nil {
+				m.interrupt()
+			}
 			m.quitting = true
 			return m, tea.Quit
 		}
@@ -57,6 +65,9 @@ func (m spinnerModel) View() string {
 	if m.quitting {
 		return ""
 	}
+	if m.status != nil {
+		return fmt.Sprintf("%s %s %s", m.spinner.View(), m.message, m.status())
+	}
 	return fmt.Sprintf("%s %s", m.spinner.View(), m.message)
 }
 
@@ -66,9 +77,10 @@ type quitMsg struct{}
 // NewSpinner creates a new animated spinner.
 func NewSpinner(message string, u *UI) *Spinner {
 	return &Spinner{
-		Message:  message,
-		headless: u.IsHeadless(),
-		done:     make(chan struct{}),
+		Message:   message,
+		headless:  u.IsHeadless(),
+		interrupt: u.interrupt,
+		done:      make(chan struct{}),
 	}
 }
 
@@ -87,8 +99,10 @@ func (s *Spinner) Start() {
 	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 
 	model := spinnerModel{
-		spinner: sp,
-		message: s.Message,
+		spinner:   sp,
+		message:   s.Message,
+		interrupt: s.interrupt,
+		status:    s.status,
 	}
 
 	s.program = tea.NewProgram(model, tea.WithOutput(os.Stderr))
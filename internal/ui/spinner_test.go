@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/dirien/minectl/internal/logging"
 )
 
@@ -99,3 +100,21 @@ func TestSpinnerStopWithoutStart(t *testing.T) {
 		})
 	}
 }
+
+func TestSpinnerInterrupt(t *testing.T) {
+	u := newTestUI(t, false)
+	interrupted := false
+	u.OnInterrupt(func() {
+		interrupted = true
+	})
+	s := NewSpinner("creating server...", u)
+	model := spinnerModel{message: s.Message, interrupt: s.interrupt}
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if !interrupted {
+		t.Error("expected Ctrl+C to call the interrupt function")
+	}
+	if !updated.(spinnerModel).quitting || cmd == nil {
+		t.Error("expected Ctrl+C to quit the spinner")
+	}
+}
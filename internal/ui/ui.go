@@ -1,7 +1,10 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dirien/minectl/internal/logging"
@@ -19,8 +22,9 @@ var (
 // interactive and headless modes. In interactive mode, it uses Charm components
 // for polished output. In headless mode, it falls back to structured logging.
 type UI struct {
-	headless bool
-	logging  *logging.MinectlLogging
+	headless  bool
+	logging   *logging.MinectlLogging
+	interrupt func()
 }
 
 // NewUI creates a new UI instance.
@@ -36,6 +40,21 @@ func (u *UI) IsHeadless() bool {
 	return u.headless
 }
 
+// Writer returns the writer for human-readable output. It is stderr when
+// machine-readable output was requested and stdout otherwise.
+func (u *UI) Writer() io.Writer {
+	if u.logging == nil {
+		return os.Stdout
+	}
+	return u.logging.Writer()
+}
+
+// OnInterrupt sets the function spinners call when Ctrl+C is pressed. The
+// spinners read the keyboard in raw mode, so Ctrl+C does not raise SIGINT.
+func (u *UI) OnInterrupt(fn func()) {
+	u.interrupt = fn
+}
+
 // Logging returns the underlying logging instance.
 func (u *UI) Logging() *logging.MinectlLogging {
 	return u.logging
@@ -47,7 +66,7 @@ func (u *UI) Info(msg string) {
 		zap.S().Infow(msg)
 		return
 	}
-	fmt.Println(infoStyle.Render("ℹ " + msg))
+	fmt.Fprintln(u.Writer(), infoStyle.Render("ℹ "+msg))
 }
 
 // Success prints a success message with a green checkmark prefix.
@@ -56,16 +75,39 @@ func (u *UI) Success(msg string) {
 		zap.S().Infow(msg)
 		return
 	}
-	fmt.Println(successStyle.Render("✓ " + msg))
+	fmt.Fprintln(u.Writer(), successStyle.Render("✓ "+msg))
 }
 
-// ErrorMsg prints an error message with a red cross prefix.
+// DetailedError is an error made of several messages, like the errors of a
+// manifest validation.
+type DetailedError interface {
+	error
+	Summary() string
+	Details() []string
+}
+
+// ErrorMsg prints an error message with a red cross prefix. The messages of a
+// DetailedError are listed below the summary, in headless mode the error is
+// logged as JSON.
 func (u *UI) ErrorMsg(err error) {
+	var detailed DetailedError
+	isDetailed := errors.As(err, &detailed)
 	if u.headless {
+		if isDetailed {
+			zap.S().Errorw(detailed.Summary(), zap.Reflect("details", detailed))
+			return
+		}
 		zap.S().Errorw(err.Error())
 		return
 	}
-	fmt.Println(errorStyle.Render("✗ " + err.Error()))
+	if isDetailed {
+		fmt.Fprintln(u.Writer(), errorStyle.Render("✗ "+detailed.Summary()))
+		for _, detail := range detailed.Details() {
+			fmt.Fprintln(u.Writer(), errorStyle.Render("  - "+detail))
+		}
+		return
+	}
+	fmt.Fprintln(u.Writer(), errorStyle.Render("✗ "+err.Error()))
 }
 
 // Warn prints a warning message with a yellow warning prefix.
@@ -74,5 +116,5 @@ func (u *UI) Warn(msg string) {
 		zap.S().Warnw(msg)
 		return
 	}
-	fmt.Println(warnStyle.Render("⚠ " + msg))
+	fmt.Fprintln(u.Writer(), warnStyle.Render("⚠ "+msg))
 }
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -78,10 +79,17 @@ func TestErrorMsg(t *testing.T) {
 			u := newTestUI(t, tt.headless)
 			// Should not panic
 			u.ErrorMsg(errors.New("something went wrong"))
+			u.ErrorMsg(fmt.Errorf("wrapped: %w", testDetailedError{}))
 		})
 	}
 }
 
+type testDetailedError struct{}
+
+func (testDetailedError) Error() string     { return "invalid manifest" }
+func (testDetailedError) Summary() string   { return "invalid manifest" }
+func (testDetailedError) Details() []string { return []string{"line 1: invalid", "line 2: invalid"} }
+
 func TestWarn(t *testing.T) {
 	tests := []struct {
 		name     string
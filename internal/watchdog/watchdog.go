@@ -0,0 +1,117 @@
+// Package watchdog shuts a Minecraft server down once no players were online
+// for some time, so idle servers do not cost money.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Probe returns the number of players online.
+type Probe func(ctx context.Context) (int, error)
+
+// Watchdog polls the players of a server and shuts it down once it was idle
+// for Idle. Failed probes do not change the idle time and never trigger the
+// shutdown, so a server which is stopped or starting is neither shut down nor
+// considered busy.
+type Watchdog struct {
+	// Idle is the time without players after which the server is shut down.
+	Idle time.Duration
+	// Warning is the time before the shutdown the players are warned, 0 disables the warning.
+	Warning time.Duration
+	// Interval between two probes.
+	Interval time.Duration
+
+	Players Probe
+	// Warn sends a message to the players, e.g. via RCON say. Optional.
+	Warn func(ctx context.Context, message string) error
+	// Shutdown stops or deletes the server. Once it returns Done, the watchdog exits.
+	Shutdown func(ctx context.Context) (Done, error)
+	// Log reports the progress of the watchdog. Optional.
+	Log func(message string)
+
+	now func() time.Time
+}
+
+// Done reports whether the watchdog has nothing left to watch after a shutdown.
+type Done bool
+
+// Run watches the server until it was shut down for good or the context is done.
+func (w *Watchdog) Run(ctx context.Context) error {
+	if w.Idle <= 0 || w.Interval <= 0 {
+		return errors.New("the idle time and the interval of the watchdog must be positive")
+	}
+	if w.Warning >= w.Idle {
+		return errors.Errorf("the warning (%s) must be sent before the shutdown after %s", w.Warning, w.Idle)
+	}
+	now := w.now
+	if now == nil {
+		now = time.Now
+	}
+
+	var idleSince time.Time
+	warned := false
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		players, err := w.Players(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			w.log(fmt.Sprintf("Could not query the players: %v", err))
+		case players > 0:
+			if !idleSince.IsZero() {
+				w.log(fmt.Sprintf("%d players online, the server is no longer idle", players))
+			}
+			idleSince, warned = time.Time{}, false
+		case idleSince.IsZero():
+			idleSince = now()
+			w.log(fmt.Sprintf("No players online, shutting down in %s", w.Idle))
+		}
+
+		// a failed probe neither warns nor shuts the server down, it may be starting
+		if err == nil && !idleSince.IsZero() {
+			idle := now().Sub(idleSince)
+			if w.Warning > 0 && !warned && idle >= w.Idle-w.Warning {
+				warned = true
+				w.warn(ctx, fmt.Sprintf("No players online, this server shuts down in %s.", (w.Idle-idle).Round(time.Second)))
+			}
+			if idle >= w.Idle {
+				w.log(fmt.Sprintf("The server was idle for %s, shutting it down", idle.Round(time.Second)))
+				done, err := w.Shutdown(ctx)
+				if err != nil {
+					return err
+				}
+				if done {
+					return nil
+				}
+				idleSince, warned = time.Time{}, false
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watchdog) warn(ctx context.Context, message string) {
+	if w.Warn == nil {
+		return
+	}
+	if err := w.Warn(ctx, message); err != nil {
+		w.log(fmt.Sprintf("Could not warn the players: %v", err))
+	}
+}
+
+func (w *Watchdog) log(message string) {
+	if w.Log != nil {
+		w.Log(message)
+	}
+}
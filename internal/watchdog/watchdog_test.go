@@ -0,0 +1,264 @@
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mcnet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/dirien/minectl/internal/ping"
+	"github.com/dirien/minectl/internal/rcon"
+)
+
+// players returns a probe answering the counts in order, repeating the last one.
+func players(counts ...int) Probe {
+	var calls atomic.Int32
+	return func(context.Context) (int, error) {
+		i := int(calls.Add(1)) - 1
+		if i >= len(counts) {
+			i = len(counts) - 1
+		}
+		if counts[i] < 0 {
+			return 0, fmt.Errorf("offline")
+		}
+		return counts[i], nil
+	}
+}
+
+// clock advances by step on every call, so the tests do not sleep for the idle time.
+func clock(step time.Duration) func() time.Time {
+	var mu sync.Mutex
+	now := time.Now()
+	return func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		players      Probe
+		warning      time.Duration
+		wantWarnings int
+		wantShutdown bool
+	}{
+		{"idle server is warned and shut down", players(0), 2 * time.Minute, 1, true},
+		{"no warning", players(0), 0, 0, true},
+		{"players reset the idle time", players(0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0), 2 * time.Minute, 1, true},
+		{"failed probes are not idle", players(-1), 2 * time.Minute, 0, false},
+		{"failed probes do not shut down an idle server", players(0, -1), 2 * time.Minute, 0, false},
+		{"busy server", players(3), 2 * time.Minute, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(t.Context(), 500*time.Millisecond)
+			defer cancel()
+			var warnings, shutdowns atomic.Int32
+			w := &Watchdog{
+				Idle:     10 * time.Minute,
+				Warning:  tt.warning,
+				Interval: time.Millisecond,
+				Players:  tt.players,
+				Warn: func(context.Context, string) error {
+					warnings.Add(1)
+					return nil
+				},
+				Shutdown: func(context.Context) (Done, error) {
+					shutdowns.Add(1)
+					return true, nil
+				},
+				now: clock(time.Minute),
+			}
+			if err := w.Run(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if got := int(warnings.Load()); got != tt.wantWarnings {
+				t.Errorf("warnings = %d, want %d", got, tt.wantWarnings)
+			}
+			if got := shutdowns.Load() == 1; got != tt.wantShutdown {
+				t.Errorf("shutdown = %v, want %v", got, tt.wantShutdown)
+			}
+		})
+	}
+}
+
+func TestRunContinuesAfterStop(t *testing.T) {
+	var shutdowns atomic.Int32
+	w := &Watchdog{
+		Idle:     10 * time.Minute,
+		Interval: time.Millisecond,
+		Players:  players(0),
+		Shutdown: func(context.Context) (Done, error) {
+			return shutdowns.Add(1) == 2, nil
+		},
+		now: clock(time.Minute),
+	}
+	if err := w.Run(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if got := shutdowns.Load(); got != 2 {
+		t.Errorf("shutdowns = %d, want 2", got)
+	}
+}
+
+func TestRunShutdownError(t *testing.T) {
+	w := &Watchdog{
+		Idle:     time.Minute,
+		Interval: time.Millisecond,
+		Players:  players(0),
+		Shutdown: func(context.Context) (Done, error) {
+			return false, fmt.Errorf("delete failed")
+		},
+		now: clock(time.Minute),
+	}
+	if err := w.Run(t.Context()); err == nil {
+		t.Fatal("want the error of the shutdown")
+	}
+}
+
+func TestRunInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		idle     time.Duration
+		warning  time.Duration
+		interval time.Duration
+	}{
+		{"no idle time", 0, 0, time.Second},
+		{"no interval", time.Minute, 0, 0},
+		{"warning after the shutdown", time.Minute, time.Minute, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Watchdog{Idle: tt.idle, Warning: tt.warning, Interval: tt.interval, Players: players(0)}
+			if err := w.Run(t.Context()); err == nil {
+				t.Fatal("want an error")
+			}
+		})
+	}
+}
+
+// serveSLP answers Server List Pings with the current number of players.
+func serveSLP(t *testing.T, online *atomic.Int32) string {
+	t.Helper()
+	listener, err := mcnet.ListenMC("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var p pk.Packet
+				for range 2 {
+					if err := conn.ReadPacket(&p); err != nil {
+						return
+					}
+				}
+				response := fmt.Sprintf(`{"version":{"name":"1.21.4","protocol":769},"players":{"max":20,"online":%d},"description":{"text":"test"}}`, online.Load())
+				if err := conn.WritePacket(pk.Marshal(0x00, pk.String(response))); err != nil {
+					return
+				}
+				if err := conn.ReadPacket(&p); err != nil {
+					return
+				}
+				_ = conn.WritePacket(p)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// serveRCON records the commands it receives.
+func serveRCON(t *testing.T, password string, commands chan<- string) (string, int) {
+	t.Helper()
+	listener, err := mcnet.ListenRCON("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if conn.AcceptLogin(password) == nil {
+				if cmd, err := conn.AcceptCmd(); err == nil {
+					commands <- cmd
+					_ = conn.RespCmd("")
+				}
+			}
+			_ = conn.Close()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p
+}
+
+func TestRunAgainstServer(t *testing.T) {
+	var online atomic.Int32
+	online.Store(1)
+	addr := serveSLP(t, &online)
+	commands := make(chan string, 10)
+	host, port := serveRCON(t, "s3cr3t", commands)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	var probes atomic.Int32
+	w := &Watchdog{
+		Idle:     200 * time.Millisecond,
+		Warning:  100 * time.Millisecond,
+		Interval: 20 * time.Millisecond,
+		Players: func(ctx context.Context) (int, error) {
+			// the last player leaves after a few probes
+			if probes.Add(1) == 3 {
+				online.Store(0)
+			}
+			status, err := ping.Java(ctx, addr)
+			if err != nil {
+				return 0, err
+			}
+			return status.Players, nil
+		},
+		Warn: func(ctx context.Context, message string) error {
+			_, err := rcon.Command(ctx, host, "s3cr3t", port, "say "+message)
+			return err
+		},
+		Shutdown: func(context.Context) (Done, error) {
+			return true, nil
+		},
+	}
+	start := time.Now()
+	if err := w.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("the watchdog did not shut the server down")
+	}
+	if elapsed := time.Since(start); elapsed < w.Idle {
+		t.Errorf("shut down after %s, before the idle time", elapsed)
+	}
+	select {
+	case cmd := <-commands:
+		if cmd[:4] != "say " {
+			t.Errorf("command = %q, want say", cmd)
+		}
+	default:
+		t.Error("the players were not warned")
+	}
+}
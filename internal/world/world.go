@@ -0,0 +1,210 @@
+// Package world reads and archives the Minecraft worlds on the local disk,
+// which minectl world pushes to and pulls from the servers.
+package world
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/blang/semver/v4"
+	"github.com/pkg/errors"
+)
+
+// LevelFile is the file holding the settings of a world.
+const LevelFile = "level.dat"
+
+// Level is the part of level.dat minectl checks.
+type Level struct {
+	Name string
+	// Version is the Minecraft version which saved the world, empty for Bedrock worlds.
+	Version string
+}
+
+// javaLevel is the NBT structure of level.dat of Java edition worlds, see
+// https://minecraft.wiki/w/Java_Edition_level_format
+type javaLevel struct {
+	Data struct {
+		LevelName string `nbt:"LevelName"`
+		Version   struct {
+			Name string `nbt:"Name"`
+		} `nbt:"Version"`
+	} `nbt:"Data"`
+}
+
+// ReadLevel validates level.dat of the world in dir. Java edition worlds
+// hold a gzip compressed NBT compound, Bedrock edition worlds a little endian
+// NBT compound after a header of its version and length.
+func ReadLevel(dir string, bedrock bool) (*Level, error) {
+	data, err := os.ReadFile(filepath.Join(dir, LevelFile))
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s is not a Minecraft world", dir)
+	}
+	if bedrock {
+		return readBedrockLevel(dir, data)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s in %s", LevelFile, dir)
+	}
+	var level javaLevel
+	if _, err := nbt.NewDecoder(gz).Decode(&level); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s in %s", LevelFile, dir)
+	}
+	return &Level{Name: level.Data.LevelName, Version: level.Data.Version.Name}, nil
+}
+
+func readBedrockLevel(dir string, data []byte) (*Level, error) {
+	if len(data) < 8 || int(binary.LittleEndian.Uint32(data[4:8])) != len(data)-8 {
+		return nil, errors.Errorf("invalid %s in %s: the length in the header does not match", LevelFile, dir)
+	}
+	level := &Level{Name: filepath.Base(dir)}
+	if name, err := os.ReadFile(filepath.Join(dir, "levelname.txt")); err == nil {
+		level.Name = strings.TrimSpace(string(name))
+	}
+	return level, nil
+}
+
+// CheckVersion fails when the world was saved by a newer Minecraft version
+// than the server runs, as Minecraft can not downgrade worlds. Versions which
+// can not be compared, e.g. snapshots, are accepted.
+func CheckVersion(level *Level, serverVersion string) error {
+	saved, err := semver.ParseTolerant(level.Version)
+	if err != nil {
+		return nil
+	}
+	server, err := semver.ParseTolerant(strings.SplitN(serverVersion, "-", 2)[0])
+	if err != nil {
+		return nil
+	}
+	if saved.GT(server) {
+		return errors.Errorf("the world was saved by Minecraft %s, the server runs %s and can not load it", level.Version, serverVersion)
+	}
+	return nil
+}
+
+// Size returns the size of the files in dir.
+func Size(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// Tar writes the files of dir to w as a tar stream below name.
+func Tar(w io.Writer, dir, name string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// session.lock of single-player worlds is held by the game and not needed by the server
+		if !d.IsDir() && !d.Type().IsRegular() || d.Name() == "session.lock" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(name, filepath.ToSlash(rel))
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Untar extracts the tar stream into dir and returns the top-level
+// directories. Existing directories are not overwritten.
+func Untar(r io.Reader, dir string) ([]string, error) {
+	tr := tar.NewReader(r)
+	var dirs []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return dirs, nil
+		}
+		if err != nil {
+			return dirs, errors.Wrap(err, "failed to read the worlds")
+		}
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == "." || name == ".." || strings.HasPrefix(name, "../") {
+			return dirs, errors.Errorf("invalid entry %q in the worlds", header.Name)
+		}
+		top, _, _ := strings.Cut(name, "/")
+		if !slices.Contains(dirs, top) {
+			if _, err := os.Stat(filepath.Join(dir, top)); err == nil {
+				return dirs, errors.Errorf("%s already exists, please remove it or pull into another directory", filepath.Join(dir, top))
+			}
+			dirs = append(dirs, top)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return dirs, err
+			}
+		case tar.TypeReg:
+			if err := writeFile(target, tr, header.FileInfo().Mode().Perm()); err != nil {
+				return dirs, err
+			}
+		}
+	}
+}
+
+func writeFile(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,168 @@
+package world
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+func writeJavaLevel(t *testing.T, dir, name, version string) {
+	t.Helper()
+	var level javaLevel
+	level.Data.LevelName = name
+	level.Data.Version.Name = version
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	if err := nbt.NewEncoder(gz).Encode(level, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, LevelFile), b.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadLevel(t *testing.T) {
+	java := t.TempDir()
+	writeJavaLevel(t, java, "My World", "1.21.4")
+
+	bedrock := t.TempDir()
+	payload := []byte{0x0a, 0, 0, 0}
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint32(header, 10)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(payload)))
+	if err := os.WriteFile(filepath.Join(bedrock, LevelFile), append(header, payload...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bedrock, "levelname.txt"), []byte("Bedrock level\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	truncated := t.TempDir()
+	if err := os.WriteFile(filepath.Join(truncated, LevelFile), append(header, payload[:2]...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	garbage := t.TempDir()
+	if err := os.WriteFile(filepath.Join(garbage, LevelFile), []byte("not a level"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		bedrock bool
+		want    Level
+		wantErr bool
+	}{
+		{name: "java", dir: java, want: Level{Name: "My World", Version: "1.21.4"}},
+		{name: "bedrock", dir: bedrock, bedrock: true, want: Level{Name: "Bedrock level"}},
+		{name: "truncated bedrock", dir: truncated, bedrock: true, wantErr: true},
+		{name: "bedrock as java", dir: bedrock, wantErr: true},
+		{name: "garbage", dir: garbage, wantErr: true},
+		{name: "missing", dir: t.TempDir(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadLevel(tt.dir, tt.bedrock)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && *got != tt.want {
+				t.Errorf("ReadLevel() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		level   string
+		server  string
+		wantErr bool
+	}{
+		{level: "1.21.4", server: "1.21.4"},
+		{level: "1.20.1", server: "1.21"},
+		{level: "1.21.4", server: "1.21.1", wantErr: true},
+		{level: "1.21.4", server: "1.21.1-R0.1-SNAPSHOT", wantErr: true},
+		{level: "24w14a", server: "1.20.4"},
+		{level: "", server: "1.20.4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level+" on "+tt.server, func(t *testing.T) {
+			err := CheckVersion(&Level{Version: tt.level}, tt.server)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTarUntar(t *testing.T) {
+	src := t.TempDir()
+	writeJavaLevel(t, src, "world", "1.21.4")
+	if err := os.MkdirAll(filepath.Join(src, "region"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"region/r.0.0.mca": "region", "session.lock": "lock"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var b bytes.Buffer
+	if err := Tar(&b, src, "survival"); err != nil {
+		t.Fatalf("Tar() error = %v", err)
+	}
+
+	dst := t.TempDir()
+	dirs, err := Untar(bytes.NewReader(b.Bytes()), dst)
+	if err != nil {
+		t.Fatalf("Untar() error = %v", err)
+	}
+	if len(dirs) != 1 || dirs[0] != "survival" {
+		t.Errorf("Untar() = %v, want [survival]", dirs)
+	}
+	if got, err := os.ReadFile(filepath.Join(dst, "survival", "region", "r.0.0.mca")); err != nil || string(got) != "region" {
+		t.Errorf("region = %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "survival", "session.lock")); !os.IsNotExist(err) {
+		t.Errorf("session.lock was archived: %v", err)
+	}
+	if _, err := ReadLevel(filepath.Join(dst, "survival"), false); err != nil {
+		t.Errorf("ReadLevel() error = %v", err)
+	}
+	if _, err := Untar(bytes.NewReader(b.Bytes()), dst); err == nil {
+		t.Error("Untar() should not overwrite existing worlds")
+	}
+}
+
+func TestUntarInvalid(t *testing.T) {
+	for _, name := range []string{"../evil", "/etc/passwd", "world/../../evil"} {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			tw := tar.NewWriter(&b)
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: 4, Typeflag: tar.TypeReg}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte("evil")); err != nil {
+				t.Fatal(err)
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Untar(&b, t.TempDir()); err == nil {
+				t.Errorf("Untar() of %q should fail", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package provider
+
+import (
+	"strings"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl-sdk/cloud/akamai"
+	"github.com/dirien/minectl-sdk/cloud/aws"
+	"github.com/dirien/minectl-sdk/cloud/azure"
+	"github.com/dirien/minectl-sdk/cloud/civo"
+	"github.com/dirien/minectl-sdk/cloud/do"
+	"github.com/dirien/minectl-sdk/cloud/exoscale"
+	"github.com/dirien/minectl-sdk/cloud/fuga"
+	"github.com/dirien/minectl-sdk/cloud/gce"
+	"github.com/dirien/minectl-sdk/cloud/hetzner"
+	"github.com/dirien/minectl-sdk/cloud/multipass"
+	"github.com/dirien/minectl-sdk/cloud/oci"
+	"github.com/dirien/minectl-sdk/cloud/ovh"
+	"github.com/dirien/minectl-sdk/cloud/scaleway"
+	"github.com/dirien/minectl-sdk/cloud/vexxhost"
+	"github.com/dirien/minectl-sdk/cloud/vultr"
+	"github.com/dirien/minectl-sdk/model"
+)
+
+// openStackCredentialKeys are read by the OpenStack based providers (VEXXHOST, Fuga Cloud).
+var openStackCredentialKeys = []string{
+	"OS_REGION_NAME", "OS_USERNAME", "OS_PASSWORD", "OS_USER_ID", "OS_PROJECT_ID", "OS_PROJECT_NAME",
+	"OS_PROJECT_DOMAIN_ID", "OS_DOMAIN_ID", "OS_DOMAIN_NAME", "OS_PASSCODE",
+}
+
+// sshUser returns a SSHUser func for a fixed user.
+func sshUser(user string) func(Credentials) string {
+	return func(Credentials) string {
+		return user
+	}
+}
+
+func init() {
+	MustRegister(Provider{
+		Name:           model.PROVIDER_DIGITALOCEAN,
+		FullName:       "DigitalOcean",
+		CredentialKeys: []string{"DIGITALOCEAN_TOKEN"},
+		Capabilities:   []Capability{Volumes},
+		Factory: func(creds Credentials, _ string) (automation.Automation, error) {
+			auto, err := do.NewDigitalOcean(creds.Get("DIGITALOCEAN_TOKEN"))
+			if err != nil {
+				return nil, err
+			}
+			return newDigitalOceanPower(auto, creds.Get("DIGITALOCEAN_TOKEN")), nil
+		},
+	})
+	MustRegister(Provider{
+		Name:           model.PROVIDER_CIVO,
+		FullName:       "Civo",
+		CredentialKeys: []string{"CIVO_TOKEN"},
+		Capabilities:   []Capability{Regions},
+		Factory: func(creds Credentials, region string) (automation.Automation, error) {
+			return civo.NewCivo(creds.Get("CIVO_TOKEN"), region)
+		},
+	})
+	MustRegister(Provider{
+		Name:           model.PROVIDER_SCALEWAY,
+		FullName:       "Scaleway",
+		CredentialKeys: []string{"ACCESS_KEY", "SECRET_KEY", "ORGANISATION_ID"},
+		Capabilities:   []Capability{Volumes, Regions},
+		Factory: func(creds Credentials, region string) (automation.Automation, error) {
+			return scaleway.NewScaleway(creds.Get("ACCESS_KEY"), creds.Get("SECRET_KEY"), creds.Get("ORGANISATION_ID"), region)
+		},
+	})
+	MustRegister(Provider{
+		Name:           model.PROVIDER_HETZNER,
+		FullName:       "Hetzner",
+		CredentialKeys: []string{"HCLOUD_TOKEN"},
+		Capabilities:   []Capability{ARM, Volumes},
+		Factory: func(creds Credentials, _ string) (automation.Automation, error) {
+			auto, err := hetzner.NewHetzner(creds.Get("HCLOUD_TOKEN"))
+			if err != nil {
+				return nil, err
+			}
+			return newHetznerPower(auto, creds.Get("HCLOUD_TOKEN")), nil
+		},
+	})
+	MustRegister(Provider{
+		Name:           model.PROVIDER_AKAMAI,
+		FullName:       "Akamai Connected Cloud",
+		CredentialKeys: []string{"LINODE_TOKEN"},
+		Capabilities:   []Capability{Volumes},
+		Factory: func(creds Credentials, _ string) (automation.Automation, error) {
+			return akamai.NewAkamai(creds.Get("LINODE_TOKEN"))
+		},
+	})
+	MustRegister(Provider{
+		Name:           model.PROVIDER_OVH,
+		FullName:       "OVHcloud",
+		CredentialKeys: []string{"OVH_ENDPOINT", "APPLICATION_KEY", "APPLICATION_SECRET", "CONSUMER_KEY", "SERVICENAME"},
+		Capabilities:   []Capability{Volumes, Regions},
+		SSHUser:        sshUser("ubuntu"),
+		Factory: func(creds Credentials, region string) (automation.Automation, error) {
+			return ovh.NewOVHcloud(creds.Get("OVH_ENDPOINT"), creds.Get("APPLICATION_KEY"), creds.Get("APPLICATION_SECRET"), creds.Get("CONSUMER_KEY"), creds.Get("SERVICENAME"), region)
+		},
+	})
+	MustRegister(Provider{
+		Name:                   model.PROVIDER_GCE,
+		FullName:               "Google Compute Engine",
+		CredentialKeys:         []string{"GOOGLE_PROJECT", "GOOGLE_SERVICE_ACCOUNT_EMAIL"},
+		OptionalCredentialKeys: []string{"GOOGLE_APPLICATION_CREDENTIALS"},
+		Capabilities:           []Capability{Spot, ARM, Volumes, Regions},
+		SSHUser: func(creds Credentials) string {
+			// OS Login users of service accounts are named after the account id
+			id, _, _ := strings.Cut(creds.Get("GOOGLE_SERVICE_ACCOUNT_EMAIL"), "@")
+			return "sa_" + id
+		},
+		Factory: func(creds Credentials, region string) (automation.Automation, error) {
+			if err := creds.Export(); err != nil {
+				return nil, err
+			}
+			return gce.NewGCE(region)
+		},
+	})
+	MustRegister(Provider{
+		Name:           model.PROVIDER_VULTR,
+		FullName:       "vultr",
+		CredentialKeys: []string{"VULTR_API_KEY"},
+		Factory: func(creds Credentials, _ string) (automation.Automation, error) {
+			return vultr.NewVultr(creds.Get("VULTR_API_KEY"))
+		},
+	})
+	MustRegister(Provider{
+		Name:                   model.PROVIDER_AZURE,
+		FullName:               "Azure",
+		CredentialKeys:         []string{"AZURE_SUBSCRIPTION_ID"},
+		OptionalCredentialKeys: []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET"},
+		Capabilities:           []Capability{Spot, ARM, Volumes},
+		SSHUser:                sshUser("ubuntu"),
+		Factory: func(creds Credentials, _ string) (automation.Automation, error) {
+			if err := creds.Export(); err != nil {
+				return nil, err
+			}
+			return azure.NewAzure()
+		},
+	})
+	MustRegister(Provider{
+		Name:         model.PROVIDER_OCI,
+		FullName:     "Oracle Cloud Infrastructure",
+		Capabilities: []Capability{ARM},
+		SSHUser:      sshUser("ubuntu"),
+		Factory: func(_ Credentials, _ string) (automation.Automation, error) {
+			return oci.NewOCI()
+		},
+	})
+	MustRegister(Provider{
+		Name:                   model.PROVIDER_AWS,
+		FullName:               "Amazon Web Services",
+		OptionalCredentialKeys: []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE"},
+		Capabilities:           []Capability{Spot, ARM, Volumes, Regions},
+		SSHUser:                sshUser("ubuntu"),
+		Factory: func(creds Credentials, region string) (automation.Automation, error) {
+			if err := creds.Export(); err != nil {
+				return nil, err
+			}
+			auto, err := aws.NewAWS(region)
+			if err != nil {
+				return nil, err
+			}
+			return newAWSPower(auto, region)
+		},
+	})
+	MustRegister(Provider{
+		Name:                   model.PROVIDER_VEXXHOST,
+		FullName:               "VEXXHOST",
+		CredentialKeys:         []string{"OS_AUTH_URL"},
+		OptionalCredentialKeys: openStackCredentialKeys,
+		SSHUser:                sshUser("ubuntu"),
+		Factory: func(creds Credentials, _ string) (automation.Automation, error) {
+			if err := creds.Export(); err != nil {
+				return nil, err
+			}
+			return vexxhost.NewVEXXHOST()
+		},
+	})
+	MustRegister(Provider{
+		Name:     model.PROVIDER_MULTIPASS,
+		FullName: "Ubuntu Multipass",
+		SSHUser:  sshUser("ubuntu"),
+		Factory: func(_ Credentials, _ string) (automation.Automation, error) {
+			return multipass.NewMultipass()
+		},
+	})
+	MustRegister(Provider{
+		Name:           model.PROVIDER_EXOSCALE,
+		FullName:       "Exoscale",
+		CredentialKeys: []string{"EXOSCALE_API_KEY", "EXOSCALE_API_SECRET"},
+		Factory: func(creds Credentials, _ string) (automation.Automation, error) {
+			return exoscale.NewExoscale(creds.Get("EXOSCALE_API_KEY"), creds.Get("EXOSCALE_API_SECRET"))
+		},
+	})
+	MustRegister(Provider{
+		Name:                   model.PROVIDER_FUGA,
+		FullName:               "Fuga Cloud",
+		CredentialKeys:         []string{"OS_AUTH_URL"},
+		OptionalCredentialKeys: openStackCredentialKeys,
+		SSHUser:                sshUser("ubuntu"),
+		Factory: func(creds Credentials, _ string) (automation.Automation, error) {
+			if err := creds.Export(); err != nil {
+				return nil, err
+			}
+			return fuga.NewFuga()
+		},
+	})
+}
@@ -0,0 +1,329 @@
+// Package fake implements a simulated cloud provider. Servers only exist in a
+// local JSON file, which makes it possible to exercise minectl offline, in CI
+// and in demos. Latency and failures of single operations can be injected.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl-sdk/common"
+	"github.com/gofrs/flock"
+	"github.com/pkg/errors"
+)
+
+// Operation names a method of automation.Automation for failure injection.
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationDelete Operation = "delete"
+	OperationList   Operation = "list"
+	OperationUpdate Operation = "update"
+	OperationUpload Operation = "upload"
+	OperationGet    Operation = "get"
+	OperationRemote Operation = "remote"
+	OperationPing   Operation = "ping"
+	OperationStop   Operation = "stop"
+	OperationStart  Operation = "start"
+)
+
+const (
+	// EnvState is the path of the JSON file holding the simulated servers.
+	EnvState = "MINECTL_FAKE_STATE"
+	// EnvLatency is the latency added to every operation, e.g. "2s".
+	EnvLatency = "MINECTL_FAKE_LATENCY"
+	// EnvFail is a comma separated list of operations which fail, e.g. "create,delete".
+	EnvFail = "MINECTL_FAKE_FAIL"
+
+	defaultStateFile = "fake-cloud.json"
+	// ipPrefix is the TEST-NET-2 documentation range, so simulated IPs never reach a real host.
+	ipPrefix = "198.51.100."
+)
+
+// ErrInjected is returned by operations configured to fail.
+var ErrInjected = errors.New("injected failure")
+
+// Server is a simulated server.
+type Server struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Region   string   `json:"region"`
+	PublicIP string   `json:"public_ip"`
+	Tags     string   `json:"tags"`
+	Edition  string   `json:"edition"`
+	Version  string   `json:"version"`
+	Plugins  []string `json:"plugins,omitempty"`
+	// Jars holds the content of the uploaded plugins, keyed by path.
+	Jars map[string][]byte `json:"jars,omitempty"`
+	// Stopped is true while the server is powered off.
+	Stopped bool `json:"stopped,omitempty"`
+	// ServiceStopped is true while the Minecraft unit is stopped via Connect.
+	ServiceStopped bool `json:"service_stopped,omitempty"`
+	// Files are the files written via Connect, keyed by path.
+	Files map[string]string `json:"files,omitempty"`
+	// World holds the files of the Minecraft worlds, keyed by the path below the Minecraft directory.
+	World map[string][]byte `json:"world,omitempty"`
+	// Commands are the commands run via Connect.
+	Commands  []string  `json:"commands,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type document struct {
+	NextID  int      `json:"next_id"`
+	Servers []Server `json:"servers"`
+}
+
+// Options configures the fake provider.
+type Options struct {
+	// Path of the JSON file holding the simulated servers.
+	Path string
+	// Latency is added to every operation.
+	Latency time.Duration
+	// Fail lists the operations which return ErrInjected.
+	Fail []Operation
+}
+
+// Fake implements automation.Automation.
+type Fake struct {
+	path    string
+	latency time.Duration
+	fail    []Operation
+}
+
+// New creates a fake provider.
+func New(opts Options) *Fake {
+	return &Fake{
+		path:    opts.Path,
+		latency: opts.Latency,
+		fail:    opts.Fail,
+	}
+}
+
+// OptionsFromValues parses the options from the values of EnvState, EnvLatency and EnvFail.
+func OptionsFromValues(path, latency, fail string) (Options, error) {
+	opts := Options{Path: path}
+	if opts.Path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return opts, err
+		}
+		opts.Path = filepath.Join(home, ".minectl", defaultStateFile)
+	}
+	if latency != "" {
+		d, err := time.ParseDuration(latency)
+		if err != nil {
+			return opts, errors.Wrapf(err, "invalid %s", EnvLatency)
+		}
+		opts.Latency = d
+	}
+	for op := range strings.SplitSeq(fail, ",") {
+		if op = strings.TrimSpace(op); op != "" {
+			opts.Fail = append(opts.Fail, Operation(op))
+		}
+	}
+	return opts, nil
+}
+
+// Simulated marks the provider as simulated, so no readiness checks are run against its servers.
+func (f *Fake) Simulated() bool {
+	return true
+}
+
+// Servers returns all simulated servers.
+func (f *Fake) Servers() ([]Server, error) {
+	var servers []Server
+	err := f.transaction(false, func(doc *document) error {
+		servers = doc.Servers
+		return nil
+	})
+	return servers, err
+}
+
+func (f *Fake) CreateServer(args automation.ServerArgs) (*automation.ResourceResults, error) {
+	if err := f.simulate(OperationCreate); err != nil {
+		return nil, err
+	}
+	var server Server
+	err := f.transaction(true, func(doc *document) error {
+		doc.NextID++
+		now := time.Now().UTC()
+		server = Server{
+			ID:        strconv.Itoa(doc.NextID),
+			Name:      args.MinecraftResource.GetName(),
+			Region:    args.MinecraftResource.GetRegion(),
+			PublicIP:  ipPrefix + strconv.Itoa(doc.NextID%254+1),
+			Tags:      strings.Join([]string{common.InstanceTag, args.MinecraftResource.GetEdition()}, ","),
+			Edition:   args.MinecraftResource.GetEdition(),
+			Version:   args.MinecraftResource.GetVersion(),
+			World:     simulatedWorld(args.MinecraftResource.GetVersion()),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		doc.Servers = append(doc.Servers, server)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return server.result(), nil
+}
+
+func (f *Fake) DeleteServer(id string, _ automation.ServerArgs) error {
+	if err := f.simulate(OperationDelete); err != nil {
+		return err
+	}
+	return f.transaction(true, func(doc *document) error {
+		i, err := doc.find(id)
+		if err != nil {
+			return err
+		}
+		doc.Servers = slices.Delete(doc.Servers, i, i+1)
+		return nil
+	})
+}
+
+func (f *Fake) ListServer() ([]automation.ResourceResults, error) {
+	if err := f.simulate(OperationList); err != nil {
+		return nil, err
+	}
+	servers, err := f.Servers()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]automation.ResourceResults, 0, len(servers))
+	for i := range servers {
+		results = append(results, *servers[i].result())
+	}
+	return results, nil
+}
+
+func (f *Fake) UpdateServer(id string, args automation.ServerArgs) error {
+	if err := f.simulate(OperationUpdate); err != nil {
+		return err
+	}
+	return f.transaction(true, func(doc *document) error {
+		i, err := doc.find(id)
+		if err != nil {
+			return err
+		}
+		doc.Servers[i].Edition = args.MinecraftResource.GetEdition()
+		doc.Servers[i].Version = args.MinecraftResource.GetVersion()
+		doc.Servers[i].UpdatedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+func (f *Fake) UploadPlugin(id string, _ automation.ServerArgs, plugin, destination string) error {
+	if err := f.simulate(OperationUpload); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(plugin)
+	if err != nil {
+		return err
+	}
+	return f.transaction(true, func(doc *document) error {
+		i, err := doc.find(id)
+		if err != nil {
+			return err
+		}
+		doc.Servers[i].addPlugin(filepath.Join(destination, filepath.Base(plugin)), content)
+		return nil
+	})
+}
+
+func (f *Fake) GetServer(id string, _ automation.ServerArgs) (*automation.ResourceResults, error) {
+	if err := f.simulate(OperationGet); err != nil {
+		return nil, err
+	}
+	var server Server
+	err := f.transaction(false, func(doc *document) error {
+		i, err := doc.find(id)
+		if err != nil {
+			return err
+		}
+		server = doc.Servers[i]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return server.result(), nil
+}
+
+func (s *Server) result() *automation.ResourceResults {
+	return &automation.ResourceResults{
+		ID:       s.ID,
+		Name:     s.Name,
+		Region:   s.Region,
+		PublicIP: s.PublicIP,
+		Tags:     s.Tags,
+	}
+}
+
+func (d *document) find(id string) (int, error) {
+	for i := range d.Servers {
+		if d.Servers[i].ID == id {
+			return i, nil
+		}
+	}
+	return -1, errors.Errorf("server %s not found", id)
+}
+
+// simulate applies the configured latency and failures to an operation.
+func (f *Fake) simulate(op Operation) error {
+	if f.latency > 0 {
+		time.Sleep(f.latency)
+	}
+	if slices.Contains(f.fail, op) {
+		return errors.Wrap(ErrInjected, string(op))
+	}
+	return nil
+}
+
+// transaction runs fn on the document while holding a file lock and saves it
+// afterward when write is true.
+func (f *Fake) transaction(write bool, fn func(doc *document) error) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	lock := flock.New(f.path + ".lock")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := lock.TryLockContext(ctx, 50*time.Millisecond); err != nil {
+		return err
+	}
+	defer func() {
+		_ = lock.Unlock()
+	}()
+
+	doc := &document{}
+	data, err := os.ReadFile(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, doc); err != nil {
+			return errors.Wrapf(err, "failed to parse %s", f.path)
+		}
+	}
+	if err := fn(doc); err != nil {
+		return err
+	}
+	if !write {
+		return nil
+	}
+	data, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, data, 0o600)
+}
@@ -0,0 +1,218 @@
+package fake
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl-sdk/model"
+)
+
+func newTestFake(t *testing.T, fail ...Operation) *Fake {
+	t.Helper()
+	return New(Options{Path: filepath.Join(t.TempDir(), "fake.json"), Fail: fail})
+}
+
+func testArgs() automation.ServerArgs {
+	return automation.ServerArgs{
+		MinecraftResource: &model.MinecraftResource{
+			Metadata: model.Metadata{Name: "minecraft-server"},
+			Spec: model.Spec{
+				Server:    model.Server{Cloud: "fake", Region: "local"},
+				Minecraft: model.Minecraft{Edition: "java", Version: "1.21.1"},
+			},
+		},
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	f := newTestFake(t)
+	args := testArgs()
+
+	created, err := f.CreateServer(args)
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if created.ID == "" || created.PublicIP == "" {
+		t.Fatalf("CreateServer() returned incomplete server %+v", created)
+	}
+	if created.Tags != "minectl,java" {
+		t.Errorf("got tags %q, want %q", created.Tags, "minectl,java")
+	}
+
+	got, err := f.GetServer(created.ID, args)
+	if err != nil {
+		t.Fatalf("GetServer() error = %v", err)
+	}
+	if *got != *created {
+		t.Errorf("GetServer() = %+v, want %+v", got, created)
+	}
+
+	args.MinecraftResource.Spec.Minecraft.Version = "1.21.4"
+	if err := f.UpdateServer(created.ID, args); err != nil {
+		t.Fatalf("UpdateServer() error = %v", err)
+	}
+	servers, err := f.Servers()
+	if err != nil {
+		t.Fatalf("Servers() error = %v", err)
+	}
+	if servers[0].Version != "1.21.4" {
+		t.Errorf("got version %q after update, want %q", servers[0].Version, "1.21.4")
+	}
+
+	plugin := filepath.Join(t.TempDir(), "plugin.jar")
+	if err := os.WriteFile(plugin, []byte("jar"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.UploadPlugin(created.ID, args, plugin, "/minecraft/plugins"); err != nil {
+		t.Fatalf("UploadPlugin() error = %v", err)
+	}
+
+	if err := f.DeleteServer(created.ID, args); err != nil {
+		t.Fatalf("DeleteServer() error = %v", err)
+	}
+	list, err := f.ListServer()
+	if err != nil {
+		t.Fatalf("ListServer() error = %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("got %d servers after delete, want 0", len(list))
+	}
+	if _, err := f.GetServer(created.ID, args); err == nil {
+		t.Error("expected an error getting a deleted server")
+	}
+}
+
+func TestInjectedFailures(t *testing.T) {
+	args := testArgs()
+	tests := []struct {
+		op  Operation
+		run func(f *Fake) error
+	}{
+		{OperationCreate, func(f *Fake) error { _, err := f.CreateServer(args); return err }},
+		{OperationList, func(f *Fake) error { _, err := f.ListServer(); return err }},
+		{OperationGet, func(f *Fake) error { _, err := f.GetServer("1", args); return err }},
+		{OperationUpdate, func(f *Fake) error { return f.UpdateServer("1", args) }},
+		{OperationDelete, func(f *Fake) error { return f.DeleteServer("1", args) }},
+		{OperationUpload, func(f *Fake) error { return f.UploadPlugin("1", args, "x.jar", "/minecraft/plugins") }},
+		{OperationPing, func(f *Fake) error { _, err := f.Ping("1"); return err }},
+		{OperationStop, func(f *Fake) error { return f.StopServer("1") }},
+		{OperationStart, func(f *Fake) error { return f.StartServer("1") }},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.op), func(t *testing.T) {
+			f := newTestFake(t, tt.op)
+			if err := tt.run(f); !errors.Is(err, ErrInjected) {
+				t.Errorf("got error %v, want ErrInjected", err)
+			}
+		})
+	}
+}
+
+func TestPing(t *testing.T) {
+	f := newTestFake(t)
+	created, err := f.CreateServer(testArgs())
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	status, err := f.Ping(created.ID)
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if status.Version != "1.21.1" {
+		t.Errorf("got version %q, want 1.21.1", status.Version)
+	}
+	if _, err := f.Ping("unknown"); err == nil {
+		t.Error("Ping() of an unknown server succeeded")
+	}
+}
+
+func TestPower(t *testing.T) {
+	f := newTestFake(t)
+	created, err := f.CreateServer(testArgs())
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	assertPower := func(want string, online bool) {
+		t.Helper()
+		state, err := f.PowerState(created.ID)
+		if err != nil {
+			t.Fatalf("PowerState() error = %v", err)
+		}
+		if state != want {
+			t.Errorf("got power state %q, want %q", state, want)
+		}
+		if _, err := f.Ping(created.ID); (err == nil) != online {
+			t.Errorf("Ping() error = %v, want online %v", err, online)
+		}
+	}
+	assertPower(powerRunning, true)
+
+	if err := f.StopServer(created.ID); err != nil {
+		t.Fatalf("StopServer() error = %v", err)
+	}
+	assertPower(powerStopped, false)
+	if _, err := f.Connect(created.ID); err == nil {
+		t.Error("Connect() to a stopped server succeeded")
+	}
+
+	if err := f.StartServer(created.ID); err != nil {
+		t.Fatalf("StartServer() error = %v", err)
+	}
+	assertPower(powerRunning, true)
+
+	// stopping only Minecraft keeps the server running
+	client, err := f.Connect(created.ID)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if _, err := client.Run(t.Context(), "sudo systemctl stop minecraft.service"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	assertPower(powerRunning, false)
+	if _, err := client.Run(t.Context(), "sudo systemctl start minecraft.service"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	assertPower(powerRunning, true)
+}
+
+func TestOptionsFromValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		latency     string
+		fail        string
+		wantLatency time.Duration
+		wantFail    []Operation
+		wantErr     bool
+	}{
+		{"defaults", "/tmp/fake.json", "", "", 0, nil, false},
+		{"latency and failures", "/tmp/fake.json", "250ms", "create, delete", 250 * time.Millisecond, []Operation{OperationCreate, OperationDelete}, false},
+		{"invalid latency", "/tmp/fake.json", "fast", "", 0, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := OptionsFromValues(tt.path, tt.latency, tt.fail)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OptionsFromValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.Latency != tt.wantLatency {
+				t.Errorf("got latency %v, want %v", opts.Latency, tt.wantLatency)
+			}
+			if len(opts.Fail) != len(tt.wantFail) {
+				t.Fatalf("got failures %v, want %v", opts.Fail, tt.wantFail)
+			}
+			for i := range opts.Fail {
+				if opts.Fail[i] != tt.wantFail[i] {
+					t.Errorf("got failures %v, want %v", opts.Fail, tt.wantFail)
+				}
+			}
+		})
+	}
+}
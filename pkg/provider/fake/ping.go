@@ -0,0 +1,34 @@
+package fake
+
+import (
+	"github.com/dirien/minectl/pkg/provider"
+)
+
+// Ping answers like a server running the version of its manifest, without
+// players. Failing pings simulate an offline server.
+func (f *Fake) Ping(id string) (*provider.PingStatus, error) {
+	if err := f.simulate(OperationPing); err != nil {
+		return nil, err
+	}
+	var server Server
+	err := f.transaction(false, func(doc *document) error {
+		i, err := doc.find(id)
+		if err != nil {
+			return err
+		}
+		server = doc.Servers[i]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if server.Stopped || server.ServiceStopped {
+		return nil, errOffline
+	}
+	return &provider.PingStatus{
+		Version:    server.Version,
+		MOTD:       "Simulated " + server.Edition + " server " + server.Name,
+		MaxPlayers: 20,
+		Latency:    f.latency,
+	}, nil
+}
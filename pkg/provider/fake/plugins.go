@@ -0,0 +1,73 @@
+package fake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// findDirRegex matches the folder of the command listing the jars with their checksums.
+	findDirRegex = regexp.MustCompile(`find '([^']*)' -maxdepth 1`)
+	quotedRegex  = regexp.MustCompile(`'([^']*)'`)
+)
+
+// addPlugin records an uploaded plugin, replacing a plugin with the same path.
+func (s *Server) addPlugin(target string, content []byte) {
+	if !slices.Contains(s.Plugins, target) {
+		s.Plugins = append(s.Plugins, target)
+	}
+	if s.Jars == nil {
+		s.Jars = map[string][]byte{}
+	}
+	s.Jars[target] = content
+}
+
+func (s *Server) removePlugin(target string) {
+	s.Plugins = slices.DeleteFunc(s.Plugins, func(p string) bool { return p == target })
+	delete(s.Jars, target)
+}
+
+// listJars simulates sha256sum of the jars in the folder.
+func (s *Server) listJars(dir string) string {
+	var b strings.Builder
+	for _, plugin := range s.Plugins {
+		if path.Dir(plugin) != path.Clean(dir) || path.Ext(plugin) != ".jar" {
+			continue
+		}
+		sum := sha256.Sum256(s.Jars[plugin])
+		fmt.Fprintf(&b, "%s  %s\n", hex.EncodeToString(sum[:]), plugin)
+	}
+	return b.String()
+}
+
+// catJar simulates reading an uploaded jar.
+func (r *Remote) catJar(cmd string, stdout io.Writer) error {
+	var content []byte
+	err := r.fake.transaction(true, func(doc *document) error {
+		i, err := doc.find(r.id)
+		if err != nil {
+			return err
+		}
+		server := &doc.Servers[i]
+		server.Commands = append(server.Commands, cmd)
+		m := quotedRegex.FindStringSubmatch(cmd)
+		if m == nil || !slices.Contains(server.Plugins, m[1]) {
+			return errors.Errorf("cat: %s: No such file or directory", cmd)
+		}
+		content = server.Jars[m[1]]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(content)
+	return err
+}
@@ -0,0 +1,61 @@
+package fake
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Power states, see provider.PowerRunning and provider.PowerStopped.
+const (
+	powerRunning = "running"
+	powerStopped = "stopped"
+)
+
+// StopServer powers the simulated server off.
+func (f *Fake) StopServer(id string) error {
+	return f.setStopped(OperationStop, id, true)
+}
+
+// StartServer powers the simulated server on.
+func (f *Fake) StartServer(id string) error {
+	return f.setStopped(OperationStart, id, false)
+}
+
+// PowerState returns the power state of the simulated server.
+func (f *Fake) PowerState(id string) (string, error) {
+	if err := f.simulate(OperationGet); err != nil {
+		return "", err
+	}
+	state := powerRunning
+	err := f.transaction(false, func(doc *document) error {
+		i, err := doc.find(id)
+		if err != nil {
+			return err
+		}
+		if doc.Servers[i].Stopped {
+			state = powerStopped
+		}
+		return nil
+	})
+	return state, err
+}
+
+func (f *Fake) setStopped(op Operation, id string, stopped bool) error {
+	if err := f.simulate(op); err != nil {
+		return err
+	}
+	return f.transaction(true, func(doc *document) error {
+		i, err := doc.find(id)
+		if err != nil {
+			return err
+		}
+		doc.Servers[i].Stopped = stopped
+		if !stopped {
+			// Minecraft is started on boot
+			doc.Servers[i].ServiceStopped = false
+		}
+		return nil
+	})
+}
+
+// errOffline is returned by Ping while the server or Minecraft is stopped.
+var errOffline = errors.New("the simulated server is stopped")
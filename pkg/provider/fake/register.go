@@ -0,0 +1,24 @@
+package fake
+
+import (
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/dirien/minectl/pkg/provider"
+)
+
+// Name is the short name of the simulated provider.
+const Name = "fake"
+
+func init() {
+	provider.MustRegister(provider.Provider{
+		Name:                   Name,
+		FullName:               "Fake (local simulation)",
+		OptionalCredentialKeys: []string{EnvState, EnvLatency, EnvFail},
+		Factory: func(creds provider.Credentials, _ string) (automation.Automation, error) {
+			opts, err := OptionsFromValues(creds.Get(EnvState), creds.Get(EnvLatency), creds.Get(EnvFail))
+			if err != nil {
+				return nil, err
+			}
+			return New(opts), nil
+		},
+	})
+}
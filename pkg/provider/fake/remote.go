@@ -0,0 +1,97 @@
+package fake
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/dirien/minectl/pkg/provider"
+)
+
+// minecraftUnit is the systemd unit of Minecraft, whose state is tracked.
+const minecraftUnit = "minecraft.service"
+
+// Remote is a simulated session on a server. Files and commands are recorded
+// on the server in the state file.
+type Remote struct {
+	fake *Fake
+	id   string
+}
+
+var _ provider.RemoteClient = (*Remote)(nil)
+
+// Connect opens a simulated session on the server.
+func (f *Fake) Connect(id string) (provider.RemoteClient, error) {
+	if err := f.simulate(OperationRemote); err != nil {
+		return nil, err
+	}
+	err := f.transaction(false, func(doc *document) error {
+		i, err := doc.find(id)
+		if err != nil {
+			return err
+		}
+		if doc.Servers[i].Stopped {
+			return errOffline
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Remote{fake: f, id: id}, nil
+}
+
+// Run records the command and simulates the commands minectl relies on: the
+// Minecraft unit is stopped and started, jars are listed with their checksums
+// and removed.
+func (r *Remote) Run(_ context.Context, cmd string) (string, error) {
+	var out string
+	err := r.fake.transaction(true, func(doc *document) error {
+		i, err := doc.find(r.id)
+		if err != nil {
+			return err
+		}
+		server := &doc.Servers[i]
+		server.Commands = append(server.Commands, cmd)
+		switch {
+		case strings.Contains(cmd, "systemctl stop "+minecraftUnit):
+			server.ServiceStopped = true
+		case strings.Contains(cmd, "systemctl start "+minecraftUnit), strings.Contains(cmd, "systemctl restart "+minecraftUnit):
+			server.ServiceStopped = false
+		case strings.Contains(cmd, "sha256sum"):
+			if m := findDirRegex.FindStringSubmatch(cmd); m != nil {
+				out = server.listJars(m[1])
+			}
+		case strings.Contains(cmd, "rm -f -- "):
+			for _, m := range quotedRegex.FindAllStringSubmatch(cmd[strings.Index(cmd, "rm -f -- "):], -1) {
+				server.removePlugin(m[1])
+			}
+		}
+		return nil
+	})
+	return out, err
+}
+
+// WriteFile records the file, jars are recorded as plugins.
+func (r *Remote) WriteFile(target string, content []byte, _ os.FileMode) error {
+	return r.fake.transaction(true, func(doc *document) error {
+		i, err := doc.find(r.id)
+		if err != nil {
+			return err
+		}
+		if path.Ext(target) == ".jar" {
+			doc.Servers[i].addPlugin(target, content)
+			return nil
+		}
+		if doc.Servers[i].Files == nil {
+			doc.Servers[i].Files = map[string]string{}
+		}
+		doc.Servers[i].Files[target] = string(content)
+		return nil
+	})
+}
+
+func (r *Remote) Close() error {
+	return nil
+}
@@ -0,0 +1,133 @@
+package fake
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/pkg/errors"
+)
+
+// levelData is the part of level.dat the simulated worlds contain.
+type levelData struct {
+	Data struct {
+		LevelName string `nbt:"LevelName"`
+		Version   struct {
+			Name string `nbt:"Name"`
+		} `nbt:"Version"`
+	} `nbt:"Data"`
+}
+
+// simulatedWorld returns the files of a new world, keyed by the path below the Minecraft directory.
+func simulatedWorld(version string) map[string][]byte {
+	var level levelData
+	level.Data.LevelName = "world"
+	level.Data.Version.Name = version
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	// the encoder only fails on unsupported types
+	_ = nbt.NewEncoder(gz).Encode(level, "")
+	_ = gz.Close()
+	return map[string][]byte{
+		"world/level.dat":         b.Bytes(),
+		"world/region/r.0.0.mca":  bytes.Repeat([]byte{0}, 8192),
+		"world_nether/level.dat":  b.Bytes(),
+		"world_the_end/level.dat": b.Bytes(),
+	}
+}
+
+// Stream simulates the tar commands minectl runs to back up and restore the
+// worlds: "tar -cf -" writes the world of the server to stdout, "tar -xf -"
+// replaces the directories of the archive in stdin.
+func (r *Remote) Stream(_ context.Context, cmd string, stdin io.Reader, stdout io.Writer) error {
+	if strings.Contains(cmd, "cat -- ") {
+		return r.catJar(cmd, stdout)
+	}
+	var archive []byte
+	switch {
+	case strings.Contains(cmd, "tar -xf -"):
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		archive = data
+	case !strings.Contains(cmd, "tar -cf -"):
+		return errors.Errorf("the fake provider can not stream %q", cmd)
+	}
+	var world map[string][]byte
+	err := r.fake.transaction(true, func(doc *document) error {
+		i, err := doc.find(r.id)
+		if err != nil {
+			return err
+		}
+		server := &doc.Servers[i]
+		server.Commands = append(server.Commands, cmd)
+		if archive != nil {
+			extracted, err := untar(archive)
+			if err != nil {
+				return err
+			}
+			for path := range extracted {
+				dir, _, _ := strings.Cut(path, "/")
+				maps.DeleteFunc(server.World, func(p string, _ []byte) bool {
+					return strings.HasPrefix(p, dir+"/")
+				})
+			}
+			if server.World == nil {
+				server.World = map[string][]byte{}
+			}
+			maps.Copy(server.World, extracted)
+		}
+		world = server.World
+		return nil
+	})
+	if err != nil || archive != nil {
+		return err
+	}
+	if len(world) == 0 {
+		return errors.New("tar: Cowardly refusing to create an empty archive")
+	}
+	return writeTar(stdout, world)
+}
+
+func writeTar(w io.Writer, files map[string][]byte) error {
+	tw := tar.NewWriter(w)
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		content := files[path]
+		if err := tw.WriteHeader(&tar.Header{Name: path, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func untar(archive []byte) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "tar: invalid archive")
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[header.Name] = content
+	}
+}
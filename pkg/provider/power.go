@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/digitalocean/godo"
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/pkg/errors"
+)
+
+// Power states of a server.
+const (
+	PowerRunning = "running"
+	PowerStopped = "stopped"
+	// PowerPending is a server which is starting or stopping.
+	PowerPending = "pending"
+	// PowerUnknown is reported for providers without power management.
+	PowerUnknown = "unknown"
+)
+
+// PowerManager is implemented by providers which can power a server off and
+// on again while keeping its disks. The calls return once the provider
+// accepted the request, callers poll PowerState until it is done.
+type PowerManager interface {
+	StopServer(id string) error
+	StartServer(id string) error
+	PowerState(id string) (string, error)
+}
+
+// digitalOceanPower adds power management to the DigitalOcean automation of the SDK.
+type digitalOceanPower struct {
+	automation.Automation
+	client *godo.Client
+}
+
+func newDigitalOceanPower(auto automation.Automation, token string) *digitalOceanPower {
+	return &digitalOceanPower{Automation: auto, client: godo.NewFromToken(token)}
+}
+
+func (d *digitalOceanPower) StopServer(id string) error {
+	dropletID, err := strconv.Atoi(id)
+	if err != nil {
+		return errors.Wrapf(err, "invalid droplet id %s", id)
+	}
+	// shutdown instead of power off, so Minecraft saves the world
+	_, _, err = d.client.DropletActions.Shutdown(context.Background(), dropletID)
+	return err
+}
+
+func (d *digitalOceanPower) StartServer(id string) error {
+	dropletID, err := strconv.Atoi(id)
+	if err != nil {
+		return errors.Wrapf(err, "invalid droplet id %s", id)
+	}
+	_, _, err = d.client.DropletActions.PowerOn(context.Background(), dropletID)
+	return err
+}
+
+func (d *digitalOceanPower) PowerState(id string) (string, error) {
+	dropletID, err := strconv.Atoi(id)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid droplet id %s", id)
+	}
+	droplet, _, err := d.client.Droplets.Get(context.Background(), dropletID)
+	if err != nil {
+		return "", err
+	}
+	switch droplet.Status {
+	case "active":
+		return PowerRunning, nil
+	case "off":
+		return PowerStopped, nil
+	case "new":
+		return PowerPending, nil
+	default:
+		return PowerUnknown, nil
+	}
+}
+
+// hetznerPower adds power management to the Hetzner automation of the SDK.
+type hetznerPower struct {
+	automation.Automation
+	client *hcloud.Client
+}
+
+func newHetznerPower(auto automation.Automation, token string) *hetznerPower {
+	return &hetznerPower{Automation: auto, client: hcloud.NewClient(hcloud.WithToken(token))}
+}
+
+func hetznerServer(id string) (*hcloud.Server, error) {
+	serverID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid server id %s", id)
+	}
+	return &hcloud.Server{ID: serverID}, nil
+}
+
+func (h *hetznerPower) StopServer(id string) error {
+	server, err := hetznerServer(id)
+	if err != nil {
+		return err
+	}
+	// shutdown instead of power off, so Minecraft saves the world
+	_, _, err = h.client.Server.Shutdown(context.Background(), server)
+	return err
+}
+
+func (h *hetznerPower) StartServer(id string) error {
+	server, err := hetznerServer(id)
+	if err != nil {
+		return err
+	}
+	_, _, err = h.client.Server.Poweron(context.Background(), server)
+	return err
+}
+
+func (h *hetznerPower) PowerState(id string) (string, error) {
+	server, err := hetznerServer(id)
+	if err != nil {
+		return "", err
+	}
+	server, _, err = h.client.Server.GetByID(context.Background(), server.ID)
+	if err != nil {
+		return "", err
+	}
+	if server == nil {
+		return "", errors.Errorf("server %s not found", id)
+	}
+	switch server.Status {
+	case hcloud.ServerStatusRunning:
+		return PowerRunning, nil
+	case hcloud.ServerStatusOff:
+		return PowerStopped, nil
+	case hcloud.ServerStatusStarting, hcloud.ServerStatusStopping, hcloud.ServerStatusInitializing:
+		return PowerPending, nil
+	default:
+		return PowerUnknown, nil
+	}
+}
+
+// awsPower adds power management to the AWS automation of the SDK.
+type awsPower struct {
+	automation.Automation
+	client *ec2.Client
+}
+
+func newAWSPower(auto automation.Automation, region string) (*awsPower, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	return &awsPower{Automation: auto, client: ec2.NewFromConfig(cfg)}, nil
+}
+
+func (a *awsPower) StopServer(id string) error {
+	_, err := a.client.StopInstances(context.Background(), &ec2.StopInstancesInput{InstanceIds: []string{id}})
+	return err
+}
+
+func (a *awsPower) StartServer(id string) error {
+	_, err := a.client.StartInstances(context.Background(), &ec2.StartInstancesInput{InstanceIds: []string{id}})
+	return err
+}
+
+func (a *awsPower) PowerState(id string) (string, error) {
+	out, err := a.client.DescribeInstances(context.Background(), &ec2.DescribeInstancesInput{InstanceIds: []string{id}})
+	if err != nil {
+		return "", err
+	}
+	if len(out.Reservations) == 0 || len(out.Reservations[0].Instances) == 0 || out.Reservations[0].Instances[0].State == nil {
+		return "", errors.Errorf("instance %s not found", id)
+	}
+	switch out.Reservations[0].Instances[0].State.Name {
+	case ec2types.InstanceStateNameRunning:
+		return PowerRunning, nil
+	case ec2types.InstanceStateNameStopped:
+		return PowerStopped, nil
+	case ec2types.InstanceStateNamePending, ec2types.InstanceStateNameStopping:
+		return PowerPending, nil
+	default:
+		return PowerUnknown, nil
+	}
+}
@@ -0,0 +1,270 @@
+// Package provider is the registry of the cloud providers minectl can provision
+// servers on. Every provider registers a factory together with its credential
+// keys and capabilities, so the CLI help texts, the wizard and the validation
+// derive from one source.
+//
+// Custom builds of minectl can add their own providers by calling Register
+// from an init function before minectl.Execute is called.
+package provider
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dirien/minectl-sdk/automation"
+	"github.com/pkg/errors"
+)
+
+// Capability is an optional feature a provider supports.
+type Capability string
+
+const (
+	// Spot instances via `spec.server.spot`.
+	Spot Capability = "spot"
+	// ARM instances via `spec.server.arm`.
+	ARM Capability = "arm"
+	// Volumes via `spec.server.volumeSize`.
+	Volumes Capability = "volumes"
+	// Regions means the provider needs a region to list servers.
+	Regions Capability = "regions"
+)
+
+// Simulated is implemented by providers which do not create real servers,
+// like the fake provider. minectl skips the readiness checks for them.
+type Simulated interface {
+	Simulated() bool
+}
+
+// IsSimulated returns true if the automation only simulates servers.
+func IsSimulated(auto automation.Automation) bool {
+	s, ok := auto.(Simulated)
+	return ok && s.Simulated()
+}
+
+// Connector is implemented by providers which reach their servers without
+// SSH, like the fake provider.
+type Connector interface {
+	Connect(id string) (RemoteClient, error)
+}
+
+// Pinger is implemented by providers which answer the pings of their servers
+// themselves, like the fake provider.
+type Pinger interface {
+	Ping(id string) (*PingStatus, error)
+}
+
+// PingStatus is the answer of a server to a ping.
+type PingStatus struct {
+	Version    string
+	MOTD       string
+	Players    int
+	MaxPlayers int
+	// Sample are names of online players.
+	Sample  []string
+	Latency time.Duration
+}
+
+// Credentials holds the credentials of a provider keyed by the names in
+// Provider.CredentialKeys and Provider.OptionalCredentialKeys.
+type Credentials map[string]string
+
+// Get returns the value of the credential key.
+func (c Credentials) Get(key string) string {
+	return c[key]
+}
+
+// Export sets the credentials as environment variables which are not set yet.
+// It is used for providers whose SDK reads its configuration from the
+// environment itself.
+func (c Credentials) Export() error {
+	for key, value := range c {
+		if os.Getenv(key) != "" {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Factory creates the automation for a provider.
+type Factory func(creds Credentials, region string) (automation.Automation, error)
+
+// Provider describes a cloud provider.
+type Provider struct {
+	// Name is the short name used in manifests, e.g. "do".
+	Name string
+	// FullName is the human-readable name, e.g. "DigitalOcean".
+	FullName string
+	// CredentialKeys are the environment variables the provider requires.
+	CredentialKeys []string
+	// OptionalCredentialKeys are environment variables the provider reads when present.
+	OptionalCredentialKeys []string
+	Capabilities           []Capability
+	// SSHUser returns the user minectl logs in with via SSH. Defaults to root.
+	SSHUser func(creds Credentials) string
+	Factory Factory
+}
+
+// Supports returns true when the provider has the capability.
+func (p *Provider) Supports(c Capability) bool {
+	return slices.Contains(p.Capabilities, c)
+}
+
+// CredentialsFromEnv reads the credentials of the provider from the environment.
+func (p *Provider) CredentialsFromEnv() Credentials {
+	return p.ResolveCredentials(nil)
+}
+
+// ResolveCredentials merges the credentials of a profile with the environment.
+// Environment variables take precedence over the profile.
+func (p *Provider) ResolveCredentials(profile Credentials) Credentials {
+	creds := Credentials{}
+	for _, key := range slices.Concat(p.CredentialKeys, p.OptionalCredentialKeys) {
+		if value := profile.Get(key); value != "" {
+			creds[key] = value
+		}
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			creds[key] = value
+		}
+	}
+	return creds
+}
+
+// Validate checks that all required credential keys are set.
+func (p *Provider) Validate(creds Credentials) error {
+	var missing []string
+	for _, key := range p.CredentialKeys {
+		if creds.Get(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("missing credentials for %s, please set %s", p.FullName, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// New validates the credentials and creates the automation.
+func (p *Provider) New(creds Credentials, region string) (automation.Automation, error) {
+	if err := p.Validate(creds); err != nil {
+		return nil, err
+	}
+	return p.Factory(creds, region)
+}
+
+var (
+	mu        sync.RWMutex
+	providers []*Provider
+)
+
+// Register adds a provider to the registry.
+func Register(p Provider) error {
+	if p.Name == "" {
+		return errors.New("provider name must not be empty")
+	}
+	if p.Factory == nil {
+		return errors.Errorf("provider %s has no factory", p.Name)
+	}
+	if p.FullName == "" {
+		p.FullName = p.Name
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, registered := range providers {
+		if registered.Name == p.Name {
+			return errors.Errorf("provider %s is already registered", p.Name)
+		}
+	}
+	providers = append(providers, &p)
+	return nil
+}
+
+// MustRegister is like Register but panics on error. It is meant to be used in init functions.
+func MustRegister(p Provider) {
+	if err := Register(p); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes a provider from the registry. It is mainly useful in tests.
+func Unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers = slices.DeleteFunc(providers, func(p *Provider) bool {
+		return p.Name == name
+	})
+}
+
+// Get returns the provider with the given short name.
+func Get(name string) (*Provider, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, p := range providers {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, errors.Errorf("Could not find provider %s", name)
+}
+
+// All returns the registered providers in registration order.
+func All() []*Provider {
+	mu.RLock()
+	defer mu.RUnlock()
+	return slices.Clone(providers)
+}
+
+// Names returns the short names of the registered providers, optionally
+// filtered to providers with all the given capabilities.
+func Names(capabilities ...Capability) []string {
+	var names []string
+	for _, p := range All() {
+		supported := true
+		for _, c := range capabilities {
+			if !p.Supports(c) {
+				supported = false
+				break
+			}
+		}
+		if supported {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// FullName returns the human-readable name of the provider or the name itself if it is unknown.
+func FullName(name string) string {
+	p, err := Get(name)
+	if err != nil {
+		return name
+	}
+	return p.FullName
+}
+
+// SSHUser returns the SSH user of the provider for the given profile credentials.
+func SSHUser(name string, profile Credentials) (string, error) {
+	p, err := Get(name)
+	if err != nil {
+		return "", err
+	}
+	if p.SSHUser == nil {
+		return "root", nil
+	}
+	return p.SSHUser(p.ResolveCredentials(profile)), nil
+}
+
+// New creates the automation of the provider. The credentials are taken from
+// the environment and, if not set there, from the given profile credentials.
+func New(name, region string, profile Credentials) (automation.Automation, error) {
+	p, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return p.New(p.ResolveCredentials(profile), region)
+}
@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dirien/minectl-sdk/automation"
+)
+
+// fakeAutomation is a no-op automation.Automation used to test the registry.
+type fakeAutomation struct {
+	region string
+	token  string
+}
+
+func (f *fakeAutomation) CreateServer(_ automation.ServerArgs) (*automation.ResourceResults, error) {
+	return &automation.ResourceResults{ID: "1", Region: f.region}, nil
+}
+
+func (f *fakeAutomation) DeleteServer(_ string, _ automation.ServerArgs) error { return nil }
+
+func (f *fakeAutomation) ListServer() ([]automation.ResourceResults, error) { return nil, nil }
+
+func (f *fakeAutomation) UpdateServer(_ string, _ automation.ServerArgs) error { return nil }
+
+func (f *fakeAutomation) UploadPlugin(_ string, _ automation.ServerArgs, _, _ string) error {
+	return nil
+}
+
+func (f *fakeAutomation) GetServer(_ string, _ automation.ServerArgs) (*automation.ResourceResults, error) {
+	return &automation.ResourceResults{ID: "1", Region: f.region}, nil
+}
+
+func registerFake(t *testing.T) {
+	t.Helper()
+	err := Register(Provider{
+		Name:           "test-fake",
+		FullName:       "Test Fake",
+		CredentialKeys: []string{"TEST_FAKE_TOKEN"},
+		Capabilities:   []Capability{Spot},
+		Factory: func(creds Credentials, region string) (automation.Automation, error) {
+			return &fakeAutomation{region: region, token: creds.Get("TEST_FAKE_TOKEN")}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	t.Cleanup(func() {
+		Unregister("test-fake")
+	})
+}
+
+func TestBuiltinProviders(t *testing.T) {
+	for _, name := range []string{"do", "civo", "scaleway", "hetzner", "akamai", "ovh", "gce", "vultr", "azure", "oci", "aws", "vexxhost", "multipass", "exoscale", "fuga"} {
+		p, err := Get(name)
+		if err != nil {
+			t.Errorf("Get(%q) error = %v", name, err)
+			continue
+		}
+		if p.FullName == "" || p.Factory == nil {
+			t.Errorf("provider %q is incomplete", name)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	registerFake(t)
+
+	tests := []struct {
+		name     string
+		provider Provider
+		wantErr  string
+	}{
+		{"empty name", Provider{Factory: func(Credentials, string) (automation.Automation, error) { return nil, nil }}, "must not be empty"},
+		{"missing factory", Provider{Name: "no-factory"}, "has no factory"},
+		{"duplicate", Provider{Name: "test-fake", Factory: func(Credentials, string) (automation.Automation, error) { return nil, nil }}, "already registered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Register(tt.provider)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Register() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	registerFake(t)
+
+	t.Run("missing credentials", func(t *testing.T) {
+		t.Setenv("TEST_FAKE_TOKEN", "")
+		_, err := New("test-fake", "fra1", nil)
+		if err == nil || !strings.Contains(err.Error(), "TEST_FAKE_TOKEN") {
+			t.Errorf("New() error = %v, want missing TEST_FAKE_TOKEN", err)
+		}
+	})
+
+	t.Run("credentials from env", func(t *testing.T) {
+		t.Setenv("TEST_FAKE_TOKEN", "secret")
+		auto, err := New("test-fake", "fra1", nil)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		fake, ok := auto.(*fakeAutomation)
+		if !ok {
+			t.Fatalf("got %T, want *fakeAutomation", auto)
+		}
+		if fake.token != "secret" || fake.region != "fra1" {
+			t.Errorf("got token %q region %q", fake.token, fake.region)
+		}
+	})
+
+	t.Run("environment takes precedence over the profile", func(t *testing.T) {
+		t.Setenv("TEST_FAKE_TOKEN", "from-env")
+		auto, err := New("test-fake", "fra1", Credentials{"TEST_FAKE_TOKEN": "from-profile"})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if token := auto.(*fakeAutomation).token; token != "from-env" {
+			t.Errorf("got token %q, want %q", token, "from-env")
+		}
+	})
+
+	t.Run("credentials from the profile", func(t *testing.T) {
+		t.Setenv("TEST_FAKE_TOKEN", "")
+		auto, err := New("test-fake", "fra1", Credentials{"TEST_FAKE_TOKEN": "from-profile"})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if token := auto.(*fakeAutomation).token; token != "from-profile" {
+			t.Errorf("got token %q, want %q", token, "from-profile")
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		if _, err := New("unknown", "", nil); err == nil {
+			t.Error("expected an error for an unknown provider")
+		}
+	})
+}
+
+func TestNames(t *testing.T) {
+	registerFake(t)
+
+	spot := Names(Spot)
+	for _, want := range []string{"aws", "gce", "azure", "test-fake"} {
+		if !slices.Contains(spot, want) {
+			t.Errorf("Names(Spot) = %v, missing %q", spot, want)
+		}
+	}
+	if slices.Contains(spot, "hetzner") {
+		t.Errorf("Names(Spot) = %v, hetzner does not support spot instances", spot)
+	}
+}
@@ -0,0 +1,20 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// RemoteClient is a session on a server. minectl connects via SSH, providers
+// implementing Connector return their own clients.
+type RemoteClient interface {
+	// Run executes the command and returns its combined output.
+	Run(ctx context.Context, cmd string) (string, error)
+	// Stream executes the command with stdin as its input and copies its
+	// output to stdout. Both are optional.
+	Stream(ctx context.Context, cmd string, stdin io.Reader, stdout io.Writer) error
+	// WriteFile writes the content to the path, creating missing directories.
+	WriteFile(path string, content []byte, mode os.FileMode) error
+	Close() error
+}